@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// auditMiddleware appends a record to the audit log for every call of a write tool,
+// including calls that were rejected before the tool ran
+func auditMiddleware(auditLog *mtvmcp.AuditLog, writeTools map[string]bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			callReq, ok := req.(*mcp.CallToolRequest)
+			if !ok || !writeTools[callReq.Params.Name] {
+				return next(ctx, method, req)
+			}
+
+			ctx, trail := mtvmcp.WithAuditTrail(ctx)
+			start := time.Now()
+			result, err := next(ctx, method, req)
+
+			record := mtvmcp.AuditRecord{
+				Time:       start.UTC(),
+				User:       callerIdentity(ctx),
+				Tool:       callReq.Params.Name,
+				Commands:   trail.Commands(),
+				DurationMS: time.Since(start).Milliseconds(),
+			}
+			if callReq.Session != nil {
+				record.SessionID = callReq.Session.ID()
+			}
+			var input map[string]any
+			if json.Unmarshal(callReq.Params.Arguments, &input) == nil {
+				record.Input = mtvmcp.SanitizeInput(input)
+				record.Namespace, _ = input["namespace"].(string)
+			}
+			for _, command := range record.Commands {
+				if command.ExitCode != 0 {
+					record.ExitCode = command.ExitCode
+					break
+				}
+			}
+			if err != nil {
+				record.ErrorType = errorCategoryRejected
+			} else if callResult, ok := result.(*mcp.CallToolResult); ok && callResult.IsError {
+				_, record.ErrorType = toolErrorLabels(callResult)
+			}
+
+			if auditErr := auditLog.Append(record); auditErr != nil {
+				log.Printf("Failed to write audit record for %s: %v", record.Tool, auditErr)
+			}
+			return result, err
+		}
+	}
+}
+
+// callerIdentity describes who made the request: the authenticated user, a digest
+// of an unverified Bearer token, or the server itself
+func callerIdentity(ctx context.Context) string {
+	if user, ok := mtvmcp.GetUserInfo(ctx); ok && user.Username != "" {
+		return user.Username
+	}
+	if token, ok := mtvmcp.GetKubeToken(ctx); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		return "token:sha256:" + hex.EncodeToString(sum[:])[:16]
+	}
+	return "server"
+}
+
+// toolNames returns the names of every tool known to the server, exposed or not
+func toolNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, tool := range allTools() {
+		names[tool.Name] = true
+	}
+	return names
+}
+
+// writeToolNames returns the names of the tools that change the cluster
+func writeToolNames() map[string]bool {
+	names := make(map[string]bool)
+	for _, tool := range allTools() {
+		if !tool.ReadOnly {
+			names[tool.Name] = true
+		}
+	}
+	return names
+}
+
+// GetAuditLogInput represents the input for GetAuditLog
+type GetAuditLogInput struct {
+	Since     string `json:"since,omitempty" jsonschema:"Only records at or after this time: an RFC 3339 timestamp or a duration before now such as 1h or 30m (optional)"`
+	Until     string `json:"until,omitempty" jsonschema:"Only records at or before this time: an RFC 3339 timestamp or a duration before now (optional)"`
+	Tool      string `json:"tool,omitempty" jsonschema:"Only records of this tool, for example DeletePlan (optional)"`
+	Namespace string `json:"namespace,omitempty" jsonschema:"Only records of tool calls in this namespace (optional)"`
+	Limit     int    `json:"limit,omitempty" jsonschema:"Maximum number of most recent records to return (optional, default 100)"`
+}
+
+// getAuditLogTool returns the GetAuditLog tool definition
+func getAuditLogTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "GetAuditLog",
+		Description: `Read the audit log of write tool calls made through this server.
+
+    Every call of a create, patch, delete or lifecycle tool is recorded with its time,
+    session, caller, sanitized input (passwords and tokens redacted), the commands it ran
+    with their exit codes, and its duration. The log is only available when the server
+    runs with --audit-log.
+
+    Records are hash-chained. The result reports whether the chain of the whole log is
+    intact; chain_valid is false if any record was edited, removed or reordered.
+
+    Args:
+        since: Only records at or after this time, RFC 3339 or a duration before now such as 1h (optional)
+        until: Only records at or before this time, RFC 3339 or a duration before now (optional)
+        tool: Only records of this tool, for example DeletePlan (optional)
+        namespace: Only records of tool calls in this namespace (optional)
+        limit: Maximum number of most recent records to return (optional, default 100)
+
+    Returns:
+        JSON with the matching records, their total count and the chain integrity`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "Get Audit Log",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
+	}
+}
+
+func handleGetAuditLog(ctx context.Context, req *mcp.CallToolRequest, input GetAuditLogInput) (*mcp.CallToolResult, *mtvmcp.AuditQueryResult, error) {
+	auditLog := mtvmcp.DefaultAuditLog()
+	if auditLog == nil {
+		return nil, nil, fmt.Errorf("the audit log is not enabled, start the server with --audit-log")
+	}
+
+	now := time.Now()
+	filter := mtvmcp.AuditFilter{Tool: input.Tool, Namespace: input.Namespace, Limit: input.Limit}
+	var err error
+	if filter.Since, err = parseAuditTime(input.Since, now); err != nil {
+		return nil, nil, fmt.Errorf("invalid since: %w", err)
+	}
+	if filter.Until, err = parseAuditTime(input.Until, now); err != nil {
+		return nil, nil, fmt.Errorf("invalid until: %w", err)
+	}
+
+	result, err := auditLog.Query(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, &result, nil
+}
+
+// parseAuditTime parses an RFC 3339 timestamp, or a duration before now; empty is the zero time
+func parseAuditTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return time.Time{}, fmt.Errorf("'%s' is neither an RFC 3339 timestamp nor a duration such as 1h", value)
+	}
+	return now.Add(-duration), nil
+}
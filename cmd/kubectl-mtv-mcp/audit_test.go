@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestAuditMiddleware(t *testing.T) {
+	auditLog, err := mtvmcp.OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtvmcp.SetDefaultAuditLog(auditLog)
+	t.Cleanup(func() {
+		mtvmcp.SetDefaultAuditLog(nil)
+		_ = auditLog.Close()
+	})
+
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"delete", "plan", "-n", "demo", "--", "web"}, mtvmcp.FakeResponse{})
+	fake.On("kubectl-mtv", []string{"get", "plan", "-n", "demo", "-o", "json"}, mtvmcp.FakeResponse{Stdout: "[]"})
+	fake.Default = &mtvmcp.FakeResponse{
+		Stderr:   `Error from server (AlreadyExists): providers.forklift.konveyor.io "vsphere" already exists`,
+		ExitCode: 1,
+	}
+	fake.On("kubectl", []string{"apply", "--server-side", "--field-manager", "kubectl-mtv-mcp", "-n", "demo", "-f", "-"}, mtvmcp.FakeResponse{})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	calls := []*mcp.CallToolParams{
+		{Name: "DeletePlan", Arguments: map[string]any{"plan_name": "web", "namespace": "demo"}},
+		{Name: "ListResources", Arguments: map[string]any{"resource_type": "plan", "namespace": "demo"}},
+		{Name: "CreateProvider", Arguments: map[string]any{"provider_name": "vsphere", "provider_type": "vsphere",
+			"url": "https://vcenter", "username": "admin", "password": "secret", "namespace": "demo"}},
+	}
+	for _, params := range calls {
+		if _, err := session.CallTool(context.Background(), params); err != nil {
+			t.Fatalf("Unexpected error calling %s: %v", params.Name, err)
+		}
+	}
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "GetAuditLog",
+		Arguments: map[string]any{"since": "1h", "namespace": "demo"},
+	})
+	if err != nil || result.IsError {
+		t.Fatalf("Unexpected GetAuditLog result %v: %v", result, err)
+	}
+	data, _ := json.Marshal(result.StructuredContent)
+	var audit mtvmcp.AuditQueryResult
+	if err := json.Unmarshal(data, &audit); err != nil {
+		t.Fatal(err)
+	}
+	if !audit.ChainValid || audit.Total != 2 || len(audit.Records) != 2 {
+		t.Fatalf("Expected 2 records in a valid chain, got %s", data)
+	}
+
+	deleted, created := audit.Records[0], audit.Records[1]
+	if deleted.Tool != "DeletePlan" || deleted.User != "server" || deleted.Namespace != "demo" || deleted.ExitCode != 0 ||
+		len(deleted.Commands) != 1 || deleted.Commands[0].Command != "kubectl-mtv delete plan -n demo -- web" {
+		t.Errorf("Unexpected DeletePlan record %+v", deleted)
+	}
+	if created.Tool != "CreateProvider" || created.ExitCode != 1 || created.ErrorType != mtvmcp.ErrorTypeAlreadyExists ||
+		created.Input["password"] != "****" || created.Input["username"] != "admin" {
+		t.Errorf("Unexpected CreateProvider record %+v", created)
+	}
+	// The credentials go to a Secret over stdin, which is deleted when the create fails
+	if len(created.Commands) != 3 || !strings.HasPrefix(created.Commands[0].Command, "kubectl apply ") ||
+		!strings.HasPrefix(created.Commands[1].Command, "kubectl-mtv create provider ") ||
+		!strings.HasPrefix(created.Commands[2].Command, "kubectl delete secret vsphere-credentials-") {
+		t.Errorf("Expected apply, create and cleanup commands, got %+v", created.Commands)
+	}
+	for _, command := range created.Commands {
+		if strings.Contains(command.Command, "admin") || strings.Contains(command.Command, "--password") {
+			t.Errorf("Expected no credentials on the command line, got %s", command.Command)
+		}
+	}
+}
+
+// brokenExecutor fails every command before it can report an exit code
+type brokenExecutor struct{}
+
+func (brokenExecutor) Execute(ctx context.Context, cmd mtvmcp.Command) (mtvmcp.ExecResult, error) {
+	return mtvmcp.ExecResult{}, errors.New("broken pipe")
+}
+
+func TestAuditMiddlewareFailedCommand(t *testing.T) {
+	auditLog, err := mtvmcp.OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mtvmcp.SetDefaultAuditLog(auditLog)
+	t.Cleanup(func() {
+		mtvmcp.SetDefaultAuditLog(nil)
+		_ = auditLog.Close()
+	})
+	mtvmcp.SetDefaultExecutor(brokenExecutor{})
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "DeletePlan",
+		Arguments: map[string]any{"plan_name": "web", "namespace": "demo"},
+	})
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected DeletePlan to fail, got %v, %v", result, err)
+	}
+
+	// The delete may have reached the cluster, so it is audited although it has no exit code
+	audit, err := auditLog.Query(mtvmcp.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.Records) != 1 {
+		t.Fatalf("Expected a single audit record, got %+v", audit.Records)
+	}
+	record := audit.Records[0]
+	if record.Tool != "DeletePlan" || record.ExitCode != -1 || len(record.Commands) != 1 ||
+		record.Commands[0].Command != "kubectl-mtv delete plan -n demo -- web" || record.Commands[0].ExitCode != -1 {
+		t.Errorf("Expected the failed delete with exit code -1, got %+v", record)
+	}
+}
+
+func TestCallerIdentity(t *testing.T) {
+	ctx := context.Background()
+	if got := callerIdentity(ctx); got != "server" {
+		t.Errorf("Expected server, got %s", got)
+	}
+	ctx = mtvmcp.WithKubeToken(ctx, "test")
+	if got := callerIdentity(ctx); got != "token:sha256:9f86d081884c7d65" {
+		t.Errorf("Expected a token digest, got %s", got)
+	}
+	ctx = mtvmcp.WithUserInfo(ctx, mtvmcp.UserInfo{Username: "alice"})
+	if got := callerIdentity(ctx); got != "alice" {
+		t.Errorf("Expected alice, got %s", got)
+	}
+}
+
+func TestParseAuditTime(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"2026-10-01T08:00:00Z", time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseAuditTime(tt.value, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("parseAuditTime(%q) = %v, %v; expected %v (error %v)", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// cacheInvalidationMiddleware drops the cached results of the namespace of every write
+// tool call once it returns, so later reads see the change. A call without a namespace
+// may change the default namespace and drops every cached result.
+func cacheInvalidationMiddleware(writeTools map[string]bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			callReq, ok := req.(*mcp.CallToolRequest)
+			if !ok || !writeTools[callReq.Params.Name] {
+				return next(ctx, method, req)
+			}
+
+			result, err := next(ctx, method, req)
+			var input struct {
+				Namespace string `json:"namespace"`
+			}
+			_ = json.Unmarshal(callReq.Params.Arguments, &input)
+			mtvmcp.InvalidateCache(input.Namespace)
+			return result, err
+		}
+	}
+}
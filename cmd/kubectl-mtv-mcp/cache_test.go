@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestCacheInvalidatedByWriteTools(t *testing.T) {
+	mtvmcp.SetCacheOptions(mtvmcp.CacheOptions{TTL: time.Minute})
+	t.Cleanup(func() { mtvmcp.SetCacheOptions(mtvmcp.CacheOptions{}) })
+
+	inventory := []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", inventory, mtvmcp.FakeResponse{Stdout: "[]"})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	call := func(name string, arguments map[string]any) {
+		t.Helper()
+		result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: name, Arguments: arguments})
+		if err != nil || result.IsError {
+			t.Fatalf("Unexpected %s result %v: %v", name, result, err)
+		}
+	}
+	inventoryRuns := func() int {
+		runs := 0
+		for _, command := range fake.Calls() {
+			if command.Args[0] == "get" {
+				runs++
+			}
+		}
+		return runs
+	}
+	listVMs := map[string]any{"resource_type": "vm", "provider_name": "vsphere", "namespace": "demo"}
+
+	call("ListInventory", listVMs)
+	call("ListInventory", listVMs)
+	if runs := inventoryRuns(); runs != 1 {
+		t.Fatalf("Expected the second query to be cached, got %d runs", runs)
+	}
+
+	call("DeletePlan", map[string]any{"plan_name": "web", "namespace": "other"})
+	call("ListInventory", listVMs)
+	if runs := inventoryRuns(); runs != 1 {
+		t.Errorf("Expected a write in another namespace to keep the result, got %d runs", runs)
+	}
+
+	call("DeletePlan", map[string]any{"plan_name": "web", "namespace": "demo"})
+	call("ListInventory", listVMs)
+	if runs := inventoryRuns(); runs != 2 {
+		t.Errorf("Expected a write in the namespace to drop the result, got %d runs", runs)
+	}
+
+	call("ListInventory", map[string]any{"resource_type": "vm", "provider_name": "vsphere", "namespace": "demo", "refresh": true})
+	if runs := inventoryRuns(); runs != 3 {
+		t.Errorf("Expected refresh to bypass the cache, got %d runs", runs)
+	}
+}
@@ -4,12 +4,11 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"log"
-	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 
-	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
 )
 
@@ -20,10 +19,43 @@ func Execute() error {
 	version := flag.Bool("version", false, "Print version information and exit")
 	help := flag.Bool("help", false, "Print help information and exit")
 	sse := flag.Bool("sse", false, "Run in SSE (Server-Sent Events) mode over HTTP")
-	port := flag.String("port", "8080", "Port to listen on for SSE mode")
-	host := flag.String("host", "127.0.0.1", "Host address to bind to for SSE mode")
+	httpMode := flag.Bool("http", false, "Run in Streamable HTTP mode over HTTP (serves /mcp, plus legacy SSE on /sse)")
+	port := flag.String("port", "8080", "Port to listen on for HTTP/SSE modes")
+	host := flag.String("host", "127.0.0.1", "Host address to bind to for HTTP/SSE modes")
 	tlsCert := flag.String("tls-cert", "", "Path to TLS certificate file (enables HTTPS)")
 	tlsKey := flag.String("tls-key", "", "Path to TLS private key file (enables HTTPS)")
+	readOnly := flag.Bool("read-only", false, "Register only read tools and refuse calls to write tools")
+	profile := flag.String("profile", ProfileAdmin, "Tool profile to expose: viewer, operator or admin")
+	tokenProfiles := flag.String("token-profiles", "", "Path to a JSON file mapping SHA-256 token digests to profiles (HTTP/SSE modes)")
+	enableTools := flag.String("enable-tools", "", "Comma-separated list of tools to expose in addition to the profile")
+	disableTools := flag.String("disable-tools", "", "Comma-separated list of tools to remove from the profile")
+	commandTimeout := flag.Duration("command-timeout", mtvmcp.DefaultCommandTimeout, "Default timeout for each kubectl/kubectl-mtv command (0 disables the timeout)")
+	record := flag.String("record", "", "Record every executed command (redacted argv, stdout, stderr, exit code) as fixtures in this directory")
+	replay := flag.String("replay", "", "Serve commands from fixtures recorded in this directory instead of running them")
+	mustGather := flag.String("must-gather", "", "Serve read tools offline from an extracted must-gather directory (write tools are disabled)")
+	kubeconfig := flag.String("kubeconfig", "", "Path to the kubeconfig file used by every kubectl/kubectl-mtv command")
+	kubeContext := flag.String("context", "", "Kubeconfig context used by every kubectl/kubectl-mtv command")
+	server := flag.String("server", "", "Kubernetes API server address used by every kubectl/kubectl-mtv command")
+	clusters := flag.String("clusters", "", "Path to a YAML/JSON cluster registry mapping cluster names to kubeconfig contexts and inventory URLs")
+	tokenReview := flag.String("token-review", mtvmcp.TokenReviewNone, "Verify HTTP/SSE Bearer tokens before accepting a session: none, self-subject-review or token-review")
+	requireToken := flag.Bool("require-token", false, "Require a Bearer token for every request, disabling the fallback to the server kubeconfig")
+	impersonate := flag.Bool("impersonate", false, "Run commands as the request user with --as/--as-group (HTTP/SSE modes, needs --token-review or --trusted-proxies)")
+	trustedProxies := flag.String("trusted-proxies", "", "Comma-separated IP addresses or CIDR ranges of proxies allowed to set the impersonated identity headers")
+	userHeader := flag.String("impersonation-user-header", defaultImpersonationUserHeader, "Header holding the user name set by a trusted proxy")
+	groupHeader := flag.String("impersonation-group-header", defaultImpersonationGroupHeader, "Header holding the user groups set by a trusted proxy (repeated or comma-separated)")
+	healthPort := flag.String("health-port", "", "Serve /healthz, /readyz, /version and /metrics on this port instead of the MCP port (HTTP/SSE modes)")
+	readinessTimeout := flag.Duration("readiness-timeout", DefaultReadinessTimeout, "Time budget of the kubectl-mtv version call made by /readyz")
+	auditLog := flag.String("audit-log", "", "Append a hash-chained JSONL record of every write tool call to this file (readable with GetAuditLog)")
+	maxSubprocesses := flag.Int("max-subprocesses", mtvmcp.DefaultMaxConcurrentSubprocesses, "Maximum number of kubectl/kubectl-mtv commands running at once across all clients (0 disables the cap)")
+	rateLimit := flag.Float64("rate-limit", 0, "Commands per second each token, user or session may start (0 disables rate limiting)")
+	rateLimitBurst := flag.Int("rate-limit-burst", mtvmcp.DefaultRateLimitBurst, "Number of commands a token, user or session may start at once before --rate-limit applies")
+	shutdownGracePeriod := flag.Duration("shutdown-grace-period", DefaultShutdownGracePeriod, "How long in-flight tool calls may run after SIGINT/SIGTERM before they are cancelled")
+	redactFlags := flag.String("redact-flags", "", "Comma-separated flags whose values are redacted in addition to --password, --token and --cacert, such as --username,--luks-secret")
+	redactionRules := flag.String("redaction-rules", "", "Path to a YAML/JSON file with extra redaction rules (flags, jsonPaths, patterns) for command output")
+	cacheTTL := flag.Duration("cache-ttl", mtvmcp.DefaultCacheTTL, "How long ListInventory results are served from the in-memory cache (0 disables the cache)")
+	cacheSizeMB := flag.Int("cache-size-mb", mtvmcp.DefaultCacheMaxBytes>>20, "Maximum total size in MiB of the cached results (0 disables the cache)")
+	maxResponseBytes := flag.Int("max-response-bytes", mtvmcp.DefaultMaxResponseBytes, "Maximum size in bytes of the items in one ListInventory, ListResources, GetLogs or GetMigrationStorage response before it is split into pages (0 disables pagination)")
+	tokenFile := flag.String("token-file", "", "Path to a file holding the bearer token used in stdio mode (read on every request)")
 	flag.Parse()
 
 	if *help {
@@ -38,6 +70,68 @@ func Execute() error {
 		fmt.Fprintf(os.Stderr, "\nModes:\n")
 		fmt.Fprintf(os.Stderr, "  Default: The server communicates via stdio using the MCP protocol.\n")
 		fmt.Fprintf(os.Stderr, "  SSE mode: The server runs an HTTP/HTTPS server for SSE-based MCP connections.\n")
+		fmt.Fprintf(os.Stderr, "  HTTP mode: The server runs an HTTP/HTTPS server for Streamable HTTP MCP connections\n")
+		fmt.Fprintf(os.Stderr, "             on /mcp, and keeps serving legacy SSE connections on /sse.\n")
+		fmt.Fprintf(os.Stderr, "\nRead-only mode:\n")
+		fmt.Fprintf(os.Stderr, "  Use --read-only to expose only the list/get tools. In HTTP/SSE modes clients can\n")
+		fmt.Fprintf(os.Stderr, "  also request a read-only session by sending the %s: true header.\n", readOnlyHeader)
+		fmt.Fprintf(os.Stderr, "\nTool profiles:\n")
+		fmt.Fprintf(os.Stderr, "  viewer:   list/get tools only.\n")
+		fmt.Fprintf(os.Stderr, "  operator: viewer tools plus ManagePlanLifecycle, PatchPlan and PatchPlanVm.\n")
+		fmt.Fprintf(os.Stderr, "  admin:    all tools, including provider, host, hook, delete and GetAuditLog tools (default).\n")
+		fmt.Fprintf(os.Stderr, "  In HTTP/SSE modes, --token-profiles selects the profile per request from the Bearer token.\n")
+		fmt.Fprintf(os.Stderr, "  Use --enable-tools and --disable-tools to adjust the profile's tool set.\n")
+		fmt.Fprintf(os.Stderr, "\nCluster selection:\n")
+		fmt.Fprintf(os.Stderr, "  Use --kubeconfig, --context and --server to point every kubectl/kubectl-mtv command\n")
+		fmt.Fprintf(os.Stderr, "  at a cluster without changing the current context in ~/.kube/config.\n")
+		fmt.Fprintf(os.Stderr, "  Use --clusters FILE to register several clusters; every tool takes an optional cluster\n")
+		fmt.Fprintf(os.Stderr, "  argument, and ListClusters reports their reachability and MTV version.\n")
+		fmt.Fprintf(os.Stderr, "  In stdio mode a bearer token is read from --token-file, or from the %s\n", tokenEnvVar)
+		fmt.Fprintf(os.Stderr, "  environment variable. In HTTP/SSE modes it is taken from the Authorization header.\n")
+		fmt.Fprintf(os.Stderr, "\nAuthentication:\n")
+		fmt.Fprintf(os.Stderr, "  In HTTP/SSE modes, --token-review verifies each Bearer token with a SelfSubjectReview\n")
+		fmt.Fprintf(os.Stderr, "  or TokenReview before a session is created, and rejects missing or invalid tokens\n")
+		fmt.Fprintf(os.Stderr, "  with 401. --require-token rejects requests without a token in every mode, so the\n")
+		fmt.Fprintf(os.Stderr, "  server kubeconfig credentials are never used for client requests.\n")
+		fmt.Fprintf(os.Stderr, "  --impersonate runs every command with the server credentials as the request user\n")
+		fmt.Fprintf(os.Stderr, "  (--as/--as-group). The user comes from the %s/%s headers, honored only\n", defaultImpersonationUserHeader, defaultImpersonationGroupHeader)
+		fmt.Fprintf(os.Stderr, "  from --trusted-proxies sources, or from a token verified with --token-review.\n")
+		fmt.Fprintf(os.Stderr, "\nRecord and replay:\n")
+		fmt.Fprintf(os.Stderr, "  Use --record DIR to capture every kubectl/kubectl-mtv command as a fixture file,\n")
+		fmt.Fprintf(os.Stderr, "  and --replay DIR to answer tool calls from those fixtures without a cluster.\n")
+		fmt.Fprintf(os.Stderr, "\nMust-gather mode:\n")
+		fmt.Fprintf(os.Stderr, "  Use --must-gather DIR to answer ListResources, GetPlanVms, GetMigrationStorage and\n")
+		fmt.Fprintf(os.Stderr, "  GetLogs from an extracted must-gather archive, without cluster access.\n")
+		fmt.Fprintf(os.Stderr, "\nAudit log:\n")
+		fmt.Fprintf(os.Stderr, "  Use --audit-log FILE to append a record of every create, patch, delete and lifecycle\n")
+		fmt.Fprintf(os.Stderr, "  tool call (caller, sanitized input, commands, exit code, duration). Records are\n")
+		fmt.Fprintf(os.Stderr, "  hash-chained so edits are detectable; the GetAuditLog tool queries the log.\n")
+		fmt.Fprintf(os.Stderr, "\nRedaction:\n")
+		fmt.Fprintf(os.Stderr, "  Command output returned to clients or recorded in fixtures is redacted, masking\n")
+		fmt.Fprintf(os.Stderr, "  Secret data, Authorization headers, bearer tokens, PEM blocks and the values of\n")
+		fmt.Fprintf(os.Stderr, "  --password, --token and --cacert. Use --redact-flags to redact more flag values and\n")
+		fmt.Fprintf(os.Stderr, "  --redaction-rules FILE to add JSON paths and regular expressions.\n")
+		fmt.Fprintf(os.Stderr, "\nHealth endpoints:\n")
+		fmt.Fprintf(os.Stderr, "  In HTTP/SSE modes /healthz (liveness), /readyz (kubectl and kubectl-mtv on PATH and\n")
+		fmt.Fprintf(os.Stderr, "  a kubectl-mtv version call within --readiness-timeout), /version and /metrics\n")
+		fmt.Fprintf(os.Stderr, "  (Prometheus text format) are served without authentication, on the MCP port or\n")
+		fmt.Fprintf(os.Stderr, "  on --health-port.\n")
+		fmt.Fprintf(os.Stderr, "\nLimits:\n")
+		fmt.Fprintf(os.Stderr, "  --max-subprocesses caps the kubectl/kubectl-mtv commands running at once, and\n")
+		fmt.Fprintf(os.Stderr, "  --rate-limit/--rate-limit-burst apply a token bucket per Bearer token, user or\n")
+		fmt.Fprintf(os.Stderr, "  session. A call over a limit fails with \"rate limited, retry after N seconds\".\n")
+		fmt.Fprintf(os.Stderr, "\nCaching:\n")
+		fmt.Fprintf(os.Stderr, "  ListInventory results are cached in memory for --cache-ttl, per Bearer token or\n")
+		fmt.Fprintf(os.Stderr, "  impersonated user, up to --cache-size-mb. A write tool call drops the cached results\n")
+		fmt.Fprintf(os.Stderr, "  of its namespace, and refresh=true bypasses the cache for one call.\n")
+		fmt.Fprintf(os.Stderr, "\nPagination:\n")
+		fmt.Fprintf(os.Stderr, "  ListInventory, ListResources, GetLogs and GetMigrationStorage results larger than\n")
+		fmt.Fprintf(os.Stderr, "  --max-response-bytes are split into pages. The response reports total, truncated and\n")
+		fmt.Fprintf(os.Stderr, "  next_cursor, which the client passes back as cursor to get the next page.\n")
+		fmt.Fprintf(os.Stderr, "\nShutdown:\n")
+		fmt.Fprintf(os.Stderr, "  On SIGINT or SIGTERM the server stops accepting new sessions and tool calls, lets\n")
+		fmt.Fprintf(os.Stderr, "  in-flight tool calls finish for up to --shutdown-grace-period, then cancels the rest\n")
+		fmt.Fprintf(os.Stderr, "  and logs the interrupted commands. A second signal exits immediately.\n")
 		fmt.Fprintf(os.Stderr, "\nTLS/HTTPS:\n")
 		fmt.Fprintf(os.Stderr, "  To enable HTTPS, provide both --tls-cert and --tls-key flags.\n")
 		fmt.Fprintf(os.Stderr, "  Without these flags, the server runs over HTTP (not secure for production).\n")
@@ -50,64 +144,201 @@ func Execute() error {
 		return nil
 	}
 
-	if *sse {
-		// SSE mode - run HTTP/HTTPS server
-		addr := *host + ":" + *port
+	mtvmcp.SetDefaultTimeout(*commandTimeout)
 
-		// Validate TLS configuration
-		useTLS := false
-		if *tlsCert != "" || *tlsKey != "" {
-			if *tlsCert == "" || *tlsKey == "" {
-				return fmt.Errorf("both --tls-cert and --tls-key must be provided for HTTPS")
-			}
-			useTLS = true
+	redaction := mtvmcp.DefaultRedactionRules()
+	if *redactionRules != "" {
+		rules, err := mtvmcp.LoadRedactionRules(*redactionRules)
+		if err != nil {
+			return err
 		}
+		redaction = redaction.Merge(rules)
+	}
+	redaction.Flags = append(redaction.Flags, strings.Split(*redactFlags, ",")...)
+	if err := mtvmcp.SetRedactionRules(redaction); err != nil {
+		return err
+	}
+
+	if *maxSubprocesses < 0 || *rateLimit < 0 || *rateLimitBurst < 0 {
+		return fmt.Errorf("--max-subprocesses, --rate-limit and --rate-limit-burst cannot be negative")
+	}
+	mtvmcp.SetLimits(mtvmcp.Limits{MaxConcurrent: *maxSubprocesses, Rate: *rateLimit, Burst: *rateLimitBurst})
+
+	if *cacheTTL < 0 || *cacheSizeMB < 0 {
+		return fmt.Errorf("--cache-ttl and --cache-size-mb cannot be negative")
+	}
+	if *cacheSizeMB == 0 {
+		*cacheTTL = 0
+	}
+	mtvmcp.SetCacheOptions(mtvmcp.CacheOptions{TTL: *cacheTTL, MaxBytes: int64(*cacheSizeMB) << 20})
+
+	if *maxResponseBytes < 0 {
+		return fmt.Errorf("--max-response-bytes cannot be negative")
+	}
+	mtvmcp.SetMaxResponseBytes(*maxResponseBytes)
+
+	if *record != "" && *replay != "" {
+		return fmt.Errorf("--record and --replay cannot be used together")
+	}
+	if *mustGather != "" && *replay != "" {
+		return fmt.Errorf("--must-gather and --replay cannot be used together")
+	}
 
-		// Create SSE handler
-		sseHandler := mcp.NewSSEHandler(func(req *http.Request) *mcp.Server {
-			return CreateReadServer()
-		}, nil)
-
-		// Wrap handler with middleware to extract token from Authorization header
-		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Extract Bearer token from Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader != "" {
-				// Check if it's a Bearer token
-				parts := strings.SplitN(authHeader, " ", 2)
-				if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
-					token := parts[1]
-					// Add token to request context
-					ctx := mtvmcp.WithKubeToken(r.Context(), token)
-					r = r.WithContext(ctx)
-					log.Printf("Token received via Authorization header (length: %d)", len(token))
-				}
+	if *mustGather != "" && (*kubeconfig != "" || *kubeContext != "" || *server != "" || *clusters != "" || *impersonate) {
+		return fmt.Errorf("--kubeconfig, --context, --server, --clusters and --impersonate cannot be used with --must-gather")
+	}
+	if err := mtvmcp.ValidateTokenReviewMode(*tokenReview); err != nil {
+		return err
+	}
+	if *tokenReview != mtvmcp.TokenReviewNone && !*sse && !*httpMode {
+		return fmt.Errorf("--token-review is only supported in HTTP/SSE modes")
+	}
+	var impersonation *impersonationOptions
+	if *impersonate {
+		if !*sse && !*httpMode {
+			return fmt.Errorf("--impersonate is only supported in HTTP/SSE modes")
+		}
+		proxies, err := parseTrustedProxies(*trustedProxies)
+		if err != nil {
+			return err
+		}
+		if len(proxies) == 0 && *tokenReview == mtvmcp.TokenReviewNone {
+			return fmt.Errorf("--impersonate needs --trusted-proxies or --token-review to identify the request user")
+		}
+		impersonation = &impersonationOptions{UserHeader: *userHeader, GroupHeader: *groupHeader, TrustedProxies: proxies}
+	} else if *trustedProxies != "" {
+		return fmt.Errorf("--trusted-proxies is only used with --impersonate")
+	}
+	if *tokenFile != "" && (*sse || *httpMode) {
+		return fmt.Errorf("--token-file is only supported in stdio mode, HTTP/SSE clients send a Bearer token")
+	}
+	mtvmcp.SetDefaultKubeConfig(mtvmcp.KubeConfig{
+		Kubeconfig: *kubeconfig,
+		Context:    *kubeContext,
+		Server:     *server,
+	})
+	if *clusters != "" {
+		registry, err := mtvmcp.LoadClusterRegistry(*clusters)
+		if err != nil {
+			return err
+		}
+		mtvmcp.SetClusterRegistry(registry)
+	}
+
+	// Offline must-gather backend: answers read commands from a captured cluster dump
+	var snapshot *mtvmcp.SnapshotInfo
+	if *mustGather != "" {
+		executor, err := mtvmcp.NewMustGatherExecutor(*mustGather)
+		if err != nil {
+			return err
+		}
+		info := executor.Snapshot()
+		snapshot = &info
+		mtvmcp.SetDefaultExecutor(executor)
+	}
+
+	// Record and replay command fixtures
+	if *record != "" {
+		recorder, err := mtvmcp.NewRecordingExecutor(mtvmcp.GetExecutor(context.Background()), *record)
+		if err != nil {
+			return err
+		}
+		mtvmcp.SetDefaultExecutor(recorder)
+	}
+	if *replay != "" {
+		replayer, err := mtvmcp.NewReplayExecutor(*replay)
+		if err != nil {
+			return err
+		}
+		mtvmcp.SetDefaultExecutor(replayer)
+	}
+
+	// Audit log of write tool calls
+	if *auditLog != "" {
+		audit, err := mtvmcp.OpenAuditLog(*auditLog)
+		if err != nil {
+			return err
+		}
+		defer audit.Close()
+		mtvmcp.SetDefaultAuditLog(audit)
+	}
+
+	serverOpts := ServerOptions{
+		ReadOnly:     *readOnly,
+		Profile:      *profile,
+		EnableTools:  ParseToolList(*enableTools),
+		DisableTools: ParseToolList(*disableTools),
+		Snapshot:     snapshot,
+	}
+	if err := ValidateProfile(serverOpts.Profile); err != nil {
+		return err
+	}
+	if err := ValidateToolNames(append(serverOpts.EnableTools, serverOpts.DisableTools...)); err != nil {
+		return err
+	}
+
+	var profiles TokenProfiles
+	if *tokenProfiles != "" {
+		var err error
+		profiles, err = LoadTokenProfiles(*tokenProfiles)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Shut down gracefully on SIGINT/SIGTERM; after the first signal the default
+	// handling is restored, so a second signal exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if *sse || *httpMode {
+		var reviewer *mtvmcp.TokenReviewer
+		if *tokenReview != mtvmcp.TokenReviewNone {
+			var err error
+			reviewer, err = mtvmcp.NewTokenReviewer(*tokenReview)
+			if err != nil {
+				return err
 			}
-			sseHandler.ServeHTTP(w, r)
-		})
-
-		if useTLS {
-			protocol := "https"
-			log.Printf("Starting kubectl-mtv MCP server in SSE mode on %s", addr)
-			log.Printf("Protocol: HTTPS (TLS enabled)")
-			log.Printf("TLS Certificate: %s", *tlsCert)
-			log.Printf("TLS Key: %s", *tlsKey)
-			log.Printf("Connect clients to: %s://%s/sse", protocol, addr)
-			log.Printf("Token authentication: Enabled via Authorization header (Bearer token)")
-
-			return http.ListenAndServeTLS(addr, *tlsCert, *tlsKey, handler)
-		} else {
-			protocol := "http"
-			log.Printf("Starting kubectl-mtv MCP server in SSE mode on %s", addr)
-			log.Printf("Protocol: HTTP (TLS disabled - use --tls-cert and --tls-key for HTTPS)")
-			log.Printf("Connect clients to: %s://%s/sse", protocol, addr)
-			log.Printf("Token authentication: Enabled via Authorization header (Bearer token)")
-
-			return http.ListenAndServe(addr, handler)
 		}
+
+		// HTTP based modes - run HTTP/HTTPS server
+		opts := httpOptions{
+			Addr:                *host + ":" + *port,
+			TLSCert:             *tlsCert,
+			TLSKey:              *tlsKey,
+			Streamable:          *httpMode,
+			Server:              serverOpts,
+			TokenProfiles:       profiles,
+			RequireToken:        *requireToken,
+			TokenReviewer:       reviewer,
+			Impersonation:       impersonation,
+			ShutdownGracePeriod: *shutdownGracePeriod,
+			Health: healthOptions{
+				ReadinessTimeout: *readinessTimeout,
+				SkipBinaryCheck:  *mustGather != "" || *replay != "",
+			},
+		}
+		if *healthPort != "" {
+			opts.Health.Addr = *host + ":" + *healthPort
+		}
+		return serveHTTP(ctx, opts)
 	}
 
 	// Stdio mode - default behavior
-	server := CreateReadServer()
-	return server.Run(context.Background(), &mcp.StdioTransport{})
+	tokens, err := stdioTokenSource(*tokenFile)
+	if err != nil {
+		return err
+	}
+	if tokens == nil && *requireToken {
+		return fmt.Errorf("--require-token needs a token from --token-file or the %s environment variable in stdio mode", tokenEnvVar)
+	}
+	mcpServer := CreateServer(serverOpts)
+	if tokens != nil {
+		mcpServer.AddReceivingMiddleware(stdioTokenMiddleware(tokens))
+	}
+	return serveStdio(ctx, mcpServer, *shutdownGracePeriod)
 }
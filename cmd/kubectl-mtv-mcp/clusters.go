@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// defaultClusterCheckTimeout bounds the reachability check of a single cluster
+const defaultClusterCheckTimeout = 15 * time.Second
+
+// ClusterStatus is the reachability and MTV version of a registered cluster
+type ClusterStatus struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description,omitempty"`
+	Context           string       `json:"context,omitempty"`
+	Server            string       `json:"server,omitempty"`
+	InventoryURL      string       `json:"inventoryURL,omitempty"`
+	Default           bool         `json:"default,omitempty" jsonschema:"True for the cluster used when a tool call does not name one"`
+	Reachable         bool         `json:"reachable" jsonschema:"True if the Kubernetes API server answered"`
+	KubernetesVersion string       `json:"kubernetesVersion,omitempty"`
+	Version           *VersionInfo `json:"version,omitempty" jsonschema:"kubectl-mtv and MTV operator version information"`
+	Error             string       `json:"error,omitempty" jsonschema:"Why the cluster or the MTV operator could not be queried"`
+}
+
+// ListClustersOutput is the result of the ListClusters tool
+type ListClustersOutput struct {
+	Clusters []ClusterStatus `json:"clusters,omitempty" jsonschema:"The registered clusters"`
+}
+
+// ListClustersInput represents the input for ListClusters
+type ListClustersInput struct {
+	TimeoutSeconds int `json:"timeout_seconds,omitempty" jsonschema:"Maximum time in seconds to check each cluster (optional, default 15, cannot exceed the server command timeout)"`
+}
+
+// listClustersTool returns the ListClusters tool definition
+func listClustersTool() *mcp.Tool {
+	return &mcp.Tool{
+		Name: "ListClusters",
+		Description: `List the clusters this server can run commands on.
+
+    Clusters are defined in the server cluster registry (--clusters). Pass a cluster name
+    as the 'cluster' argument of any other tool to run its commands on that cluster.
+    Without a registry, the server kubeconfig cluster is listed as 'default'.
+
+    For each cluster reports whether the Kubernetes API server is reachable, the
+    Kubernetes version, and the kubectl-mtv and MTV operator versions.
+
+    Args:
+        timeout_seconds: Maximum time in seconds to check each cluster (optional, default 15, cannot exceed the server command timeout)
+
+    Returns:
+        JSON list of clusters with reachability and version information`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "List Clusters",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
+	}
+}
+
+func handleListClusters(ctx context.Context, req *mcp.CallToolRequest, input ListClustersInput) (*mcp.CallToolResult, *ListClustersOutput, error) {
+	// The server command timeout bounds the check, a caller can only shorten it
+	timeout := mtvmcp.CallTimeout(int(defaultClusterCheckTimeout / time.Second))
+	if input.TimeoutSeconds > 0 {
+		timeout = mtvmcp.CallTimeout(input.TimeoutSeconds)
+	}
+
+	// Without a registry, report the cluster of the server kubeconfig
+	clusters := []mtvmcp.Cluster{{Name: "default", Description: "Cluster of the server kubeconfig"}}
+	defaultCluster := "default"
+	registry := mtvmcp.GetClusterRegistry()
+	if registry != nil {
+		clusters = registry.Clusters
+		defaultCluster = registry.Default
+	}
+
+	// Check the clusters concurrently, so unreachable clusters do not add up
+	statuses := make([]ClusterStatus, len(clusters))
+	var wg sync.WaitGroup
+	for i, cluster := range clusters {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses[i] = checkCluster(ctx, cluster, registry != nil, timeout)
+			statuses[i].Default = cluster.Name == defaultCluster
+		}()
+	}
+	wg.Wait()
+
+	return nil, &ListClustersOutput{Clusters: statuses}, nil
+}
+
+// checkCluster reports the reachability and MTV version of a cluster
+func checkCluster(ctx context.Context, cluster mtvmcp.Cluster, registered bool, timeout time.Duration) ClusterStatus {
+	status := ClusterStatus{
+		Name:         cluster.Name,
+		Description:  cluster.Description,
+		Context:      cluster.Context,
+		Server:       cluster.Server,
+		InventoryURL: cluster.InventoryURL,
+	}
+
+	if registered {
+		var err error
+		if ctx, err = mtvmcp.WithCluster(ctx, cluster.Name); err != nil {
+			status.Error = err.Error()
+			return status
+		}
+	}
+	ctx, cancel := mtvmcp.WithCommandTimeout(ctx, timeout)
+	defer cancel()
+
+	// The API server version is readable by any authenticated user
+	output, err := runJSONCommand(ctx, mtvmcp.RunKubectlCommand, []string{"version", "-o", "json"})
+	if err != nil {
+		status.Error = commandErrorMessage(err)
+		return status
+	}
+	var kubeVersion struct {
+		ServerVersion *struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"serverVersion"`
+	}
+	if !output.DecodeStdout(&kubeVersion) || kubeVersion.ServerVersion == nil {
+		status.Error = "the Kubernetes API server did not report its version"
+		return status
+	}
+	status.Reachable = true
+	status.KubernetesVersion = kubeVersion.ServerVersion.GitVersion
+
+	output, err = runJSONCommand(ctx, mtvmcp.RunKubectlMTVCommand, []string{"version", "-o", "json"})
+	if err != nil {
+		status.Error = commandErrorMessage(err)
+		return status
+	}
+	var version VersionInfo
+	if output.DecodeStdout(&version) {
+		status.Version = &version
+	}
+	return status
+}
+
+// runJSONCommand runs a command and parses its structured response
+func runJSONCommand(ctx context.Context, run func(context.Context, []string) (string, error), args []string) (mtvmcp.CommandOutput, error) {
+	result, err := run(ctx, args)
+	if err != nil {
+		return mtvmcp.CommandOutput{}, err
+	}
+	return mtvmcp.ParseCommandOutput(result)
+}
+
+// commandErrorMessage returns the short message of a classified command error
+func commandErrorMessage(err error) string {
+	var commandErr *mtvmcp.CommandError
+	if errors.As(err, &commandErr) {
+		return commandErr.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"context"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestListClusters(t *testing.T) {
+	mtvmcp.SetClusterRegistry(&mtvmcp.ClusterRegistry{
+		Default: "prod",
+		Clusters: []mtvmcp.Cluster{
+			{Name: "prod", Context: "prod-admin"},
+			{Name: "lab", Context: "lab"},
+		},
+	})
+	t.Cleanup(func() { mtvmcp.SetClusterRegistry(nil) })
+
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl", []string{"--context", "prod-admin", "version", "-o", "json"}, mtvmcp.FakeResponse{
+		Stdout: `{"clientVersion": {"gitVersion": "v1.31.0"}, "serverVersion": {"gitVersion": "v1.30.4"}}`,
+	})
+	fake.On("kubectl-mtv", []string{"--context", "prod-admin", "version", "-o", "json"}, mtvmcp.FakeResponse{
+		Stdout: `{"clientVersion": "v0.5.0", "operatorVersion": "2.9.0", "operatorNamespace": "openshift-mtv"}`,
+	})
+	fake.On("kubectl", []string{"--context", "lab", "version", "-o", "json"}, mtvmcp.FakeResponse{
+		Stderr:   "Unable to connect to the server: dial tcp: lookup api.lab.example.com: no such host",
+		ExitCode: 1,
+	})
+	ctx := mtvmcp.WithExecutor(context.Background(), fake)
+
+	_, out, err := handleListClusters(ctx, nil, ListClustersInput{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(out.Clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %+v", out.Clusters)
+	}
+
+	prod := out.Clusters[0]
+	if !prod.Reachable || !prod.Default || prod.KubernetesVersion != "v1.30.4" || prod.Version == nil || prod.Version.OperatorVersion != "2.9.0" {
+		t.Errorf("Unexpected prod status: %+v", prod)
+	}
+
+	lab := out.Clusters[1]
+	if lab.Reachable || lab.Default || lab.Version != nil || lab.Error != "Unable to connect to the server: dial tcp: lookup api.lab.example.com: no such host" {
+		t.Errorf("Unexpected lab status: %+v", lab)
+	}
+}
+
+// deadlineExecutor records the time left before the deadline of each command
+type deadlineExecutor struct {
+	mu   sync.Mutex
+	left []time.Duration
+}
+
+func (e *deadlineExecutor) Execute(ctx context.Context, cmd mtvmcp.Command) (mtvmcp.ExecResult, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		e.left = append(e.left, time.Until(deadline))
+	} else {
+		e.left = append(e.left, -1)
+	}
+	return mtvmcp.ExecResult{ExitCode: 1, Stderr: "Unable to connect to the server"}, nil
+}
+
+func TestListClustersTimeout(t *testing.T) {
+	mtvmcp.SetDefaultTimeout(time.Minute)
+	t.Cleanup(func() { mtvmcp.SetDefaultTimeout(mtvmcp.DefaultCommandTimeout) })
+
+	tests := []struct {
+		name    string
+		seconds int
+		max     time.Duration
+	}{
+		{name: "default", seconds: 0, max: defaultClusterCheckTimeout},
+		{name: "shorter", seconds: 5, max: 5 * time.Second},
+		{name: "capped at the server timeout", seconds: 3600, max: time.Minute},
+		{name: "overflowing value capped", seconds: math.MaxInt, max: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			executor := &deadlineExecutor{}
+			ctx := mtvmcp.WithExecutor(context.Background(), executor)
+			if _, _, err := handleListClusters(ctx, nil, ListClustersInput{TimeoutSeconds: tt.seconds}); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, left := range executor.left {
+				if left <= 0 || left > tt.max {
+					t.Errorf("Expected a deadline within %v, got %v", tt.max, left)
+				}
+			}
+			if len(executor.left) == 0 {
+				t.Errorf("Expected the cluster to be checked")
+			}
+		})
+	}
+}
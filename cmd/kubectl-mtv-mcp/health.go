@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// DefaultReadinessTimeout is the default time budget of the kubectl-mtv readiness check
+const DefaultReadinessTimeout = 5 * time.Second
+
+// versionProbeInterval is how long /readyz and /version reuse a kubectl-mtv version result
+const versionProbeInterval = 10 * time.Second
+
+// healthOptions configures the /healthz, /readyz, /version and /metrics endpoints
+type healthOptions struct {
+	// Addr serves the endpoints on a separate listener; empty serves them on the MCP mux
+	Addr string
+	// ReadinessTimeout bounds the kubectl-mtv version call of the readiness check
+	ReadinessTimeout time.Duration
+	// SkipBinaryCheck skips the PATH lookup of kubectl and kubectl-mtv,
+	// for offline modes that do not run them
+	SkipBinaryCheck bool
+}
+
+// healthCheck is the result of a single readiness check
+type healthCheck struct {
+	Name    string `json:"name"`
+	OK      bool   `json:"ok"`
+	Message string `json:"message,omitempty"`
+}
+
+// readinessResponse is the body of the /readyz endpoint
+type readinessResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+// versionResponse is the body of the /version endpoint
+type versionResponse struct {
+	Version           string `json:"version"`
+	KubectlMTVVersion string `json:"kubectlMTVVersion,omitempty"`
+	OperatorVersion   string `json:"operatorVersion,omitempty"`
+	Error             string `json:"error,omitempty"`
+}
+
+// registerHealthHandlers adds the /healthz, /readyz, /version and /metrics endpoints to mux.
+// The endpoints do not require a Bearer token.
+func registerHealthHandlers(mux *http.ServeMux, opts healthOptions) {
+	timeout := opts.ReadinessTimeout
+	if timeout <= 0 {
+		timeout = DefaultReadinessTimeout
+	}
+	probe := &versionProbe{timeout: timeout}
+
+	// Liveness: the process is up and serving HTTP
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		_, _ = w.Write([]byte("ok\n"))
+	})
+
+	// Readiness: the CLI tools are installed and kubectl-mtv can reach the cluster
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		var checks []healthCheck
+		if !opts.SkipBinaryCheck {
+			for _, binary := range []string{"kubectl-mtv", "kubectl"} {
+				check := healthCheck{Name: binary, OK: true}
+				if path, err := exec.LookPath(binary); err != nil {
+					check.OK = false
+					check.Message = err.Error()
+				} else {
+					check.Message = path
+				}
+				checks = append(checks, check)
+			}
+		}
+
+		check := healthCheck{Name: "kubectl-mtv version", OK: true}
+		if _, err := probe.get(r.Context()); err != nil {
+			check.OK = false
+			check.Message = commandErrorMessage(err)
+		}
+		checks = append(checks, check)
+
+		response := readinessResponse{Status: "ok", Checks: checks}
+		status := http.StatusOK
+		for _, check := range checks {
+			if !check.OK {
+				response.Status = "fail"
+				status = http.StatusServiceUnavailable
+			}
+		}
+		writeJSON(w, status, response)
+	})
+
+	mux.HandleFunc("/version", func(w http.ResponseWriter, r *http.Request) {
+		response := versionResponse{Version: Version}
+		if version, err := probe.get(r.Context()); err != nil {
+			response.Error = commandErrorMessage(err)
+		} else {
+			response.KubectlMTVVersion = version.ClientVersion
+			response.OperatorVersion = version.OperatorVersion
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+
+	// Prometheus metrics of tool calls, subprocesses and sessions
+	mux.Handle("/metrics", mtvmcp.MetricsHandler())
+}
+
+// versionProbe runs the kubectl-mtv version check of the health endpoints.
+// The endpoints do not require a token, so the result is shared by all callers
+// for versionProbeInterval and concurrent requests wait for a single run.
+type versionProbe struct {
+	timeout time.Duration
+
+	mu      sync.Mutex
+	checked time.Time
+	version VersionInfo
+	err     error
+}
+
+// get returns the last kubectl-mtv version result, probing again once it is stale
+func (p *versionProbe) get(ctx context.Context) (VersionInfo, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checked.IsZero() && time.Since(p.checked) < versionProbeInterval {
+		return p.version, p.err
+	}
+
+	// The probe is not recorded or replayed and does not count against the
+	// subprocess limits; a caller hanging up does not fail the shared result
+	ctx = context.WithoutCancel(ctx)
+	ctx = mtvmcp.WithServerCommand(mtvmcp.WithExecutor(ctx, mtvmcp.ProbeExecutor(ctx)))
+	p.version, p.err = detectVersion(ctx, p.timeout)
+	p.checked = time.Now()
+	return p.version, p.err
+}
+
+// detectVersion runs kubectl-mtv version within timeout, with the server credentials
+func detectVersion(ctx context.Context, timeout time.Duration) (VersionInfo, error) {
+	ctx, cancel := mtvmcp.WithCommandTimeout(ctx, timeout)
+	defer cancel()
+
+	output, err := runJSONCommand(ctx, mtvmcp.RunKubectlMTVCommand, []string{"version", "-o", "json"})
+	if err != nil {
+		return VersionInfo{}, err
+	}
+	var version VersionInfo
+	if !output.DecodeStdout(&version) {
+		return VersionInfo{}, fmt.Errorf("failed to parse kubectl-mtv version output")
+	}
+	return version, nil
+}
+
+// writeJSON writes v as a JSON response with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
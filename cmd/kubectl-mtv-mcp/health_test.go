@@ -0,0 +1,228 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestHealthEndpoints(t *testing.T) {
+	versionArgs := []string{"version", "-o", "json"}
+	healthy := mtvmcp.NewFakeExecutor()
+	healthy.On("kubectl-mtv", versionArgs, mtvmcp.FakeResponse{Stdout: `{"clientVersion": "v0.5.0", "operatorVersion": "2.9.0"}`})
+	failing := mtvmcp.NewFakeExecutor()
+	failing.On("kubectl-mtv", versionArgs, mtvmcp.FakeResponse{Stderr: "Unable to connect to the server", ExitCode: 1})
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	tests := []struct {
+		name       string
+		executor   mtvmcp.Executor
+		opts       healthOptions
+		emptyPath  bool
+		path       string
+		wantStatus int
+		check      func(t *testing.T, body []byte)
+	}{
+		{
+			name:       "liveness",
+			executor:   failing,
+			path:       "/healthz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "ready",
+			executor:   healthy,
+			opts:       healthOptions{SkipBinaryCheck: true},
+			path:       "/readyz",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "kubectl-mtv version fails",
+			executor:   failing,
+			opts:       healthOptions{SkipBinaryCheck: true},
+			path:       "/readyz",
+			wantStatus: http.StatusServiceUnavailable,
+			check: func(t *testing.T, body []byte) {
+				var response readinessResponse
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Fatal(err)
+				}
+				if response.Status != "fail" || len(response.Checks) != 1 || response.Checks[0].Message != "Unable to connect to the server" {
+					t.Errorf("Unexpected readiness response: %s", body)
+				}
+			},
+		},
+		{
+			name:       "binaries missing from PATH",
+			executor:   healthy,
+			emptyPath:  true,
+			path:       "/readyz",
+			wantStatus: http.StatusServiceUnavailable,
+			check: func(t *testing.T, body []byte) {
+				var response readinessResponse
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Fatal(err)
+				}
+				if len(response.Checks) != 3 || response.Checks[0].OK || response.Checks[1].OK || !response.Checks[2].OK {
+					t.Errorf("Expected failed PATH checks, got %s", body)
+				}
+			},
+		},
+		{
+			name:       "version",
+			executor:   healthy,
+			path:       "/version",
+			wantStatus: http.StatusOK,
+			check: func(t *testing.T, body []byte) {
+				var response versionResponse
+				if err := json.Unmarshal(body, &response); err != nil {
+					t.Fatal(err)
+				}
+				if response.Version != Version || response.KubectlMTVVersion != "v0.5.0" || response.OperatorVersion != "2.9.0" {
+					t.Errorf("Unexpected version response: %s", body)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.emptyPath {
+				t.Setenv("PATH", t.TempDir())
+			}
+			mtvmcp.SetDefaultExecutor(tt.executor)
+
+			mux := http.NewServeMux()
+			registerHealthHandlers(mux, tt.opts)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d: %s", tt.wantStatus, rec.Code, rec.Body.String())
+			}
+			if tt.check != nil {
+				tt.check(t, rec.Body.Bytes())
+			}
+		})
+	}
+}
+
+func TestHealthVersionProbe(t *testing.T) {
+	versionArgs := []string{"version", "-o", "json"}
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	get := func(t *testing.T, mux *http.ServeMux, path string) *httptest.ResponseRecorder {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("Expected %s to succeed, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		return rec
+	}
+
+	t.Run("result is shared between requests", func(t *testing.T) {
+		fake := mtvmcp.NewFakeExecutor()
+		fake.On("kubectl-mtv", versionArgs, mtvmcp.FakeResponse{Stdout: `{"clientVersion": "v0.5.0"}`})
+		mtvmcp.SetDefaultExecutor(fake)
+
+		mux := http.NewServeMux()
+		registerHealthHandlers(mux, healthOptions{SkipBinaryCheck: true})
+		for _, path := range []string{"/readyz", "/version", "/readyz"} {
+			get(t, mux, path)
+		}
+		if calls := fake.Calls(); len(calls) != 1 {
+			t.Errorf("Expected a single kubectl-mtv version call, got %d", len(calls))
+		}
+	})
+
+	t.Run("not recorded", func(t *testing.T) {
+		fake := mtvmcp.NewFakeExecutor()
+		fake.On("kubectl-mtv", versionArgs, mtvmcp.FakeResponse{Stdout: `{"clientVersion": "v0.5.0"}`})
+		dir := t.TempDir()
+		recorder, err := mtvmcp.NewRecordingExecutor(fake, dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mtvmcp.SetDefaultExecutor(recorder)
+
+		mux := http.NewServeMux()
+		registerHealthHandlers(mux, healthOptions{SkipBinaryCheck: true})
+		get(t, mux, "/readyz")
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 || len(fake.Calls()) != 1 {
+			t.Errorf("Expected the probe to run without writing fixtures, got %d fixtures", len(entries))
+		}
+	})
+
+	t.Run("replay fixtures not consumed", func(t *testing.T) {
+		dir := t.TempDir()
+		for i, operatorVersion := range []string{"2.9.0", "2.10.0"} {
+			fixture, err := json.Marshal(mtvmcp.Fixture{
+				Name:   "kubectl-mtv",
+				Args:   versionArgs,
+				Stdout: `{"operatorVersion": "` + operatorVersion + `"}`,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			name := filepath.Join(dir, fmt.Sprintf("%06d-version.json", i+1))
+			if err := os.WriteFile(name, fixture, 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		replay, err := mtvmcp.NewReplayExecutor(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mtvmcp.SetDefaultExecutor(replay)
+
+		mux := http.NewServeMux()
+		registerHealthHandlers(mux, healthOptions{SkipBinaryCheck: true})
+		if body := get(t, mux, "/version").Body.String(); !strings.Contains(body, `"operatorVersion":"2.9.0"`) {
+			t.Errorf("Expected the first recording, got %s", body)
+		}
+
+		// A tool call still gets the first recording
+		result, err := replay.Execute(context.Background(), mtvmcp.Command{Name: "kubectl-mtv", Args: versionArgs})
+		if err != nil || !strings.Contains(result.Stdout, "2.9.0") {
+			t.Errorf("Expected the probe not to consume the first recording, got %q, %v", result.Stdout, err)
+		}
+	})
+}
+
+func TestHealthEndpointsSkipAuthentication(t *testing.T) {
+	handler := newHTTPHandler(httpOptions{RequireToken: true, Streamable: true})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("Expected /healthz without a token to succeed, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("Expected /mcp without a token to be rejected, got %d", rec.Code)
+	}
+
+	// With a separate health address the MCP mux does not serve the endpoints
+	handler = newHTTPHandler(httpOptions{Streamable: true, Health: healthOptions{Addr: "127.0.0.1:8081"}})
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected /healthz not to be served on the MCP mux, got %d", rec.Code)
+	}
+}
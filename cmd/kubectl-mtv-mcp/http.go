@@ -0,0 +1,269 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// readOnlyHeader is the HTTP header clients can set to request a read-only session
+const readOnlyHeader = "X-MCP-Read-Only"
+
+// httpOptions holds the listener configuration shared by the SSE and Streamable HTTP modes
+type httpOptions struct {
+	Addr       string
+	TLSCert    string
+	TLSKey     string
+	Streamable bool
+	Server     ServerOptions
+	// TokenProfiles selects the profile per request from the authenticated token
+	TokenProfiles TokenProfiles
+	// RequireToken rejects requests without a Bearer token instead of falling back
+	// to the server kubeconfig
+	RequireToken bool
+	// TokenReviewer verifies Bearer tokens and resolves the user before a session
+	// is created; nil accepts tokens without verification
+	TokenReviewer *mtvmcp.TokenReviewer
+	// Impersonation runs commands as the request user; nil disables impersonation
+	Impersonation *impersonationOptions
+	// Health configures the /healthz, /readyz, /version and /metrics endpoints
+	Health healthOptions
+	// ShutdownGracePeriod is how long in-flight tool calls may run after shutdown starts
+	ShutdownGracePeriod time.Duration
+}
+
+// readOnlyRequested reports whether the read-only header is set to a true value
+func readOnlyRequested(header http.Header) bool {
+	if header == nil {
+		return false
+	}
+	readOnly, err := strconv.ParseBool(header.Get(readOnlyHeader))
+	return err == nil && readOnly
+}
+
+// withBearerToken wraps a handler with middleware that extracts the Bearer token
+// from the Authorization header and adds it to the request context.
+// When a token reviewer is set, the token is verified and the resolved user is
+// added to the context; missing or invalid tokens are rejected with 401, as are
+// missing tokens when a token is required.
+// In impersonation mode the request user comes from the identity headers of a
+// trusted proxy, or from the reviewed token, and requests without a user are rejected.
+func withBearerToken(next http.Handler, opts httpOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A trusted proxy identifies the user with the identity headers
+		if opts.Impersonation != nil {
+			if user, ok := opts.Impersonation.proxyIdentity(r); ok {
+				ctx := mtvmcp.WithImpersonation(mtvmcp.WithUserInfo(r.Context(), user), user)
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+		}
+
+		// Extract Bearer token from Authorization header
+		token := ""
+		authHeader := r.Header.Get("Authorization")
+		if authHeader != "" {
+			// Check if it's a Bearer token
+			parts := strings.SplitN(authHeader, " ", 2)
+			if len(parts) == 2 && strings.ToLower(parts[0]) == "bearer" {
+				token = strings.TrimSpace(parts[1])
+			}
+		}
+
+		if token == "" {
+			if opts.RequireToken || opts.TokenReviewer != nil || opts.Impersonation != nil {
+				unauthorized(w, "a Bearer token is required")
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Add token to request context
+		ctx := mtvmcp.WithKubeToken(r.Context(), token)
+		log.Printf("Token received via Authorization header (length: %d)", len(token))
+
+		if opts.TokenReviewer != nil {
+			user, err := opts.TokenReviewer.Review(r.Context(), token)
+			if err != nil {
+				if errors.Is(err, mtvmcp.ErrUnauthenticated) {
+					log.Printf("Rejected request from %s: %v", r.RemoteAddr, err)
+					unauthorized(w, "invalid Bearer token")
+					return
+				}
+				log.Printf("Token review failed: %v", err)
+				http.Error(w, "token review failed", http.StatusServiceUnavailable)
+				return
+			}
+			if user.Username != "" {
+				ctx = mtvmcp.WithUserInfo(ctx, user)
+			}
+		}
+
+		if opts.Impersonation != nil {
+			user, ok := mtvmcp.GetUserInfo(ctx)
+			if !ok {
+				unauthorized(w, "the request user could not be identified")
+				return
+			}
+			ctx = mtvmcp.WithImpersonation(ctx, user)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// unauthorized writes a 401 response asking for a Bearer token
+func unauthorized(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="kubectl-mtv-mcp"`)
+	http.Error(w, message, http.StatusUnauthorized)
+}
+
+// newHTTPHandler builds the HTTP handler for the selected mode.
+// In SSE mode the legacy SSE handler serves every path.
+// In Streamable HTTP mode the Streamable HTTP handler is served on /mcp and the
+// legacy SSE handler on /sse, so clients can be migrated gradually.
+// The health and metrics endpoints are served on the same mux unless they have their own address.
+func newHTTPHandler(opts httpOptions) http.Handler {
+	getServer := func(req *http.Request) *mcp.Server {
+		serverOpts := opts.Server
+		if token, ok := mtvmcp.GetKubeToken(req.Context()); ok {
+			if profile, ok := opts.TokenProfiles.ProfileForToken(token); ok {
+				serverOpts.Profile = profile
+			}
+		}
+		if readOnlyRequested(req.Header) {
+			serverOpts.ReadOnly = true
+		}
+		return CreateServer(serverOpts)
+	}
+
+	mux := http.NewServeMux()
+	if opts.Health.Addr == "" {
+		registerHealthHandlers(mux, opts.Health)
+	}
+
+	sseHandler := withBearerToken(mcp.NewSSEHandler(getServer, nil), opts)
+	if !opts.Streamable {
+		mux.Handle("/", sseHandler)
+		return mux
+	}
+
+	mux.Handle("/mcp", withBearerToken(mcp.NewStreamableHTTPHandler(getServer, nil), opts))
+	mux.Handle("/sse", sseHandler)
+	return mux
+}
+
+// serveHTTP runs the HTTP/HTTPS server for the SSE and Streamable HTTP modes until
+// ctx is done, then stops accepting new sessions and drains the in-flight tool calls
+func serveHTTP(ctx context.Context, opts httpOptions) error {
+	// Validate TLS configuration
+	useTLS := false
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		if opts.TLSCert == "" || opts.TLSKey == "" {
+			return fmt.Errorf("both --tls-cert and --tls-key must be provided for HTTPS")
+		}
+		useTLS = true
+	}
+
+	mode := "SSE"
+	if opts.Streamable {
+		mode = "Streamable HTTP"
+	}
+
+	handler := newHTTPHandler(opts)
+
+	protocol := "http"
+	if useTLS {
+		protocol = "https"
+	}
+
+	log.Printf("Starting kubectl-mtv MCP server in %s mode on %s", mode, opts.Addr)
+	if useTLS {
+		log.Printf("Protocol: HTTPS (TLS enabled)")
+		log.Printf("TLS Certificate: %s", opts.TLSCert)
+		log.Printf("TLS Key: %s", opts.TLSKey)
+	} else {
+		log.Printf("Protocol: HTTP (TLS disabled - use --tls-cert and --tls-key for HTTPS)")
+	}
+	if opts.Streamable {
+		log.Printf("Connect Streamable HTTP clients to: %s://%s/mcp", protocol, opts.Addr)
+		log.Printf("Connect legacy SSE clients to: %s://%s/sse", protocol, opts.Addr)
+	} else {
+		log.Printf("Connect clients to: %s://%s/sse", protocol, opts.Addr)
+	}
+	log.Printf("Token authentication: Enabled via Authorization header (Bearer token)")
+	if opts.TokenReviewer != nil {
+		log.Printf("Token review: Enabled (missing or invalid tokens are rejected)")
+	} else if opts.RequireToken {
+		log.Printf("Token required: Enabled (no kubeconfig fallback)")
+	}
+	if opts.Impersonation != nil {
+		log.Printf("Impersonation: Enabled (%d trusted proxy range(s), headers %s/%s)",
+			len(opts.Impersonation.TrustedProxies), opts.Impersonation.UserHeader, opts.Impersonation.GroupHeader)
+	}
+	log.Printf("Tool profile: %s", opts.Server.Profile)
+	if len(opts.TokenProfiles) > 0 {
+		log.Printf("Token profiles: %d token(s) mapped to profiles", len(opts.TokenProfiles))
+	}
+	if opts.Server.ReadOnly {
+		log.Printf("Read-only mode: Enabled (write tools are not registered)")
+	} else {
+		log.Printf("Read-only mode: Per request via %s header", readOnlyHeader)
+	}
+
+	server := &http.Server{Addr: opts.Addr, Handler: handler}
+	var healthServer *http.Server
+	errs := make(chan error, 2)
+	if opts.Health.Addr != "" {
+		healthMux := http.NewServeMux()
+		registerHealthHandlers(healthMux, opts.Health)
+		healthServer = &http.Server{Addr: opts.Health.Addr, Handler: healthMux}
+		log.Printf("Health endpoints: http://%s/healthz, /readyz, /version and /metrics", opts.Health.Addr)
+		go func() {
+			errs <- healthServer.ListenAndServe()
+		}()
+	} else {
+		log.Printf("Health endpoints: %s://%s/healthz, /readyz, /version and /metrics", protocol, opts.Addr)
+	}
+
+	go func() {
+		if useTLS {
+			errs <- server.ListenAndServeTLS(opts.TLSCert, opts.TLSKey)
+			return
+		}
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Close the listener so no new session is accepted, while the open sessions
+	// keep their connections until the in-flight tool calls are drained
+	log.Printf("Shutting down: no longer accepting new sessions")
+	shutdownCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	inFlightCalls.shutdown(opts.ShutdownGracePeriod)
+
+	// Drop the remaining connections, such as idle SSE streams
+	err := server.Close()
+	if healthServer != nil {
+		_ = healthServer.Close()
+	}
+	log.Printf("Shutdown complete")
+	return err
+}
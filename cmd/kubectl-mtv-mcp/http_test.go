@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestWithBearerToken(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.Default = nil
+	fake.On("kubectl", []string{"create", "-f", "-", "-o", "json"}, mtvmcp.FakeResponse{
+		Stdout: `{"status": {"authenticated": true, "user": {"username": "jdoe", "groups": ["admins"]}}}`,
+	})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	reviewer, err := mtvmcp.NewTokenReviewer(mtvmcp.TokenReviewTokenReview)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		opts       httpOptions
+		header     string
+		wantStatus int
+		wantToken  string
+		wantUser   string
+	}{
+		{name: "no token falls back to kubeconfig", wantStatus: http.StatusOK},
+		{name: "token forwarded", header: "Bearer abc", wantStatus: http.StatusOK, wantToken: "abc"},
+		{name: "required token missing", opts: httpOptions{RequireToken: true}, wantStatus: http.StatusUnauthorized},
+		{name: "required token present", opts: httpOptions{RequireToken: true}, header: "Bearer abc", wantStatus: http.StatusOK, wantToken: "abc"},
+		{name: "reviewed token missing", opts: httpOptions{TokenReviewer: reviewer}, header: "Basic dXNlcjpwYXNz", wantStatus: http.StatusUnauthorized},
+		{name: "reviewed token", opts: httpOptions{TokenReviewer: reviewer}, header: "Bearer abc", wantStatus: http.StatusOK, wantToken: "abc", wantUser: "jdoe"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotToken, gotUser string
+			handler := withBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotToken, _ = mtvmcp.GetKubeToken(r.Context())
+				user, _ := mtvmcp.GetUserInfo(r.Context())
+				gotUser = user.Username
+			}), tt.opts)
+
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Errorf("Expected a WWW-Authenticate header")
+			}
+			if gotToken != tt.wantToken || gotUser != tt.wantUser {
+				t.Errorf("Expected token %q and user %q, got %q and %q", tt.wantToken, tt.wantUser, gotToken, gotUser)
+			}
+		})
+	}
+
+	t.Run("rejected token", func(t *testing.T) {
+		rejecting := mtvmcp.NewFakeExecutor()
+		rejecting.Default = &mtvmcp.FakeResponse{Stdout: `{"status": {"authenticated": false}}`}
+		mtvmcp.SetDefaultExecutor(rejecting)
+		rejectingReviewer, err := mtvmcp.NewTokenReviewer(mtvmcp.TokenReviewTokenReview)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		called := false
+		handler := withBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }),
+			httpOptions{TokenReviewer: rejectingReviewer})
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set("Authorization", "Bearer bad")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized || called {
+			t.Errorf("Expected 401 without calling the handler, got %d (called: %v)", rec.Code, called)
+		}
+	})
+}
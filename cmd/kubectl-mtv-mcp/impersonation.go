@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// Default headers an authenticating proxy uses to pass the user identity
+const (
+	defaultImpersonationUserHeader  = "X-Remote-User"
+	defaultImpersonationGroupHeader = "X-Remote-Group"
+)
+
+// impersonationOptions configures per-user impersonation in the HTTP/SSE modes.
+// Commands run with the server credentials and --as/--as-group for the request user,
+// who is identified by a trusted proxy header or a reviewed Bearer token.
+type impersonationOptions struct {
+	// UserHeader and GroupHeader carry the identity set by a trusted proxy
+	UserHeader  string
+	GroupHeader string
+	// TrustedProxies are the source addresses allowed to set the identity headers
+	TrustedProxies []netip.Prefix
+}
+
+// parseTrustedProxies parses a comma-separated list of IP addresses and CIDR ranges
+func parseTrustedProxies(list string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			prefix, err := netip.ParsePrefix(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid trusted proxy '%s': %w", entry, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s': %w", entry, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// trusted reports whether the request comes from a trusted proxy.
+// Only the connection source address is checked, X-Forwarded-For is not trusted.
+func (o *impersonationOptions) trusted(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range o.TrustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// proxyIdentity returns the user set by a trusted proxy in the identity headers.
+// Identity headers from untrusted sources are ignored.
+func (o *impersonationOptions) proxyIdentity(r *http.Request) (mtvmcp.UserInfo, bool) {
+	username := strings.TrimSpace(r.Header.Get(o.UserHeader))
+	if username == "" {
+		return mtvmcp.UserInfo{}, false
+	}
+	if !o.trusted(r) {
+		log.Printf("Ignoring %s header from untrusted source %s", o.UserHeader, r.RemoteAddr)
+		return mtvmcp.UserInfo{}, false
+	}
+
+	user := mtvmcp.UserInfo{Username: username}
+	for _, value := range r.Header.Values(o.GroupHeader) {
+		for _, group := range strings.Split(value, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				user.Groups = append(user.Groups, group)
+			}
+		}
+	}
+	return user, true
+}
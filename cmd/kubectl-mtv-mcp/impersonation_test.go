@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		list    string
+		want    []string
+		wantErr bool
+	}{
+		{list: "", want: nil},
+		{list: "10.0.0.1", want: []string{"10.0.0.1/32"}},
+		{list: "10.0.0.0/8, ::1", want: []string{"10.0.0.0/8", "::1/128"}},
+		{list: "10.1.2.3/8", want: []string{"10.0.0.0/8"}},
+		{list: "proxy.example.com", wantErr: true},
+		{list: "10.0.0.0/33", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			prefixes, err := parseTrustedProxies(tt.list)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			var got []string
+			for _, prefix := range prefixes {
+				got = append(got, prefix.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestImpersonationMiddleware(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.Default = &mtvmcp.FakeResponse{
+		Stdout: `{"status": {"authenticated": true, "user": {"username": "token-user", "groups": ["token-group"]}}}`,
+	}
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	reviewer, err := mtvmcp.NewTokenReviewer(mtvmcp.TokenReviewTokenReview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proxies, err := parseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	impersonation := &impersonationOptions{
+		UserHeader:     defaultImpersonationUserHeader,
+		GroupHeader:    defaultImpersonationGroupHeader,
+		TrustedProxies: proxies,
+	}
+
+	tests := []struct {
+		name       string
+		opts       httpOptions
+		remoteAddr string
+		headers    map[string]string
+		wantStatus int
+		wantUser   string
+		wantGroups []string
+	}{
+		{
+			name:       "trusted proxy",
+			opts:       httpOptions{Impersonation: impersonation},
+			remoteAddr: "10.1.2.3:40000",
+			headers:    map[string]string{"X-Remote-User": "jdoe", "X-Remote-Group": "admins, dev"},
+			wantStatus: http.StatusOK,
+			wantUser:   "jdoe",
+			wantGroups: []string{"admins", "dev"},
+		},
+		{
+			name:       "untrusted proxy without token",
+			opts:       httpOptions{Impersonation: impersonation},
+			remoteAddr: "192.168.1.10:40000",
+			headers:    map[string]string{"X-Remote-User": "jdoe"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "untrusted proxy with unreviewed token",
+			opts:       httpOptions{Impersonation: impersonation},
+			remoteAddr: "192.168.1.10:40000",
+			headers:    map[string]string{"X-Remote-User": "jdoe", "Authorization": "Bearer abc"},
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			name:       "untrusted proxy with reviewed token",
+			opts:       httpOptions{Impersonation: impersonation, TokenReviewer: reviewer},
+			remoteAddr: "192.168.1.10:40000",
+			headers:    map[string]string{"X-Remote-User": "jdoe", "Authorization": "Bearer abc"},
+			wantStatus: http.StatusOK,
+			wantUser:   "token-user",
+			wantGroups: []string{"token-group"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got mtvmcp.UserInfo
+			var impersonating bool
+			handler := withBearerToken(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got, impersonating = mtvmcp.GetImpersonation(r.Context())
+			}), tt.opts)
+
+			req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for key, value := range tt.headers {
+				req.Header.Set(key, value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("Expected status %d, got %d", tt.wantStatus, rec.Code)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if !impersonating || got.Username != tt.wantUser || !slices.Equal(got.Groups, tt.wantGroups) {
+				t.Errorf("Expected impersonation of %s %v, got %+v (impersonating: %v)", tt.wantUser, tt.wantGroups, got, impersonating)
+			}
+		})
+	}
+}
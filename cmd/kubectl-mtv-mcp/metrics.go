@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// Error categories of tool calls that did not fail with a classified command error
+const (
+	// errorCategoryRejected is a call rejected before the tool ran, such as an unavailable tool
+	errorCategoryRejected = "rejected"
+	// errorCategoryInternal is any other tool error
+	errorCategoryInternal = "internal"
+)
+
+// unknownToolLabel is the tool label of calls to names the server does not know,
+// so that clients cannot create new series by calling random names
+const unknownToolLabel = "unknown"
+
+// metricsMiddleware records the count, latency and errors of tools/call requests.
+// Calls are labelled with the tool name only if it is one of knownTools.
+func metricsMiddleware(knownTools map[string]bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			callReq, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			start := time.Now()
+			result, err := next(ctx, method, req)
+			tool := callReq.Params.Name
+			if !knownTools[tool] {
+				tool = unknownToolLabel
+			}
+			mtvmcp.RecordToolCall(tool, time.Since(start))
+
+			if err != nil {
+				mtvmcp.RecordToolError(tool, "none", errorCategoryRejected)
+			} else if callResult, ok := result.(*mcp.CallToolResult); ok && callResult.IsError {
+				exitCode, category := toolErrorLabels(callResult)
+				mtvmcp.RecordToolError(tool, exitCode, category)
+			}
+			return result, err
+		}
+	}
+}
+
+// toolErrorLabels returns the exit code and error category of a failed tool call,
+// read from the JSON command or validation error in its text content
+func toolErrorLabels(result *mcp.CallToolResult) (exitCode, category string) {
+	for _, content := range result.Content {
+		text, ok := content.(*mcp.TextContent)
+		if !ok {
+			continue
+		}
+		var body struct {
+			Error       string `json:"error"`
+			Type        string `json:"type"`
+			ReturnValue *int   `json:"return_value"`
+		}
+		if err := json.Unmarshal([]byte(text.Text), &body); err != nil {
+			continue
+		}
+		switch body.Error {
+		case "command_error":
+			exitCode = "none"
+			if body.ReturnValue != nil {
+				exitCode = strconv.Itoa(*body.ReturnValue)
+			}
+			return exitCode, body.Type
+		case "validation_error":
+			return "none", mtvmcp.ErrorTypeValidationFailed
+		case "rate_limited":
+			return "none", body.Error
+		}
+	}
+	return "none", errorCategoryInternal
+}
+
+// trackSession counts the session as active until it ends
+func trackSession(ctx context.Context, req *mcp.InitializedRequest) {
+	if req == nil || req.Session == nil {
+		return
+	}
+	mtvmcp.SessionStarted()
+	go func() {
+		defer mtvmcp.SessionEnded()
+		_ = req.Session.Wait()
+	}()
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestToolMetrics(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"delete", "plan", "--", "my-plan"}, mtvmcp.FakeResponse{
+		Stderr:   `Error from server (Forbidden): plans.forklift.konveyor.io "my-plan" is forbidden`,
+		ExitCode: 1,
+	})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	calls := `kubectl_mtv_mcp_tool_calls_total{tool="DeletePlan"}`
+	forbidden := `kubectl_mtv_mcp_tool_errors_total{tool="DeletePlan",exit_code="1",category="forbidden"}`
+	rejected := `kubectl_mtv_mcp_tool_errors_total{tool="DeletePlan",exit_code="none",category="rejected"}`
+	beforeCalls, beforeForbidden, beforeRejected := scrapeMetric(t, calls), scrapeMetric(t, forbidden), scrapeMetric(t, rejected)
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	if _, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "DeletePlan",
+		Arguments: map[string]any{"plan_name": "my-plan"},
+	}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	readOnly := connectTestClient(t, CreateServer(ServerOptions{ReadOnly: true}))
+	if _, err := readOnly.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "DeletePlan",
+		Arguments: map[string]any{"plan_name": "my-plan"},
+	}); err == nil {
+		t.Fatal("Expected the read-only server to reject DeletePlan")
+	}
+
+	if got := scrapeMetric(t, calls); got != beforeCalls+2 {
+		t.Errorf("Expected 2 more DeletePlan calls, got %v -> %v", beforeCalls, got)
+	}
+	if got := scrapeMetric(t, forbidden); got != beforeForbidden+1 {
+		t.Errorf("Expected 1 more forbidden error, got %v -> %v", beforeForbidden, got)
+	}
+	if got := scrapeMetric(t, rejected); got != beforeRejected+1 {
+		t.Errorf("Expected 1 more rejected call, got %v -> %v", beforeRejected, got)
+	}
+	if got := scrapeMetric(t, "kubectl_mtv_mcp_active_sessions"); got < 2 {
+		t.Errorf("Expected at least 2 active sessions, got %v", got)
+	}
+}
+
+func TestToolMetricsUnknownTool(t *testing.T) {
+	unknown := `kubectl_mtv_mcp_tool_calls_total{tool="unknown"}`
+	before := scrapeMetric(t, unknown)
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	if _, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "NoSuchTool-12345"}); err == nil {
+		t.Fatal("Expected the call to an unknown tool to fail")
+	}
+
+	if got := scrapeMetric(t, unknown); got != before+1 {
+		t.Errorf("Expected 1 more unknown tool call, got %v -> %v", before, got)
+	}
+	for _, series := range []string{
+		`kubectl_mtv_mcp_tool_calls_total{tool="NoSuchTool-12345"}`,
+		`kubectl_mtv_mcp_tool_errors_total{tool="NoSuchTool-12345",exit_code="none",category="rejected"}`,
+	} {
+		if got := scrapeMetric(t, series); got != 0 {
+			t.Errorf("Expected no series for the unknown tool name, got %s %v", series, got)
+		}
+	}
+}
+
+func TestToolErrorLabels(t *testing.T) {
+	tests := []struct {
+		name         string
+		text         string
+		wantExitCode string
+		wantCategory string
+	}{
+		{"command error", mtvmcp.NewCommandError("kubectl-mtv get plan", 2, "Error from server (NotFound): not found").Error(), "2", "not_found"},
+		{"validation error", `{"error": "validation_error", "message": "invalid"}`, "none", "validation_failed"},
+		{"rate limited", `{"error": "rate_limited", "type": "rate_limit", "retry_after_seconds": 2}`, "none", "rate_limited"},
+		{"plain text", "something went wrong", "none", "internal"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exitCode, category := toolErrorLabels(&mcp.CallToolResult{
+				IsError: true,
+				Content: []mcp.Content{&mcp.TextContent{Text: tt.text}},
+			})
+			if exitCode != tt.wantExitCode || category != tt.wantCategory {
+				t.Errorf("Expected %s/%s, got %s/%s", tt.wantExitCode, tt.wantCategory, exitCode, category)
+			}
+		})
+	}
+}
+
+// scrapeMetric returns the value of a series from the /metrics endpoint, 0 if absent
+func scrapeMetric(t *testing.T, series string) float64 {
+	t.Helper()
+	mux := http.NewServeMux()
+	registerHealthHandlers(mux, healthOptions{})
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || !strings.HasPrefix(recorder.Header().Get("Content-Type"), "text/plain") {
+		t.Fatalf("Unexpected /metrics response: %d %s", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+	body, _ := io.ReadAll(recorder.Body)
+	for _, line := range strings.Split(string(body), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			var v float64
+			if _, err := fmt.Sscan(value, &v); err != nil {
+				t.Fatalf("Invalid sample %q: %v", line, err)
+			}
+			return v
+		}
+	}
+	return 0
+}
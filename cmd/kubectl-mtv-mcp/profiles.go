@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Tool profiles map deployment roles to tool subsets
+const (
+	// ProfileViewer exposes the list/get tools
+	ProfileViewer = "viewer"
+	// ProfileOperator adds plan lifecycle and plan patching tools
+	ProfileOperator = "operator"
+	// ProfileAdmin exposes every tool
+	ProfileAdmin = "admin"
+)
+
+// profileRank orders the profiles, each profile includes the tools of lower ranked profiles
+var profileRank = map[string]int{
+	ProfileViewer:   0,
+	ProfileOperator: 1,
+	ProfileAdmin:    2,
+}
+
+// ValidateProfile checks that the profile name is known
+func ValidateProfile(profile string) error {
+	if _, ok := profileRank[profile]; !ok {
+		return fmt.Errorf("invalid profile '%s'. Valid profiles: %s, %s, %s", profile, ProfileViewer, ProfileOperator, ProfileAdmin)
+	}
+	return nil
+}
+
+// ValidateToolNames checks that every name refers to a known tool
+func ValidateToolNames(names []string) error {
+	known := make(map[string]bool)
+	for _, tool := range allTools() {
+		known[tool.Name] = true
+	}
+	var unknown []string
+	for _, name := range names {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown tool(s): %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// ParseToolList parses a comma-separated list of tool names
+func ParseToolList(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// selectTools returns the tools exposed for the given server options.
+// The profile selects the base tool set, EnableTools and DisableTools adjust it,
+// and ReadOnly always removes every write tool.
+func selectTools(opts ServerOptions) []toolRegistration {
+	profile := opts.Profile
+	if profile == "" {
+		profile = ProfileAdmin
+	}
+	rank, ok := profileRank[profile]
+	if !ok {
+		// Unknown profiles get the least privileged tool set
+		rank = profileRank[ProfileViewer]
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range opts.EnableTools {
+		enabled[name] = true
+	}
+	disabled := make(map[string]bool)
+	for _, name := range opts.DisableTools {
+		disabled[name] = true
+	}
+
+	var selected []toolRegistration
+	for _, tool := range allTools() {
+		if profileRank[tool.Profile] > rank && !enabled[tool.Name] {
+			continue
+		}
+		if disabled[tool.Name] {
+			continue
+		}
+		if opts.ReadOnly && !tool.ReadOnly {
+			continue
+		}
+		if opts.Snapshot != nil && !snapshotTools[tool.Name] {
+			continue
+		}
+		selected = append(selected, tool)
+	}
+	return selected
+}
+
+// TokenProfiles maps the SHA-256 hex digest of a bearer token to a profile name
+type TokenProfiles map[string]string
+
+// LoadTokenProfiles loads a JSON file mapping SHA-256 token digests to profile names.
+// Digests are used so that the file never holds raw tokens, for example:
+//
+//	{"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08": "viewer"}
+func LoadTokenProfiles(path string) (TokenProfiles, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token profiles: %w", err)
+	}
+
+	var profiles TokenProfiles
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse token profiles: %w", err)
+	}
+
+	normalized := make(TokenProfiles, len(profiles))
+	for digest, profile := range profiles {
+		if err := ValidateProfile(profile); err != nil {
+			return nil, fmt.Errorf("token profiles: %w", err)
+		}
+		normalized[strings.ToLower(strings.TrimPrefix(digest, "sha256:"))] = profile
+	}
+	return normalized, nil
+}
+
+// ProfileForToken returns the profile assigned to the token, if any
+func (p TokenProfiles) ProfileForToken(token string) (string, bool) {
+	if token == "" || len(p) == 0 {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(token))
+	profile, ok := p[hex.EncodeToString(sum[:])]
+	return profile, ok
+}
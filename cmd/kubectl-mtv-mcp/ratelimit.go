@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// rateLimitMiddleware rate limits the commands of a tool call by the caller identity,
+// or by the MCP session when the caller is not identified
+func rateLimitMiddleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if callReq, ok := req.(*mcp.CallToolRequest); ok {
+				ctx = mtvmcp.WithRateLimitKey(ctx, rateLimitKey(ctx, callReq.Session))
+			}
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// rateLimitKey returns the identity a tool call is rate limited by
+func rateLimitKey(ctx context.Context, session *mcp.ServerSession) string {
+	if identity := callerIdentity(ctx); identity != "server" {
+		return identity
+	}
+	if session != nil && session.ID() != "" {
+		return "session:" + session.ID()
+	}
+	return ""
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestRateLimitKey(t *testing.T) {
+	tests := []struct {
+		name string
+		ctx  context.Context
+		want string
+	}{
+		{"no identity", context.Background(), ""},
+		{"token", mtvmcp.WithKubeToken(context.Background(), "test"), "token:sha256:9f86d081884c7d65"},
+		{"user", mtvmcp.WithUserInfo(mtvmcp.WithKubeToken(context.Background(), "test"), mtvmcp.UserInfo{Username: "alice"}), "alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rateLimitKey(tt.ctx, nil); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRateLimitedToolCall(t *testing.T) {
+	mtvmcp.SetLimits(mtvmcp.Limits{Rate: 0.001, Burst: 1})
+	t.Cleanup(func() { mtvmcp.SetLimits(mtvmcp.Limits{MaxConcurrent: mtvmcp.DefaultMaxConcurrentSubprocesses}) })
+
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"delete", "plan", "--", "my-plan"}, mtvmcp.FakeResponse{})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	params := &mcp.CallToolParams{Name: "DeletePlan", Arguments: map[string]any{"plan_name": "my-plan"}}
+	if result, err := session.CallTool(context.Background(), params); err != nil || result.IsError {
+		t.Fatalf("Expected the first call to succeed, got %v %v", result, err)
+	}
+
+	result, err := session.CallTool(context.Background(), params)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	text, _ := result.Content[0].(*mcp.TextContent)
+	if !result.IsError || text == nil || !strings.Contains(text.Text, "rate limited, retry after") {
+		t.Errorf("Expected a rate limited tool error, got %+v", result.Content)
+	}
+}
@@ -1,25 +1,54 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yaacov/kubectl-mtv-mcp/cmd/kubectl-mtv-mcp/tools"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
 )
 
-func CreateReadServer() *mcp.Server {
-	server := mcp.NewServer(&mcp.Implementation{
-		Name:    "kubectl-mtv",
-		Version: Version,
-	}, nil)
+// ServerOptions configures which tools are exposed by the MCP server
+type ServerOptions struct {
+	// ReadOnly registers only the read tools and refuses calls to write tools
+	ReadOnly bool
+	// Profile selects the base tool set (viewer, operator or admin), defaults to admin
+	Profile string
+	// EnableTools adds tools that are not part of the profile
+	EnableTools []string
+	// DisableTools removes tools from the profile
+	DisableTools []string
+	// Snapshot serves tools from an offline snapshot; only snapshot-capable
+	// read tools are exposed and every result is marked with the snapshot
+	Snapshot *mtvmcp.SnapshotInfo
+}
+
+// toolRegistration describes a tool that can be registered on the server
+type toolRegistration struct {
+	Name string
+	// ReadOnly marks tools that do not change the cluster, from their read-only hint
+	ReadOnly bool
+	// Profile is the least privileged profile that includes the tool
+	Profile  string
+	register func(server *mcp.Server)
+}
 
-	// Register read-only tools
-	mcp.AddTool(server, tools.GetListResourcesTool(), tools.HandleListResources)
-	mcp.AddTool(server, tools.GetListInventoryTool(), tools.HandleListInventory)
-	mcp.AddTool(server, tools.GetGetLogsTool(), tools.HandleGetLogs)
-	mcp.AddTool(server, tools.GetGetMigrationStorageTool(), tools.HandleGetMigrationStorage)
-	mcp.AddTool(server, tools.GetGetPlanVmsTool(), tools.HandleGetPlanVms)
+// newToolRegistration wraps a typed tool handler into a toolRegistration
+func newToolRegistration[In, Out any](tool *mcp.Tool, handler mcp.ToolHandlerFor[In, Out], profile string) toolRegistration {
+	return toolRegistration{
+		Name:     tool.Name,
+		ReadOnly: tool.Annotations != nil && tool.Annotations.ReadOnlyHint,
+		Profile:  profile,
+		register: func(server *mcp.Server) {
+			mcp.AddTool(server, tool, handler)
+		},
+	}
+}
 
-	// GetVersion tool - kept here since extraction script skipped it
-	mcp.AddTool(server, &mcp.Tool{
+// getVersionTool returns the GetVersion tool definition
+func getVersionTool() *mcp.Tool {
+	return &mcp.Tool{
 		Name: "GetVersion",
 		Description: `Get kubectl-mtv and MTV operator version information.
 
@@ -31,24 +60,117 @@ This tool provides comprehensive version information including:
 
 This is essential for troubleshooting MTV setup and understanding the deployment.
 
+Args:
+    cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
+
 Returns:
     Version information in JSON format`,
-	}, handleGetVersion)
-
-	// Register write tools (USE WITH CAUTION)
-	mcp.AddTool(server, tools.GetManagePlanLifecycleTool(), tools.HandleManagePlanLifecycle)
-	mcp.AddTool(server, tools.GetCreateProviderTool(), tools.HandleCreateProvider)
-	mcp.AddTool(server, tools.GetManageMappingTool(), tools.HandleManageMapping)
-	mcp.AddTool(server, tools.GetCreatePlanTool(), tools.HandleCreatePlan)
-	mcp.AddTool(server, tools.GetCreateHostTool(), tools.HandleCreateHost)
-	mcp.AddTool(server, tools.GetCreateHookTool(), tools.HandleCreateHook)
-	mcp.AddTool(server, tools.GetDeleteProviderTool(), tools.HandleDeleteProvider)
-	mcp.AddTool(server, tools.GetDeletePlanTool(), tools.HandleDeletePlan)
-	mcp.AddTool(server, tools.GetDeleteHostTool(), tools.HandleDeleteHost)
-	mcp.AddTool(server, tools.GetDeleteHookTool(), tools.HandleDeleteHook)
-	mcp.AddTool(server, tools.GetPatchProviderTool(), tools.HandlePatchProvider)
-	mcp.AddTool(server, tools.GetPatchPlanTool(), tools.HandlePatchPlan)
-	mcp.AddTool(server, tools.GetPatchPlanVmTool(), tools.HandlePatchPlanVm)
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "Get Version",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
+	}
+}
+
+// allTools returns every tool known to the server
+func allTools() []toolRegistration {
+	return []toolRegistration{
+		// Viewer tools (read-only)
+		newToolRegistration(tools.GetListResourcesTool(), tools.HandleListResources, ProfileViewer),
+		newToolRegistration(tools.GetListInventoryTool(), tools.HandleListInventory, ProfileViewer),
+		newToolRegistration(tools.GetGetLogsTool(), tools.HandleGetLogs, ProfileViewer),
+		newToolRegistration(tools.GetGetMigrationStorageTool(), tools.HandleGetMigrationStorage, ProfileViewer),
+		newToolRegistration(tools.GetGetPlanVmsTool(), tools.HandleGetPlanVms, ProfileViewer),
+		newToolRegistration(getVersionTool(), handleGetVersion, ProfileViewer),
+		newToolRegistration(listClustersTool(), handleListClusters, ProfileViewer),
+
+		// Operator tools (USE WITH CAUTION)
+		newToolRegistration(tools.GetManagePlanLifecycleTool(), tools.HandleManagePlanLifecycle, ProfileOperator),
+		newToolRegistration(tools.GetPatchPlanTool(), tools.HandlePatchPlan, ProfileOperator),
+		newToolRegistration(tools.GetPatchPlanVmTool(), tools.HandlePatchPlanVm, ProfileOperator),
+
+		// Admin tools (USE WITH CAUTION)
+		newToolRegistration(tools.GetCreateProviderTool(), tools.HandleCreateProvider, ProfileAdmin),
+		newToolRegistration(tools.GetManageMappingTool(), tools.HandleManageMapping, ProfileAdmin),
+		newToolRegistration(tools.GetCreatePlanTool(), tools.HandleCreatePlan, ProfileAdmin),
+		newToolRegistration(tools.GetCreateHostTool(), tools.HandleCreateHost, ProfileAdmin),
+		newToolRegistration(tools.GetCreateHookTool(), tools.HandleCreateHook, ProfileAdmin),
+		newToolRegistration(tools.GetDeleteProviderTool(), tools.HandleDeleteProvider, ProfileAdmin),
+		newToolRegistration(tools.GetDeletePlanTool(), tools.HandleDeletePlan, ProfileAdmin),
+		newToolRegistration(tools.GetDeleteHostTool(), tools.HandleDeleteHost, ProfileAdmin),
+		newToolRegistration(tools.GetDeleteHookTool(), tools.HandleDeleteHook, ProfileAdmin),
+		newToolRegistration(tools.GetPatchProviderTool(), tools.HandlePatchProvider, ProfileAdmin),
+
+		// Admin read-only tools: the audit log shows every caller's inputs and commands
+		newToolRegistration(getAuditLogTool(), handleGetAuditLog, ProfileAdmin),
+	}
+}
+
+// CreateServer creates an MCP server exposing the tools allowed by opts
+func CreateServer(opts ServerOptions) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{
+		Name:    "kubectl-mtv",
+		Version: Version,
+	}, &mcp.ServerOptions{InitializedHandler: trackSession})
+
+	// registered maps each exposed tool name to its read-only flag
+	registered := make(map[string]bool)
+	for _, tool := range selectTools(opts) {
+		tool.register(server)
+		registered[tool.Name] = tool.ReadOnly
+	}
+
+	// Drop cached read results once a write tool changes their namespace
+	server.AddReceivingMiddleware(cacheInvalidationMiddleware(writeToolNames()))
+
+	// Rate limit the commands of each caller
+	server.AddReceivingMiddleware(rateLimitMiddleware())
+
+	// Track tool calls so that shutdown can drain them
+	server.AddReceivingMiddleware(inFlightCalls.middleware())
+
+	// Refuse calls to tools that are not exposed, even if a client calls them by name
+	server.AddReceivingMiddleware(toolGuardMiddleware(registered))
+
+	if opts.Snapshot != nil {
+		server.AddReceivingMiddleware(snapshotMiddleware(*opts.Snapshot))
+	}
+
+	// Record every write tool call, including the ones refused above
+	if auditLog := mtvmcp.DefaultAuditLog(); auditLog != nil {
+		server.AddReceivingMiddleware(auditMiddleware(auditLog, writeToolNames()))
+	}
+
+	// Added last so it is outermost and also counts rejected calls
+	server.AddReceivingMiddleware(metricsMiddleware(toolNames()))
 
 	return server
 }
+
+// CreateReadServer creates an MCP server exposing all read and write tools.
+//
+// Deprecated: use CreateServer, which allows restricting the exposed tools.
+func CreateReadServer() *mcp.Server {
+	return CreateServer(ServerOptions{})
+}
+
+// toolGuardMiddleware rejects tools/call requests for tools that are not registered,
+// and write tool calls that carry the read-only HTTP header
+func toolGuardMiddleware(registered map[string]bool) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			if callReq, ok := req.(*mcp.CallToolRequest); ok {
+				name := callReq.Params.Name
+				readOnly, ok := registered[name]
+				if !ok {
+					return nil, fmt.Errorf("tool %q is not available on this server", name)
+				}
+				if !readOnly && callReq.Extra != nil && readOnlyRequested(callReq.Extra.Header) {
+					return nil, fmt.Errorf("tool %q is a write tool and the request is read-only", name)
+				}
+			}
+			return next(ctx, method, req)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package cmd
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// connectTestClient connects an in-memory client session to the given server
+func connectTestClient(t *testing.T, server *mcp.Server) *mcp.ClientSession {
+	t.Helper()
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+	if _, err := server.Connect(ctx, serverTransport, nil); err != nil {
+		t.Fatalf("failed to connect server: %v", err)
+	}
+	client := mcp.NewClient(&mcp.Implementation{Name: "test-client", Version: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		t.Fatalf("failed to connect client: %v", err)
+	}
+	t.Cleanup(func() { _ = session.Close() })
+	return session
+}
+
+// listToolNames returns the sorted names of the tools exposed by the session
+func listToolNames(t *testing.T, session *mcp.ClientSession) []string {
+	t.Helper()
+	result, err := session.ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to list tools: %v", err)
+	}
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCreateServerReadOnly(t *testing.T) {
+	session := connectTestClient(t, CreateServer(ServerOptions{ReadOnly: true}))
+
+	expected := []string{"GetAuditLog", "GetLogs", "GetMigrationStorage", "GetPlanVms", "GetVersion", "ListClusters", "ListInventory", "ListResources"}
+	names := listToolNames(t, session)
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected read-only tools %v, got %v", expected, names)
+	}
+
+	// Write tools must be refused even when called by name
+	_, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "DeletePlan",
+		Arguments: map[string]any{"plan_name": "my-plan", "dry_run": true},
+	})
+	if err == nil {
+		t.Errorf("Expected DeletePlan to be refused in read-only mode")
+	}
+}
+
+func TestCreateServerAllTools(t *testing.T) {
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+
+	names := listToolNames(t, session)
+	if len(names) != len(allTools()) {
+		t.Errorf("Expected %d tools, got %d: %v", len(allTools()), len(names), names)
+	}
+}
+
+func TestToolAnnotations(t *testing.T) {
+	result, err := connectTestClient(t, CreateServer(ServerOptions{})).ListTools(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("failed to list tools: %v", err)
+	}
+
+	readOnly := make(map[string]bool)
+	for _, tool := range allTools() {
+		readOnly[tool.Name] = tool.ReadOnly
+	}
+
+	for _, tool := range result.Tools {
+		annotations := tool.Annotations
+		if annotations == nil {
+			t.Errorf("Tool %s has no annotations", tool.Name)
+			continue
+		}
+		if annotations.Title == "" {
+			t.Errorf("Tool %s has no title annotation", tool.Name)
+		}
+		if annotations.ReadOnlyHint != readOnly[tool.Name] {
+			t.Errorf("Tool %s readOnlyHint is %v, expected %v", tool.Name, annotations.ReadOnlyHint, readOnly[tool.Name])
+		}
+		if tool.OutputSchema == nil {
+			t.Errorf("Tool %s has no output schema", tool.Name)
+		}
+		// destructiveHint defaults to true, so write tools must set it explicitly
+		if !annotations.ReadOnlyHint && annotations.DestructiveHint == nil {
+			t.Errorf("Tool %s is a write tool without a destructiveHint", tool.Name)
+		}
+	}
+
+	expected := map[string]struct {
+		destructive bool
+		idempotent  bool
+	}{
+		"DeletePlan":     {destructive: true, idempotent: true},
+		"DeleteProvider": {destructive: true, idempotent: true},
+		"PatchPlan":      {destructive: false, idempotent: false},
+		"CreatePlan":     {destructive: false, idempotent: false},
+	}
+	for _, tool := range result.Tools {
+		want, ok := expected[tool.Name]
+		if !ok || tool.Annotations == nil || tool.Annotations.DestructiveHint == nil {
+			continue
+		}
+		if *tool.Annotations.DestructiveHint != want.destructive || tool.Annotations.IdempotentHint != want.idempotent {
+			t.Errorf("Tool %s has destructiveHint=%v idempotentHint=%v, expected %v/%v", tool.Name,
+				*tool.Annotations.DestructiveHint, tool.Annotations.IdempotentHint, want.destructive, want.idempotent)
+		}
+	}
+}
+
+func TestStructuredContentMatchesOutputSchema(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "inventory", "vm", "-A", "-o", "json", "--", "vsphere"}, mtvmcp.FakeResponse{
+		Stdout: `[{"id": "vm-1", "name": "web", "cpuCount": 2, "disks": [{"file": "[ds1] web.vmdk"}]}]`,
+	})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "ListInventory",
+		Arguments: map[string]any{"resource_type": "vm", "provider_name": "vsphere", "all_namespaces": true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.IsError {
+		t.Fatalf("Expected structured content to validate, got error %v", result.Content)
+	}
+	content, _ := result.StructuredContent.(map[string]any)
+	vms, _ := content["vms"].([]any)
+	if len(vms) != 1 {
+		t.Errorf("Expected one VM in structured content, got %v", content)
+	}
+}
+
+func TestCommandFailureIsError(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"delete", "plan", "--", "my-plan"}, mtvmcp.FakeResponse{
+		Stderr:   `Error from server (Forbidden): plans.forklift.konveyor.io "my-plan" is forbidden`,
+		ExitCode: 1,
+	})
+	mtvmcp.SetDefaultExecutor(fake)
+	t.Cleanup(func() { mtvmcp.SetDefaultExecutor(mtvmcp.SubprocessExecutor{}) })
+
+	session := connectTestClient(t, CreateServer(ServerOptions{}))
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "DeletePlan",
+		Arguments: map[string]any{"plan_name": "my-plan"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !result.IsError || len(result.Content) != 1 {
+		t.Fatalf("Expected an error result, got %+v", result)
+	}
+	text, _ := result.Content[0].(*mcp.TextContent)
+	if text == nil || !strings.Contains(text.Text, `"type": "forbidden"`) {
+		t.Errorf("Expected a forbidden error type, got %v", result.Content[0])
+	}
+}
+
+func TestCreateServerSnapshot(t *testing.T) {
+	capturedAt := time.Date(2026, 10, 1, 8, 30, 0, 0, time.UTC)
+	session := connectTestClient(t, CreateServer(ServerOptions{
+		Snapshot: &mtvmcp.SnapshotInfo{Source: "must-gather", Path: "/tmp/mg", CapturedAt: capturedAt},
+	}))
+
+	expected := []string{"GetLogs", "GetMigrationStorage", "GetPlanVms", "ListResources"}
+	names := listToolNames(t, session)
+	if strings.Join(names, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected snapshot tools %v, got %v", expected, names)
+	}
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "ListResources",
+		Arguments: map[string]any{"resource_type": "plan", "dry_run": true},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := result.Meta["snapshot"]; !ok {
+		t.Errorf("Expected snapshot metadata, got %v", result.Meta)
+	}
+	content, ok := result.StructuredContent.(map[string]any)
+	if !ok {
+		t.Fatalf("Unexpected structured content %T", result.StructuredContent)
+	}
+	snapshot, _ := content["snapshot"].(map[string]any)
+	if snapshot["captured_at"] != "2026-10-01T08:30:00Z" {
+		t.Errorf("Expected capture timestamp in structured content, got %v", content["snapshot"])
+	}
+}
+
+func TestSelectToolsProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ServerOptions
+		expected []string
+	}{
+		{
+			name:     "viewer profile",
+			opts:     ServerOptions{Profile: ProfileViewer},
+			expected: []string{"GetLogs", "GetMigrationStorage", "GetPlanVms", "GetVersion", "ListClusters", "ListInventory", "ListResources"},
+		},
+		{
+			name: "operator profile",
+			opts: ServerOptions{Profile: ProfileOperator},
+			expected: []string{"GetLogs", "GetMigrationStorage", "GetPlanVms", "GetVersion", "ListClusters", "ListInventory", "ListResources",
+				"ManagePlanLifecycle", "PatchPlan", "PatchPlanVm"},
+		},
+		{
+			name:     "viewer profile with enabled and disabled tools",
+			opts:     ServerOptions{Profile: ProfileViewer, EnableTools: []string{"PatchPlan"}, DisableTools: []string{"GetLogs"}},
+			expected: []string{"GetMigrationStorage", "GetPlanVms", "GetVersion", "ListClusters", "ListInventory", "ListResources", "PatchPlan"},
+		},
+		{
+			name:     "read-only overrides enabled write tools",
+			opts:     ServerOptions{Profile: ProfileAdmin, ReadOnly: true, EnableTools: []string{"DeletePlan"}},
+			expected: []string{"GetAuditLog", "GetLogs", "GetMigrationStorage", "GetPlanVms", "GetVersion", "ListClusters", "ListInventory", "ListResources"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names []string
+			for _, tool := range selectTools(tt.opts) {
+				names = append(names, tool.Name)
+			}
+			sort.Strings(names)
+			sort.Strings(tt.expected)
+			if strings.Join(names, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("Expected tools %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestTokenProfiles(t *testing.T) {
+	// SHA-256 digest of "test"
+	profiles := TokenProfiles{"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08": ProfileViewer}
+
+	if profile, ok := profiles.ProfileForToken("test"); !ok || profile != ProfileViewer {
+		t.Errorf("Expected token to map to viewer profile, got %q (found: %v)", profile, ok)
+	}
+	if _, ok := profiles.ProfileForToken("other"); ok {
+		t.Errorf("Expected unknown token to have no profile")
+	}
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// DefaultShutdownGracePeriod is how long in-flight tool calls may run after SIGINT or
+// SIGTERM, below the default Kubernetes termination grace period of 30 seconds
+const DefaultShutdownGracePeriod = 25 * time.Second
+
+// interruptWait bounds the wait for cancelled tool calls to return after the grace period
+const interruptWait = 10 * time.Second
+
+// callTracker tracks in-flight tool calls so that shutdown can drain them.
+// Once draining, new tool calls are refused; when the grace period is over the
+// remaining calls are cancelled through their contexts.
+type callTracker struct {
+	mu       sync.Mutex
+	draining bool
+	calls    map[int64]string
+	nextID   int64
+	idle     chan struct{}
+
+	// stop is cancelled to interrupt the calls still running after the grace period
+	stop   context.Context
+	cancel context.CancelFunc
+}
+
+func newCallTracker() *callTracker {
+	stop, cancel := context.WithCancel(context.Background())
+	return &callTracker{calls: make(map[int64]string), stop: stop, cancel: cancel}
+}
+
+// inFlightCalls tracks the tool calls of every server created by CreateServer
+var inFlightCalls = newCallTracker()
+
+// begin registers a tool call, returning false once the tracker is draining
+func (t *callTracker) begin(tool string) (int64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return 0, false
+	}
+	t.nextID++
+	t.calls[t.nextID] = tool
+	return t.nextID, true
+}
+
+// end unregisters a tool call
+func (t *callTracker) end(id int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.calls, id)
+	if len(t.calls) == 0 && t.idle != nil {
+		close(t.idle)
+		t.idle = nil
+	}
+}
+
+// running returns the names of the tool calls still in flight
+func (t *callTracker) running() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.calls))
+	for _, name := range t.calls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// drain refuses new tool calls and returns a channel closed when no call is in flight
+func (t *callTracker) drain() <-chan struct{} {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.draining = true
+	idle := make(chan struct{})
+	if len(t.calls) == 0 {
+		close(idle)
+		return idle
+	}
+	if t.idle == nil {
+		t.idle = make(chan struct{})
+	}
+	return t.idle
+}
+
+// middleware refuses tool calls while draining and makes the others cancellable by shutdown
+func (t *callTracker) middleware() mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			callReq, ok := req.(*mcp.CallToolRequest)
+			if !ok {
+				return next(ctx, method, req)
+			}
+
+			id, ok := t.begin(callReq.Params.Name)
+			if !ok {
+				return nil, fmt.Errorf("the server is shutting down, retry the %s call on another instance", callReq.Params.Name)
+			}
+			defer t.end(id)
+
+			ctx, cancel := context.WithCancel(ctx)
+			defer cancel()
+			stopInterrupt := context.AfterFunc(t.stop, cancel)
+			defer stopInterrupt()
+			return next(ctx, method, req)
+		}
+	}
+}
+
+// shutdown drains the in-flight tool calls for up to grace, then cancels and logs the remaining ones
+func (t *callTracker) shutdown(grace time.Duration) {
+	idle := t.drain()
+	if running := t.running(); len(running) > 0 {
+		log.Printf("Waiting up to %s for %d in-flight tool call(s): %s", grace, len(running), strings.Join(running, ", "))
+	}
+
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-idle:
+		return
+	case <-timer.C:
+	}
+
+	log.Printf("Grace period expired, interrupting tool call(s): %s", strings.Join(t.running(), ", "))
+	for _, command := range mtvmcp.RunningCommands() {
+		log.Printf("Interrupted command: %s", command)
+	}
+	t.cancel()
+
+	select {
+	case <-idle:
+	case <-time.After(interruptWait):
+		log.Printf("Tool call(s) still running after interruption: %s", strings.Join(t.running(), ", "))
+	}
+}
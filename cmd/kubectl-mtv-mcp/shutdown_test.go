@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// callToolRequest builds a tools/call request for the tracker middleware
+func callToolRequest(name string) *mcp.CallToolRequest {
+	return &mcp.CallToolRequest{Params: &mcp.CallToolParamsRaw{Name: name}}
+}
+
+func TestShutdownDrainsInFlightCalls(t *testing.T) {
+	tracker := newCallTracker()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := tracker.middleware()(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		close(started)
+		select {
+		case <-release:
+			return &mcp.CallToolResult{}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := handler(context.Background(), "tools/call", callToolRequest("CreatePlan"))
+		callErr <- err
+	}()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		tracker.shutdown(time.Minute)
+		close(done)
+	}()
+
+	// New calls are refused while draining
+	for deadline := time.Now().Add(5 * time.Second); !isDraining(tracker); time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the tracker to drain")
+		}
+	}
+	if _, err := handler(context.Background(), "tools/call", callToolRequest("DeletePlan")); err == nil || !strings.Contains(err.Error(), "shutting down") {
+		t.Errorf("Expected new tool calls to be refused, got %v", err)
+	}
+
+	close(release)
+	if err := <-callErr; err != nil {
+		t.Errorf("Expected the in-flight call to finish, got %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected shutdown to return once the in-flight call finished")
+	}
+}
+
+// isDraining reports whether the tracker refuses new calls
+func isDraining(tracker *callTracker) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+	return tracker.draining
+}
+
+func TestShutdownCancelsCallsAfterGracePeriod(t *testing.T) {
+	tracker := newCallTracker()
+	started := make(chan struct{})
+	handler := tracker.middleware()(func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	callErr := make(chan error, 1)
+	go func() {
+		_, err := handler(context.Background(), "tools/call", callToolRequest("CreatePlan"))
+		callErr <- err
+	}()
+	<-started
+
+	if running := tracker.running(); len(running) != 1 || running[0] != "CreatePlan" {
+		t.Errorf("Expected CreatePlan in flight, got %v", running)
+	}
+
+	start := time.Now()
+	tracker.shutdown(50 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Shutdown took %s", elapsed)
+	}
+	if err := <-callErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the call to be cancelled, got %v", err)
+	}
+	if running := tracker.running(); len(running) != 0 {
+		t.Errorf("Expected no call in flight, got %v", running)
+	}
+}
+
+func TestServeHTTPShutdown(t *testing.T) {
+	tracker := inFlightCalls
+	inFlightCalls = newCallTracker()
+	t.Cleanup(func() { inFlightCalls = tracker })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- serveHTTP(ctx, httpOptions{Addr: "127.0.0.1:0", Streamable: true, ShutdownGracePeriod: time.Second})
+	}()
+	cancel()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("Expected a clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected serveHTTP to return after shutdown")
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// snapshotTools are the read tools that can be answered from a must-gather snapshot
+var snapshotTools = map[string]bool{
+	"ListResources":       true,
+	"GetPlanVms":          true,
+	"GetMigrationStorage": true,
+	"GetLogs":             true,
+}
+
+// snapshotMiddleware marks every tool result as served from an offline snapshot.
+// The snapshot description is added to the result metadata and, for JSON object
+// results, to the structured content so that it is visible to the model.
+func snapshotMiddleware(info mtvmcp.SnapshotInfo) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			result, err := next(ctx, method, req)
+			if err != nil {
+				return result, err
+			}
+
+			callResult, ok := result.(*mcp.CallToolResult)
+			if !ok || callResult == nil {
+				return result, nil
+			}
+			markSnapshot(callResult, info)
+			return callResult, nil
+		}
+	}
+}
+
+// markSnapshot adds the snapshot description to a tool result
+func markSnapshot(result *mcp.CallToolResult, info mtvmcp.SnapshotInfo) {
+	if result.Meta == nil {
+		result.Meta = mcp.Meta{}
+	}
+	result.Meta["snapshot"] = info
+
+	raw, ok := result.StructuredContent.(json.RawMessage)
+	if !ok {
+		return
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(raw, &content); err != nil {
+		return
+	}
+	content["snapshot"] = info
+
+	marked, err := json.Marshal(content)
+	if err != nil {
+		return
+	}
+	result.StructuredContent = json.RawMessage(marked)
+
+	// Keep the text content in sync when it mirrors the structured content
+	if len(result.Content) == 1 {
+		if text, ok := result.Content[0].(*mcp.TextContent); ok && text.Text == string(raw) {
+			text.Text = string(marked)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// tokenEnvVar is the environment variable holding the bearer token in stdio mode
+const tokenEnvVar = "KUBECTL_MTV_MCP_TOKEN"
+
+// tokenSource returns the bearer token for a request, or "" to use the kubeconfig credentials
+type tokenSource func() (string, error)
+
+// stdioTokenSource returns the token source for stdio mode.
+// A token file is read on every request, so rotated tokens (e.g. projected
+// service account tokens) are picked up without a restart. Otherwise the token
+// is taken from the KUBECTL_MTV_MCP_TOKEN environment variable.
+func stdioTokenSource(tokenFile string) (tokenSource, error) {
+	if tokenFile != "" {
+		source := func() (string, error) {
+			data, err := os.ReadFile(tokenFile)
+			if err != nil {
+				return "", fmt.Errorf("failed to read token file: %w", err)
+			}
+			token := strings.TrimSpace(string(data))
+			if token == "" {
+				return "", fmt.Errorf("token file %s is empty", tokenFile)
+			}
+			return token, nil
+		}
+		// Fail at startup rather than on the first tool call
+		if _, err := source(); err != nil {
+			return nil, err
+		}
+		return source, nil
+	}
+
+	if token := strings.TrimSpace(os.Getenv(tokenEnvVar)); token != "" {
+		return func() (string, error) { return token, nil }, nil
+	}
+	return nil, nil
+}
+
+// stdioTokenMiddleware adds the token of source to the context of every request
+func stdioTokenMiddleware(source tokenSource) mcp.Middleware {
+	return func(next mcp.MethodHandler) mcp.MethodHandler {
+		return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			token, err := source()
+			if err != nil {
+				return nil, err
+			}
+			return next(mtvmcp.WithKubeToken(ctx, token), method, req)
+		}
+	}
+}
+
+// serveStdio runs the server over stdin/stdout until the client disconnects or ctx
+// is done; on ctx the in-flight tool calls are drained for up to grace
+func serveStdio(ctx context.Context, server *mcp.Server, grace time.Duration) error {
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Run(runCtx, &mcp.StdioTransport{})
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Printf("Shutting down: no longer accepting tool calls")
+	inFlightCalls.shutdown(grace)
+	cancel()
+	<-done
+	log.Printf("Shutdown complete")
+	return nil
+}
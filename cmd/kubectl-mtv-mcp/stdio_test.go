@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+func TestStdioTokenSource(t *testing.T) {
+	t.Run("no token", func(t *testing.T) {
+		t.Setenv(tokenEnvVar, "")
+		source, err := stdioTokenSource("")
+		if err != nil || source != nil {
+			t.Errorf("Expected no token source, got %v, %v", source, err)
+		}
+	})
+
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv(tokenEnvVar, " env-token\n")
+		source, err := stdioTokenSource("")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if token, _ := source(); token != "env-token" {
+			t.Errorf("Expected env-token, got %q", token)
+		}
+	})
+
+	t.Run("file is re-read", func(t *testing.T) {
+		t.Setenv(tokenEnvVar, "env-token")
+		path := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(path, []byte("first\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		source, err := stdioTokenSource(path)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if err := os.WriteFile(path, []byte("rotated\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if token, _ := source(); token != "rotated" {
+			t.Errorf("Expected the rotated token, got %q", token)
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := stdioTokenSource(filepath.Join(t.TempDir(), "missing")); err == nil {
+			t.Errorf("Expected an error for a missing token file")
+		}
+	})
+
+	t.Run("empty file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "token")
+		if err := os.WriteFile(path, []byte("\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stdioTokenSource(path); err == nil {
+			t.Errorf("Expected an error for an empty token file")
+		}
+	})
+}
+
+func TestStdioTokenMiddleware(t *testing.T) {
+	var got string
+	handler := stdioTokenMiddleware(func() (string, error) { return "stdio-token", nil })(
+		func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+			got, _ = mtvmcp.GetKubeToken(ctx)
+			return nil, nil
+		})
+
+	if _, err := handler(context.Background(), "tools/call", &mcp.CallToolRequest{}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got != "stdio-token" {
+		t.Errorf("Expected stdio-token in the request context, got %q", got)
+	}
+}
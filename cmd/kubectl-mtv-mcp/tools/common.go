@@ -10,14 +10,41 @@ import (
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
 )
 
+// runKubectlStdout runs a kubectl command and returns its stdout,
+// or a classified error if the command failed
+func runKubectlStdout(ctx context.Context, args []string) (string, error) {
+	result, err := mtvmcp.RunKubectlCommand(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	output, err := mtvmcp.ParseCommandOutput(result)
+	if err != nil {
+		return "", err
+	}
+	return output.Stdout, nil
+}
+
+// runKubectlMTVStdout runs a kubectl-mtv command and returns its stdout,
+// or a classified error if the command failed
+func runKubectlMTVStdout(ctx context.Context, args []string) (string, error) {
+	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
+	if err != nil {
+		return "", err
+	}
+	output, err := mtvmcp.ParseCommandOutput(result)
+	if err != nil {
+		return "", err
+	}
+	return output.Stdout, nil
+}
+
 // findControllerPod finds the forklift-controller pod in the specified namespace
 func findControllerPod(ctx context.Context, namespace string) (string, error) {
-	output, err := mtvmcp.RunKubectlCommand(ctx, []string{"get", "pods", "-n", namespace, "-l", "app=forklift-controller", "-o", "jsonpath={.items[0].metadata.name}"})
+	podName, err := runKubectlStdout(ctx, []string{"get", "pods", "-n", namespace, "-l", "app=forklift-controller", "-o", "jsonpath={.items[0].metadata.name}"})
 	if err != nil {
-		return "", fmt.Errorf("failed to find controller pod: %w", err)
+		return "", err
 	}
 
-	podName := mtvmcp.ExtractStdoutFromResponse(output)
 	podName = strings.TrimSpace(podName)
 	if podName == "" {
 		return "", fmt.Errorf("no controller pod found in namespace %s", namespace)
@@ -27,23 +54,21 @@ func findControllerPod(ctx context.Context, namespace string) (string, error) {
 }
 
 // getControllerLogs retrieves logs from the controller pod
-func getControllerLogs(ctx context.Context, container string, lines int, follow bool, namespace string) (*mcp.CallToolResult, any, error) {
+func getControllerLogs(ctx context.Context, container string, lines int, follow bool, namespace string) (*mcp.CallToolResult, *GetLogsOutput, error) {
 	// Get MTV operator namespace if not provided
 	if namespace == "" {
-		versionOutput, err := mtvmcp.RunKubectlMTVCommand(ctx, []string{"version", "-o", "json"})
+		stdout, err := runKubectlMTVStdout(ctx, []string{"version", "-o", "json"})
 		if err != nil {
-			return nil, "", fmt.Errorf("failed to get operator namespace: %w", err)
+			return nil, nil, err
 		}
-
-		stdout := mtvmcp.ExtractStdoutFromResponse(versionOutput)
 		var versionData map[string]interface{}
 		if err := json.Unmarshal([]byte(stdout), &versionData); err != nil {
-			return nil, "", fmt.Errorf("failed to parse version output: %w", err)
+			return nil, nil, fmt.Errorf("failed to parse version output: %w", err)
 		}
 
 		ns, ok := versionData["operatorNamespace"].(string)
 		if !ok || ns == "" {
-			return nil, "", fmt.Errorf("operatorNamespace not found in version output")
+			return nil, nil, fmt.Errorf("operatorNamespace not found in version output")
 		}
 		namespace = ns
 	}
@@ -51,19 +76,17 @@ func getControllerLogs(ctx context.Context, container string, lines int, follow
 	// Find controller pod
 	podName, err := findControllerPod(ctx, namespace)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Get pod information
-	podInfoOutput, err := mtvmcp.RunKubectlCommand(ctx, []string{"get", "pod", "-n", namespace, podName, "-o", "json"})
+	podStdout, err := runKubectlStdout(ctx, []string{"get", "pod", "-n", namespace, podName, "-o", "json"})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get pod info: %w", err)
+		return nil, nil, err
 	}
-
-	podStdout := mtvmcp.ExtractStdoutFromResponse(podInfoOutput)
-	var podInfo map[string]interface{}
+	var podInfo PodInfo
 	if err := json.Unmarshal([]byte(podStdout), &podInfo); err != nil {
-		return nil, "", fmt.Errorf("failed to parse pod info: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse pod info: %w", err)
 	}
 
 	// Build kubectl logs command
@@ -78,47 +101,38 @@ func getControllerLogs(ctx context.Context, container string, lines int, follow
 		logsArgs = append(logsArgs, "-f")
 	}
 
-	logsOutput, err := mtvmcp.RunKubectlCommand(ctx, logsArgs)
+	logsStdout, err := runKubectlStdout(ctx, logsArgs)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get logs: %w", err)
-	}
-
-	logsStdout := mtvmcp.ExtractStdoutFromResponse(logsOutput)
-
-	result := map[string]interface{}{
-		"pod":  podInfo,
-		"logs": logsStdout,
+		return nil, nil, err
 	}
 
-	return nil, result, nil
+	return nil, &GetLogsOutput{Pod: podInfo, Logs: logsStdout}, nil
 }
 
 // getImporterLogs retrieves logs from an importer pod
-func getImporterLogs(ctx context.Context, lines int, follow bool, namespace, planID, migrationID, vmID string) (*mcp.CallToolResult, any, error) {
+func getImporterLogs(ctx context.Context, lines int, follow bool, namespace, planID, migrationID, vmID string) (*mcp.CallToolResult, *GetLogsOutput, error) {
 	if planID == "" || migrationID == "" || vmID == "" {
-		return nil, "", fmt.Errorf("plan_id, migration_id, and vm_id are required for importer pod logs")
+		return nil, nil, fmt.Errorf("plan_id, migration_id, and vm_id are required for importer pod logs")
 	}
 
 	if namespace == "" {
-		return nil, "", fmt.Errorf("namespace is required for importer pod logs")
+		return nil, nil, fmt.Errorf("namespace is required for importer pod logs")
 	}
 
 	// Find PVCs with migration labels
 	labelSelector := fmt.Sprintf("plan=%s,migration=%s,vmID=%s", planID, migrationID, vmID)
-	pvcsOutput, err := mtvmcp.RunKubectlCommand(ctx, []string{"get", "pvc", "-n", namespace, "-l", labelSelector, "-o", "json"})
+	pvcsStdout, err := runKubectlStdout(ctx, []string{"get", "pvc", "-n", namespace, "-l", labelSelector, "-o", "json"})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get PVCs: %w", err)
+		return nil, nil, err
 	}
-
-	pvcsStdout := mtvmcp.ExtractStdoutFromResponse(pvcsOutput)
 	var pvcsData map[string]interface{}
 	if err := json.Unmarshal([]byte(pvcsStdout), &pvcsData); err != nil {
-		return nil, "", fmt.Errorf("failed to parse PVCs: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse PVCs: %w", err)
 	}
 
 	pvcs, ok := pvcsData["items"].([]interface{})
 	if !ok || len(pvcs) == 0 {
-		return nil, "", fmt.Errorf("no PVCs found with labels plan=%s, migration=%s, vmID=%s", planID, migrationID, vmID)
+		return nil, nil, fmt.Errorf("no PVCs found with labels plan=%s, migration=%s, vmID=%s", planID, migrationID, vmID)
 	}
 
 	// Find migration PVC UID
@@ -140,25 +154,23 @@ func getImporterLogs(ctx context.Context, lines int, follow bool, namespace, pla
 	}
 
 	if migrationPVCUID == "" {
-		return nil, "", fmt.Errorf("could not find migration PVC UID")
+		return nil, nil, fmt.Errorf("could not find migration PVC UID")
 	}
 
 	// Find prime PVC owned by migration PVC
-	allPVCsOutput, err := mtvmcp.RunKubectlCommand(ctx, []string{"get", "pvc", "-n", namespace, "-o", "json"})
+	allPVCsStdout, err := runKubectlStdout(ctx, []string{"get", "pvc", "-n", namespace, "-o", "json"})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get all PVCs: %w", err)
+		return nil, nil, err
 	}
-
-	allPVCsStdout := mtvmcp.ExtractStdoutFromResponse(allPVCsOutput)
 	var allPVCsData map[string]interface{}
 	if err := json.Unmarshal([]byte(allPVCsStdout), &allPVCsData); err != nil {
-		return nil, "", fmt.Errorf("failed to parse all PVCs: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse all PVCs: %w", err)
 	}
 
 	var importerPodName string
 	allPVCs, ok := allPVCsData["items"].([]interface{})
 	if !ok {
-		return nil, "", fmt.Errorf("unexpected PVC list format: missing items[]")
+		return nil, nil, fmt.Errorf("unexpected PVC list format: missing items[]")
 	}
 	for _, pvc := range allPVCs {
 		pvcMap, ok := pvc.(map[string]interface{})
@@ -193,19 +205,17 @@ func getImporterLogs(ctx context.Context, lines int, follow bool, namespace, pla
 	}
 
 	if importerPodName == "" {
-		return nil, "", fmt.Errorf("could not find importer pod name in PVC annotations")
+		return nil, nil, fmt.Errorf("could not find importer pod name in PVC annotations")
 	}
 
 	// Get pod information
-	podInfoOutput, err := mtvmcp.RunKubectlCommand(ctx, []string{"get", "pod", "-n", namespace, importerPodName, "-o", "json"})
+	podStdout, err := runKubectlStdout(ctx, []string{"get", "pod", "-n", namespace, importerPodName, "-o", "json"})
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get pod info: %w", err)
+		return nil, nil, err
 	}
-
-	podStdout := mtvmcp.ExtractStdoutFromResponse(podInfoOutput)
-	var podInfo map[string]interface{}
+	var podInfo PodInfo
 	if err := json.Unmarshal([]byte(podStdout), &podInfo); err != nil {
-		return nil, "", fmt.Errorf("failed to parse pod info: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse pod info: %w", err)
 	}
 
 	// Build kubectl logs command
@@ -217,24 +227,22 @@ func getImporterLogs(ctx context.Context, lines int, follow bool, namespace, pla
 		logsArgs = append(logsArgs, "-f")
 	}
 
-	logsOutput, err := mtvmcp.RunKubectlCommand(ctx, logsArgs)
+	logsStdout, err := runKubectlStdout(ctx, logsArgs)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get logs: %w", err)
+		return nil, nil, err
 	}
 
-	logsStdout := mtvmcp.ExtractStdoutFromResponse(logsOutput)
+	return nil, &GetLogsOutput{Pod: podInfo, Logs: logsStdout}, nil
+}
 
-	result := map[string]interface{}{
-		"pod":  podInfo,
-		"logs": logsStdout,
+// getMigrationStorage retrieves the PVCs or DataVolumes of a migration, with their describe output
+func getMigrationStorage(ctx context.Context, resource, migrationID, planID, vmID, namespace string, allNamespaces bool) ([]StorageResource, error) {
+	kindName := "PVCs"
+	if resource == "datavolume" {
+		kindName = "DataVolumes"
 	}
 
-	return nil, result, nil
-}
-
-// getMigrationPVCs retrieves PVCs for a migration
-func getMigrationPVCs(ctx context.Context, migrationID, planID, vmID, namespace string, allNamespaces bool) (*mcp.CallToolResult, any, error) {
-	args := []string{"get", "pvc"}
+	args := []string{"get", resource}
 
 	if allNamespaces {
 		args = append(args, "-A")
@@ -260,124 +268,36 @@ func getMigrationPVCs(ctx context.Context, migrationID, planID, vmID, namespace
 
 	args = append(args, "-o", "json")
 
-	output, err := mtvmcp.RunKubectlCommand(ctx, args)
+	stdout, err := runKubectlStdout(ctx, args)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to get PVCs: %w", err)
+		return nil, err
 	}
 
-	stdout := mtvmcp.ExtractStdoutFromResponse(output)
-
-	// Parse and enhance with describe output
-	var pvcsData map[string]interface{}
-	if err := json.Unmarshal([]byte(stdout), &pvcsData); err != nil {
-		return nil, "", fmt.Errorf("failed to parse PVCs: %w", err)
-	}
-
-	// Add describe output for each PVC
-	if items, ok := pvcsData["items"].([]interface{}); ok {
-		for i, item := range items {
-			if pvcMap, ok := item.(map[string]interface{}); ok {
-				if metadata, ok := pvcMap["metadata"].(map[string]interface{}); ok {
-					pvcName, ok := metadata["name"].(string)
-					if !ok {
-						continue
-					}
-					pvcNs := namespace
-					if allNamespaces {
-						if ns, ok := metadata["namespace"].(string); ok {
-							pvcNs = ns
-						}
-					}
-
-					descArgs := []string{"describe", "pvc"}
-					if pvcNs != "" {
-						descArgs = append(descArgs, "-n", pvcNs)
-					}
-					descArgs = append(descArgs, pvcName)
-					describeOutput, _ := mtvmcp.RunKubectlCommand(ctx, descArgs)
-					describeStdout := mtvmcp.ExtractStdoutFromResponse(describeOutput)
-					pvcMap["describe"] = describeStdout
-					items[i] = pvcMap
-				}
-			}
-		}
-		pvcsData["items"] = items
+	var list struct {
+		Items []StorageResource `json:"items"`
 	}
-
-	return nil, pvcsData, nil
-}
-
-// getMigrationDataVolumes retrieves DataVolumes for a migration
-func getMigrationDataVolumes(ctx context.Context, migrationID, planID, vmID, namespace string, allNamespaces bool) (*mcp.CallToolResult, any, error) {
-	args := []string{"get", "datavolume"}
-
-	if allNamespaces {
-		args = append(args, "-A")
-	} else if namespace != "" {
-		args = append(args, "-n", namespace)
+	if err := json.Unmarshal([]byte(stdout), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", kindName, err)
 	}
 
-	// Build label selector
-	var labels []string
-	if migrationID != "" {
-		labels = append(labels, fmt.Sprintf("migration=%s", migrationID))
-	}
-	if planID != "" {
-		labels = append(labels, fmt.Sprintf("plan=%s", planID))
-	}
-	if vmID != "" {
-		labels = append(labels, fmt.Sprintf("vmID=%s", vmID))
-	}
-
-	if len(labels) > 0 {
-		args = append(args, "-l", strings.Join(labels, ","))
-	}
-
-	args = append(args, "-o", "json")
+	// Add describe output for each resource
+	for i, item := range list.Items {
+		if item.Metadata.Name == "" {
+			continue
+		}
+		itemNs := namespace
+		if allNamespaces && item.Metadata.Namespace != "" {
+			itemNs = item.Metadata.Namespace
+		}
 
-	output, err := mtvmcp.RunKubectlCommand(ctx, args)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to get DataVolumes: %w", err)
-	}
-
-	stdout := mtvmcp.ExtractStdoutFromResponse(output)
-
-	// Parse and enhance with describe output
-	var dvsData map[string]interface{}
-	if err := json.Unmarshal([]byte(stdout), &dvsData); err != nil {
-		return nil, "", fmt.Errorf("failed to parse DataVolumes: %w", err)
-	}
-
-	// Add describe output for each DataVolume
-	if items, ok := dvsData["items"].([]interface{}); ok {
-		for i, item := range items {
-			if dvMap, ok := item.(map[string]interface{}); ok {
-				if metadata, ok := dvMap["metadata"].(map[string]interface{}); ok {
-					dvName, ok := metadata["name"].(string)
-					if !ok {
-						continue
-					}
-					dvNs := namespace
-					if allNamespaces {
-						if ns, ok := metadata["namespace"].(string); ok {
-							dvNs = ns
-						}
-					}
-
-					descArgs := []string{"describe", "datavolume"}
-					if dvNs != "" {
-						descArgs = append(descArgs, "-n", dvNs)
-					}
-					descArgs = append(descArgs, dvName)
-					describeOutput, _ := mtvmcp.RunKubectlCommand(ctx, descArgs)
-					describeStdout := mtvmcp.ExtractStdoutFromResponse(describeOutput)
-					dvMap["describe"] = describeStdout
-					items[i] = dvMap
-				}
-			}
+		descArgs := []string{"describe", resource}
+		if itemNs != "" {
+			descArgs = append(descArgs, "-n", itemNs)
 		}
-		dvsData["items"] = items
+		descArgs = append(descArgs, item.Metadata.Name)
+		describeOutput, _ := mtvmcp.RunKubectlCommand(ctx, descArgs)
+		list.Items[i].Describe = mtvmcp.ExtractStdoutFromResponse(describeOutput)
 	}
 
-	return nil, dvsData, nil
+	return list.Items, nil
 }
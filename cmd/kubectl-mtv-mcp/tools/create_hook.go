@@ -17,6 +17,7 @@ type CreateHookInput struct {
 	Playbook       string `json:"playbook,omitempty"`
 	Deadline       int    `json:"deadline,omitempty"`
 	DryRun         bool   `json:"dry_run,omitempty"`
+	Cluster        string `json:"cluster,omitempty"`
 }
 
 // GetCreateHookTool returns the tool definition
@@ -46,6 +47,7 @@ func GetCreateHookTool() *mcp.Tool {
         service_account: Service account to use for the hook (optional)
         playbook: Ansible playbook content or @filename to load from file (optional)
         deadline: Hook deadline in seconds, 0 for no timeout (optional, default 0)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming hook creation
@@ -63,23 +65,40 @@ func GetCreateHookTool() *mcp.Tool {
         # Create hook with default image and service account
         create_hook("validate-target",
                    service_account="migration-validator", deadline=600)`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Hook",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandleCreateHook(ctx context.Context, req *mcp.CallToolRequest, input CreateHookInput) (*mcp.CallToolResult, any, error) {
+func HandleCreateHook(ctx context.Context, req *mcp.CallToolRequest, input CreateHookInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"hook_name": input.HookName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"hook_name": input.HookName,
+	}); err != nil {
+		return nil, nil, err
 	}
 
-	args := []string{"create", "hook", input.HookName}
+	args := []string{"create", "hook"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
@@ -99,15 +118,17 @@ func HandleCreateHook(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		args = append(args, "--deadline", fmt.Sprintf("%d", input.Deadline))
 	}
 
+	mtvmcp.AddPositionalArgs(&args, input.HookName)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
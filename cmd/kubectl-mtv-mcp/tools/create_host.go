@@ -2,6 +2,7 @@ package tools
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
@@ -21,6 +22,7 @@ type CreateHostInput struct {
 	Cacert              string `json:"cacert,omitempty"`
 	InventoryURL        string `json:"inventory_url,omitempty"`
 	DryRun              bool   `json:"dry_run,omitempty"`
+	Cluster             string `json:"cluster,omitempty"`
 }
 
 // GetCreateHostTool returns the tool definition
@@ -42,7 +44,8 @@ func GetCreateHostTool() *mcp.Tool {
 
     Authentication Options:
     - existing_secret: Use existing Kubernetes secret with credentials
-    - username/password: Create new credentials (will create secret automatically)
+    - username/password: Create new credentials, stored in a new secret named
+      <host_name>-credentials-<suffix> that is deleted if the host cannot be created
     - ESXi providers: Can automatically inherit provider credentials
 
     Args:
@@ -58,6 +61,7 @@ func GetCreateHostTool() *mcp.Tool {
         host_insecure_skip_tls: Skip TLS verification for host connection (optional, default False)
         cacert: CA certificate content or @filename to load from file (optional)
         inventory_url: Base URL for inventory service (optional, auto-discovered if not provided)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming host creation
@@ -74,24 +78,55 @@ func GetCreateHostTool() *mcp.Tool {
 
         # Create host for ESXi endpoint provider (inherits credentials)
         create_host("esxi-host-01", "my-esxi-provider", ip_address="192.168.1.10")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Host",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandleCreateHost(ctx context.Context, req *mcp.CallToolRequest, input CreateHostInput) (*mcp.CallToolResult, any, error) {
+func HandleCreateHost(ctx context.Context, req *mcp.CallToolRequest, input CreateHostInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"host_name": input.HostName,
 		"provider":  input.Provider,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	args := []string{"create", "host", input.HostName}
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"provider": input.Provider,
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := mtvmcp.ValidatePositionalArgs(map[string]string{
+		"host_name": input.HostName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// Pass the credentials in a Secret created by the server, never on the command line
+	secret, err := hostCredentialSecret(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret != nil && input.ExistingSecret != "" {
+		return nil, nil, fmt.Errorf("cannot use existing_secret with username or password")
+	}
+
+	args := []string{"create", "host"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
@@ -100,15 +135,12 @@ func HandleCreateHost(ctx context.Context, req *mcp.CallToolRequest, input Creat
 	if input.Provider != "" {
 		args = append(args, "--provider", input.Provider)
 	}
-	if input.Username != "" {
-		args = append(args, "--username", input.Username)
-	}
-	if input.Password != "" {
-		args = append(args, "--password", input.Password)
-	}
 	if input.ExistingSecret != "" {
 		args = append(args, "--existing-secret", input.ExistingSecret)
 	}
+	if secret != nil {
+		args = append(args, "--existing-secret", secret.Name)
+	}
 	if input.IPAddress != "" {
 		args = append(args, "--ip-address", input.IPAddress)
 	}
@@ -116,22 +148,41 @@ func HandleCreateHost(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		args = append(args, "--network-adapter", input.NetworkAdapter)
 	}
 	mtvmcp.AddBooleanFlag(&args, "host-insecure-skip-tls", input.HostInsecureSkipTLS)
-	if input.Cacert != "" {
+	if secret == nil && input.Cacert != "" {
+		// Without credentials the host inherits the provider credentials, the
+		// CA certificate is public and kubectl-mtv adds it to them
 		args = append(args, "--cacert", input.Cacert)
 	}
-	if input.InventoryURL != "" {
-		args = append(args, "--inventory-url", input.InventoryURL)
+	if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); inventoryURL != "" {
+		args = append(args, "--inventory-url", inventoryURL)
 	}
 
-	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
+	mtvmcp.AddPositionalArgs(&args, input.HostName)
+
+	// Return the full command result to provide complete diagnostic information
+	output, err := runWithCredentialSecret(ctx, secret, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
+	return nil, output, nil
+}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+// hostCredentialSecret returns the Secret holding the host credentials,
+// or nil if no username or password is given
+func hostCredentialSecret(input CreateHostInput) (*credentialSecret, error) {
+	if input.Username == "" && input.Password == "" {
+		return nil, nil
+	}
+	cacert, err := loadCacert(input.Cacert)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	return nil, data, nil
+
+	data := map[string]string{}
+	setSecretValue(data, "user", input.Username)
+	setSecretValue(data, "password", input.Password)
+	setSecretValue(data, "cacert", cacert)
+	setSecretBool(data, "insecureSkipVerify", input.HostInsecureSkipTLS)
+
+	return newCredentialSecret(input.HostName, input.Namespace, "hosts", nil, data), nil
 }
@@ -57,6 +57,7 @@ type CreatePlanInput struct {
 	ConvertorNodeSelector          string `json:"convertor_node_selector,omitempty"`
 	ConvertorAffinity              string `json:"convertor_affinity,omitempty"`
 	DryRun                         bool   `json:"dry_run,omitempty"`
+	Cluster                        string `json:"cluster,omitempty"`
 }
 
 // GetCreatePlanTool returns the tool definition
@@ -328,6 +329,7 @@ func GetCreatePlanTool() *mcp.Tool {
             - 'REQUIRE pods(app=storage) on node' - Co-locate convertor with storage pods
             - 'PREFER pods(tier=compute) on zone' - Prefer same zone as compute pods
             - 'AVOID pods(workload=heavy) on node' - Separate convertor from heavy workloads
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming plan creation
@@ -388,39 +390,56 @@ func GetCreatePlanTool() *mcp.Tool {
                    vms="where powerState = 'Off' limit 10",
                    migration_type="cold",
                    description="Cold migration of first 10 powered-off VMs")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Migration Plan",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input CreatePlanInput) (*mcp.CallToolResult, any, error) {
+func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input CreatePlanInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"plan_name":       input.PlanName,
 		"source_provider": input.SourceProvider,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Validate conversion-only migration constraints
 	if input.MigrationType == "conversion" {
 		if input.StorageMapping != "" {
-			return nil, "", fmt.Errorf("cannot use storage_mapping with migration_type 'conversion'")
+			return nil, nil, fmt.Errorf("cannot use storage_mapping with migration_type 'conversion'")
 		}
 		if input.StoragePairs != "" {
-			return nil, "", fmt.Errorf("cannot use storage_pairs with migration_type 'conversion'")
+			return nil, nil, fmt.Errorf("cannot use storage_pairs with migration_type 'conversion'")
 		}
 	}
 
 	// Validate network pairs constraints
 	if err := mtvmcp.ValidateNetworkPairs(input.NetworkPairs); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	args := []string{"create", "plan", input.PlanName}
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"plan_name": input.PlanName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{"create", "plan"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
@@ -450,7 +469,7 @@ func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		case "block":
 			args = append(args, "--default-volume-mode", "Block")
 		default:
-			return nil, "", fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
+			return nil, nil, fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
 		}
 	}
 	if input.DefaultAccessMode != "" {
@@ -463,7 +482,7 @@ func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		case "readonlymany", "rom":
 			args = append(args, "--default-access-mode", "ReadOnlyMany")
 		default:
-			return nil, "", fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
+			return nil, nil, fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
 		}
 	}
 	if input.DefaultOffloadPlugin != "" {
@@ -521,7 +540,7 @@ func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		case "cold", "warm", "live", "conversion":
 			args = append(args, "--migration-type", mt)
 		default:
-			return nil, "", fmt.Errorf("invalid migration_type: %s (valid: cold|warm|live|conversion)", input.MigrationType)
+			return nil, nil, fmt.Errorf("invalid migration_type: %s (valid: cold|warm|live|conversion)", input.MigrationType)
 		}
 	}
 	if input.DefaultTargetNetwork != "" {
@@ -549,11 +568,11 @@ func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		case "on", "off", "auto":
 			args = append(args, "--target-power-state", ps)
 		default:
-			return nil, "", fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
+			return nil, nil, fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
 		}
 	}
-	if input.InventoryURL != "" {
-		args = append(args, "--inventory-url", input.InventoryURL)
+	if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); inventoryURL != "" {
+		args = append(args, "--inventory-url", inventoryURL)
 	}
 
 	// Add new preflight and convertor flags
@@ -568,15 +587,17 @@ func HandleCreatePlan(ctx context.Context, req *mcp.CallToolRequest, input Creat
 		args = append(args, "--convertor-affinity", input.ConvertorAffinity)
 	}
 
+	mtvmcp.AddPositionalArgs(&args, input.PlanName)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
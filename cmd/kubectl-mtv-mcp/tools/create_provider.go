@@ -29,6 +29,7 @@ type CreateProviderInput struct {
 	ProviderProjectName    string `json:"provider_project_name,omitempty"`
 	ProviderRegionName     string `json:"provider_region_name,omitempty"`
 	DryRun                 bool   `json:"dry_run,omitempty"`
+	Cluster                string `json:"cluster,omitempty"`
 }
 
 // GetCreateProviderTool returns the tool definition
@@ -50,6 +51,9 @@ func GetCreateProviderTool() *mcp.Tool {
     - OVA: url
 
     Security Notes:
+    - Credentials are stored in a new secret named <provider_name>-credentials-<suffix>,
+      created before the provider and deleted if the provider cannot be created; they are
+      never passed on the kubectl-mtv command line
     - Use cacert parameter with certificate content or prefix with @ to load from file
     - Set insecure_skip_tls=True to skip TLS verification (not recommended for production)
     - For existing secrets, use the secret parameter instead of credentials
@@ -86,6 +90,7 @@ func GetCreateProviderTool() *mcp.Tool {
         provider_domain_name: OpenStack domain name (OpenStack only)
         provider_project_name: OpenStack project name (OpenStack only)
         provider_region_name: OpenStack region name (OpenStack only)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming provider creation
@@ -103,49 +108,66 @@ func GetCreateProviderTool() *mcp.Tool {
         # Create OpenShift provider with token
         create_provider("my-openshift", "openshift", url="https://api.ocp.example.com:6443",
                        token="sha256~abcdef...")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Create Provider",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandleCreateProvider(ctx context.Context, req *mcp.CallToolRequest, input CreateProviderInput) (*mcp.CallToolResult, any, error) {
+func HandleCreateProvider(ctx context.Context, req *mcp.CallToolRequest, input CreateProviderInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"provider_name": input.ProviderName,
 		"provider_type": input.ProviderType,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	args := []string{"create", "provider", "--type", input.ProviderType, input.ProviderName}
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"provider_name": input.ProviderName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{"create", "provider", "--type", input.ProviderType}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
 
+	// Pass the credentials in a Secret created by the server, never on the command line
+	secret, err := providerCredentialSecret(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	if secret != nil && input.Secret != "" {
+		return nil, nil, fmt.Errorf("cannot use secret with username, password, token or cacert")
+	}
+
 	// Add authentication parameters
 	if input.Secret != "" {
 		args = append(args, "--secret", input.Secret)
 	}
+	if secret != nil {
+		args = append(args, "--secret", secret.Name)
+	}
 	if input.URL != "" {
 		args = append(args, "--url", input.URL)
 	}
-	if input.Username != "" {
-		args = append(args, "--username", input.Username)
-	}
-	if input.Password != "" {
-		args = append(args, "--password", input.Password)
-	}
-	if input.Cacert != "" {
-		args = append(args, "--cacert", input.Cacert)
-	}
 	mtvmcp.AddBooleanFlag(&args, "provider-insecure-skip-tls", input.InsecureSkipTLS)
-	if input.Token != "" {
-		args = append(args, "--token", input.Token)
-	}
 
 	// vSphere-specific parameters
 	if input.VDDKInitImage != "" {
@@ -173,15 +195,46 @@ func HandleCreateProvider(ctx context.Context, req *mcp.CallToolRequest, input C
 		args = append(args, "--provider-region-name", input.ProviderRegionName)
 	}
 
-	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
+	mtvmcp.AddPositionalArgs(&args, input.ProviderName)
+
+	// Return the full command result to provide complete diagnostic information
+	output, err := runWithCredentialSecret(ctx, secret, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
+	return nil, output, nil
+}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+// providerCredentialSecret returns the Secret holding the provider credentials, with the
+// keys Forklift reads for the provider type, or nil if no credentials are given
+func providerCredentialSecret(input CreateProviderInput) (*credentialSecret, error) {
+	if input.Username == "" && input.Password == "" && input.Token == "" && input.Cacert == "" {
+		return nil, nil
+	}
+	cacert, err := loadCacert(input.Cacert)
 	if err != nil {
-		return nil, "", err
+		return nil, err
+	}
+
+	data := map[string]string{}
+	setSecretValue(data, "url", input.URL)
+	setSecretValue(data, providerUserKey(input.ProviderType), input.Username)
+	setSecretValue(data, "password", input.Password)
+	setSecretValue(data, "token", input.Token)
+	setSecretValue(data, "cacert", cacert)
+	setSecretBool(data, "insecureSkipVerify", input.InsecureSkipTLS)
+	setSecretValue(data, "domainName", input.ProviderDomainName)
+	setSecretValue(data, "projectName", input.ProviderProjectName)
+	setSecretValue(data, "regionName", input.ProviderRegionName)
+
+	labels := map[string]string{"createdForProviderType": input.ProviderType}
+	return newCredentialSecret(input.ProviderName, input.Namespace, "providers", labels, data), nil
+}
+
+// providerUserKey returns the Secret key of the username for a provider type
+func providerUserKey(providerType string) string {
+	if providerType == "openstack" {
+		return "username"
 	}
-	return nil, data, nil
+	return "user"
 }
@@ -0,0 +1,282 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// credentialFieldManager is the server-side apply field manager of the credential Secrets
+const credentialFieldManager = "kubectl-mtv-mcp"
+
+// credentialOwnerKinds maps the resource type of a credential Secret to the kind
+// of the Forklift resources that reference it
+var credentialOwnerKinds = map[string]string{"providers": "Provider", "hosts": "Host"}
+
+// secretNameSuffix returns the random suffix of a new credential Secret name
+var secretNameSuffix = func() string {
+	b := make([]byte, 3)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)[:5]
+}
+
+// credentialSecret is a Secret the server creates or updates to pass credentials
+// to kubectl-mtv by reference instead of on the command line
+type credentialSecret struct {
+	Name      string
+	Namespace string
+	// ResourceType is the Forklift resource type that references the Secret
+	ResourceType string
+	Labels       map[string]string
+	Data         map[string]string
+	// Update applies the data to an existing Secret, keeping its other keys
+	Update bool
+}
+
+// newCredentialSecret returns a Secret with a new name derived from owner,
+// or nil if data holds no credentials
+func newCredentialSecret(owner, namespace, resourceType string, labels, data map[string]string) *credentialSecret {
+	if len(data) == 0 {
+		return nil
+	}
+
+	// Derive a DNS-1123 subdomain from the owner, keeping room for the suffix
+	prefix := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(owner))
+	prefix = strings.Trim(prefix[:min(len(prefix), 253-len("-credentials-xxxxx"))], ".-")
+	if prefix == "" {
+		prefix = "mtv"
+	}
+	secretLabels := map[string]string{"createdForResourceType": resourceType}
+	for key, value := range labels {
+		secretLabels[key] = value
+	}
+	return &credentialSecret{
+		Name:         prefix + "-credentials-" + secretNameSuffix(),
+		Namespace:    namespace,
+		ResourceType: resourceType,
+		Labels:       secretLabels,
+		Data:         data,
+	}
+}
+
+// manifest returns the Secret as a JSON manifest, with the data base64 encoded
+func (s *credentialSecret) manifest() (string, error) {
+	type metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace,omitempty"`
+		Labels    map[string]string `json:"labels,omitempty"`
+	}
+	secret := struct {
+		APIVersion string            `json:"apiVersion"`
+		Kind       string            `json:"kind"`
+		Metadata   metadata          `json:"metadata"`
+		Type       string            `json:"type,omitempty"`
+		Data       map[string]string `json:"data"`
+	}{
+		APIVersion: "v1",
+		Kind:       "Secret",
+		Metadata:   metadata{Name: s.Name, Namespace: s.Namespace, Labels: s.Labels},
+		Data:       make(map[string]string, len(s.Data)),
+	}
+	// The type of an existing Secret is immutable, only set it on new Secrets
+	if !s.Update {
+		secret.Type = "Opaque"
+	}
+	for key, value := range s.Data {
+		secret.Data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+
+	data, err := json.Marshal(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal secret: %w", err)
+	}
+	return string(data), nil
+}
+
+// applyCredentialSecret creates or updates the Secret, sending its manifest over
+// stdin so the credentials never appear on a command line
+func applyCredentialSecret(ctx context.Context, secret *credentialSecret) (mtvmcp.CommandOutput, error) {
+	manifest, err := secret.manifest()
+	if err != nil {
+		return mtvmcp.CommandOutput{}, err
+	}
+
+	// Server-side apply does not copy the data into a last-applied-configuration annotation
+	args := []string{"apply", "--server-side", "--field-manager", credentialFieldManager}
+	if secret.Update {
+		// Apply removes the fields its field manager set before and no longer sets, so each
+		// set of updated keys gets its own field manager, and the other keys are kept.
+		// Take over the keys kubectl-mtv set when it created the Secret.
+		keys := slices.Sorted(maps.Keys(secret.Data))
+		args = []string{"apply", "--server-side", "--field-manager", credentialFieldManager + "-" + strings.Join(keys, "-"), "--force-conflicts"}
+	}
+	if secret.Namespace != "" {
+		args = append(args, "-n", secret.Namespace)
+	}
+	args = append(args, "-f", "-")
+
+	result, err := mtvmcp.RunKubectlCommandWithStdin(ctx, args, manifest)
+	if err != nil {
+		return mtvmcp.CommandOutput{}, err
+	}
+	return mtvmcp.ParseCommandOutput(result)
+}
+
+// deleteCredentialSecret deletes a Secret created for a command that failed.
+// It runs even if the tool call was cancelled; a failure is logged, not returned,
+// so the error of the failed command is reported.
+func deleteCredentialSecret(ctx context.Context, secret *credentialSecret) {
+	args := []string{"delete", "secret", secret.Name, "--ignore-not-found"}
+	if secret.Namespace != "" {
+		args = append(args, "-n", secret.Namespace)
+	}
+	if _, err := runKubectlStdout(context.WithoutCancel(ctx), args); err != nil {
+		log.Printf("Failed to delete credential secret %s: %v", secret.Name, err)
+	}
+}
+
+// setCredentialSecretOwners makes the Forklift resources that reference a new Secret its
+// owners, so the Secret is garbage collected when they are deleted. kubectl-mtv does
+// this for the Secrets it creates itself. A failure is logged, not returned, since
+// the resources were created.
+func setCredentialSecretOwners(ctx context.Context, secret *credentialSecret) {
+	ctx = context.WithoutCancel(ctx)
+	kind, ok := credentialOwnerKinds[secret.ResourceType]
+	if !ok {
+		return
+	}
+
+	args := []string{"get", secret.ResourceType + ".forklift.konveyor.io", "-o", "json"}
+	if secret.Namespace != "" {
+		args = append(args, "-n", secret.Namespace)
+	}
+	stdout, err := runKubectlStdout(ctx, args)
+	if err != nil {
+		log.Printf("Failed to find the owners of credential secret %s: %v", secret.Name, err)
+		return
+	}
+	var list struct {
+		Items []struct {
+			Metadata struct {
+				Name string `json:"name"`
+				UID  string `json:"uid"`
+			} `json:"metadata"`
+			Spec struct {
+				Secret struct {
+					Name string `json:"name"`
+				} `json:"secret"`
+			} `json:"spec"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &list); err != nil {
+		log.Printf("Failed to parse the owners of credential secret %s: %v", secret.Name, err)
+		return
+	}
+
+	type ownerReference struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Name       string `json:"name"`
+		UID        string `json:"uid"`
+	}
+	var owners []ownerReference
+	for _, item := range list.Items {
+		if item.Spec.Secret.Name == secret.Name && item.Metadata.UID != "" {
+			owners = append(owners, ownerReference{
+				APIVersion: "forklift.konveyor.io/v1beta1", Kind: kind, Name: item.Metadata.Name, UID: item.Metadata.UID,
+			})
+		}
+	}
+	if len(owners) == 0 {
+		log.Printf("No %s references credential secret %s, it is not garbage collected", kind, secret.Name)
+		return
+	}
+
+	patch, err := json.Marshal(map[string]any{"metadata": map[string]any{"ownerReferences": owners}})
+	if err != nil {
+		log.Printf("Failed to marshal the owners of credential secret %s: %v", secret.Name, err)
+		return
+	}
+	args = []string{"patch", "secret", secret.Name, "--type", "merge", "-p", string(patch)}
+	if secret.Namespace != "" {
+		args = append(args, "-n", secret.Namespace)
+	}
+	if _, err := runKubectlStdout(ctx, args); err != nil {
+		log.Printf("Failed to set the owners of credential secret %s: %v", secret.Name, err)
+	}
+}
+
+// runWithCredentialSecret applies the Secret, if any, then runs the kubectl-mtv command
+// that references it. A new Secret is owned by the resources the command creates, or
+// deleted if the command fails. In dry run mode the output lists both commands.
+func runWithCredentialSecret(ctx context.Context, secret *credentialSecret, args []string) (*mtvmcp.CommandOutput, error) {
+	var applied mtvmcp.CommandOutput
+	if secret != nil {
+		var err error
+		if applied, err = applyCredentialSecret(ctx, secret); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
+	var output mtvmcp.CommandOutput
+	if err == nil {
+		output, err = mtvmcp.ParseCommandOutput(result)
+	}
+	if err != nil {
+		if secret != nil && !secret.Update && !mtvmcp.GetDryRun(ctx) {
+			deleteCredentialSecret(ctx, secret)
+		}
+		return nil, err
+	}
+
+	if secret != nil && mtvmcp.GetDryRun(ctx) {
+		output.Stdout = applied.Stdout + "\n" + output.Stdout
+	} else if secret != nil && !secret.Update {
+		setCredentialSecretOwners(ctx, secret)
+	}
+	return &output, nil
+}
+
+// loadCacert returns the CA certificate content, read from a file if it has the @filename form
+func loadCacert(cacert string) (string, error) {
+	path, ok := strings.CutPrefix(cacert, "@")
+	if !ok {
+		return cacert, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	return string(data), nil
+}
+
+// setSecretValue sets a Secret key if value is not empty
+func setSecretValue(data map[string]string, key, value string) {
+	if value != "" {
+		data[key] = value
+	}
+}
+
+// setSecretBool sets a Secret key to "true" or "false" if value is set
+func setSecretBool(data map[string]string, key string, value *bool) {
+	if value != nil {
+		data[key] = strconv.FormatBool(*value)
+	}
+}
@@ -14,6 +14,7 @@ type DeleteHookInput struct {
 	Namespace string `json:"namespace,omitempty"`
 	AllHooks  bool   `json:"all_hooks,omitempty"`
 	DryRun    bool   `json:"dry_run,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
 }
 
 // GetDeleteHookTool returns the tool definition
@@ -29,6 +30,7 @@ func GetDeleteHookTool() *mcp.Tool {
         dry_run: If true, shows the kubectl-mtv command instead of executing it (educational mode) (optional, default: false)
         namespace: Kubernetes namespace containing the hook (optional)
         all_hooks: Delete all hooks in the namespace (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming hook deletion
@@ -39,39 +41,60 @@ func GetDeleteHookTool() *mcp.Tool {
 
         # Delete all hooks in namespace
         DeleteHook(all_hooks=true, namespace="demo")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Hook",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+			IdempotentHint:  true,
+		},
 	}
 }
 
-func HandleDeleteHook(ctx context.Context, req *mcp.CallToolRequest, input DeleteHookInput) (*mcp.CallToolResult, any, error) {
+func HandleDeleteHook(ctx context.Context, req *mcp.CallToolRequest, input DeleteHookInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"hook_name": input.HookName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"delete", "hook"}
 
 	if input.AllHooks {
 		args = append(args, "--all")
 	} else {
 		if input.HookName == "" {
-			return nil, "", fmt.Errorf("hook_name is required when all_hooks=false")
+			return nil, nil, fmt.Errorf("hook_name is required when all_hooks=false")
 		}
-		args = append(args, input.HookName)
 	}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
 
+	if !input.AllHooks {
+		mtvmcp.AddPositionalArgs(&args, input.HookName)
+	}
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
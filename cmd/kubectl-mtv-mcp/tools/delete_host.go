@@ -14,6 +14,7 @@ type DeleteHostInput struct {
 	Namespace string `json:"namespace,omitempty"`
 	AllHosts  bool   `json:"all_hosts,omitempty"`
 	DryRun    bool   `json:"dry_run,omitempty"`
+	Cluster   string `json:"cluster,omitempty"`
 }
 
 // GetDeleteHostTool returns the tool definition
@@ -29,6 +30,7 @@ func GetDeleteHostTool() *mcp.Tool {
         dry_run: If true, shows the kubectl-mtv command instead of executing it (educational mode) (optional, default: false)
         namespace: Kubernetes namespace containing the host (optional)
         all_hosts: Delete all hosts in the namespace (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming host deletion
@@ -39,39 +41,60 @@ func GetDeleteHostTool() *mcp.Tool {
 
         # Delete all hosts in namespace
         DeleteHost(all_hosts=true, namespace="demo")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Host",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+			IdempotentHint:  true,
+		},
 	}
 }
 
-func HandleDeleteHost(ctx context.Context, req *mcp.CallToolRequest, input DeleteHostInput) (*mcp.CallToolResult, any, error) {
+func HandleDeleteHost(ctx context.Context, req *mcp.CallToolRequest, input DeleteHostInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"host_name": input.HostName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"delete", "host"}
 
 	if input.AllHosts {
 		args = append(args, "--all")
 	} else {
 		if input.HostName == "" {
-			return nil, "", fmt.Errorf("host_name is required when all_hosts=false")
+			return nil, nil, fmt.Errorf("host_name is required when all_hosts=false")
 		}
-		args = append(args, input.HostName)
 	}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
 
+	if !input.AllHosts {
+		mtvmcp.AddPositionalArgs(&args, input.HostName)
+	}
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
@@ -16,6 +16,7 @@ type DeletePlanInput struct {
 	SkipArchive bool   `json:"skip_archive,omitempty"`
 	CleanAll    bool   `json:"clean_all,omitempty"`
 	DryRun      bool   `json:"dry_run,omitempty"`
+	Cluster     string `json:"cluster,omitempty"`
 }
 
 // GetDeletePlanTool returns the tool definition
@@ -39,6 +40,7 @@ func GetDeletePlanTool() *mcp.Tool {
         all_plans: Delete all plans in the namespace (optional)
         skip_archive: Skip archiving and delete immediately (optional)
         clean_all: Archive, delete VMs on failed migration, then delete (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming plan deletion
@@ -55,24 +57,41 @@ func GetDeletePlanTool() *mcp.Tool {
 
         # Delete all plans in namespace
         DeletePlan(all_plans=true, namespace="demo")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Plan",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+			IdempotentHint:  true,
+		},
 	}
 }
 
-func HandleDeletePlan(ctx context.Context, req *mcp.CallToolRequest, input DeletePlanInput) (*mcp.CallToolResult, any, error) {
+func HandleDeletePlan(ctx context.Context, req *mcp.CallToolRequest, input DeletePlanInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"plan_name": input.PlanName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"delete", "plan"}
 
 	if input.AllPlans {
 		args = append(args, "--all")
 	} else {
 		if input.PlanName == "" {
-			return nil, "", fmt.Errorf("plan_name is required when all_plans=false")
+			return nil, nil, fmt.Errorf("plan_name is required when all_plans=false")
 		}
-		args = append(args, input.PlanName)
 	}
 
 	if input.Namespace != "" {
@@ -87,15 +106,19 @@ func HandleDeletePlan(ctx context.Context, req *mcp.CallToolRequest, input Delet
 		args = append(args, "--clean-all")
 	}
 
+	if !input.AllPlans {
+		mtvmcp.AddPositionalArgs(&args, input.PlanName)
+	}
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
@@ -14,6 +14,7 @@ type DeleteProviderInput struct {
 	Namespace    string `json:"namespace,omitempty"`
 	AllProviders bool   `json:"all_providers,omitempty"`
 	DryRun       bool   `json:"dry_run,omitempty"`
+	Cluster      string `json:"cluster,omitempty"`
 }
 
 // GetDeleteProviderTool returns the tool definition
@@ -29,6 +30,7 @@ func GetDeleteProviderTool() *mcp.Tool {
         dry_run: If true, shows the kubectl-mtv command instead of executing it (educational mode) (optional, default: false)
         namespace: Kubernetes namespace containing the provider (optional)
         all_providers: Delete all providers in the namespace (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming provider deletion
@@ -39,39 +41,60 @@ func GetDeleteProviderTool() *mcp.Tool {
 
         # Delete all providers in namespace
         DeleteProvider(all_providers=true, namespace="demo")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Delete Provider",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+			IdempotentHint:  true,
+		},
 	}
 }
 
-func HandleDeleteProvider(ctx context.Context, req *mcp.CallToolRequest, input DeleteProviderInput) (*mcp.CallToolResult, any, error) {
+func HandleDeleteProvider(ctx context.Context, req *mcp.CallToolRequest, input DeleteProviderInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"provider_name": input.ProviderName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"delete", "provider"}
 
 	if input.AllProviders {
 		args = append(args, "--all")
 	} else {
 		if input.ProviderName == "" {
-			return nil, "", fmt.Errorf("provider_name is required when all_providers=false")
+			return nil, nil, fmt.Errorf("provider_name is required when all_providers=false")
 		}
-		args = append(args, input.ProviderName)
 	}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
 
+	if !input.AllProviders {
+		mtvmcp.AddPositionalArgs(&args, input.ProviderName)
+	}
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
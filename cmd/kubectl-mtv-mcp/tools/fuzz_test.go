@@ -0,0 +1,223 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// fuzzSentinel is a valid name used to locate the argv slots of a fuzzed value
+const fuzzSentinel = "fuzz-sentinel"
+
+// argvBuilder calls a tool handler with value in every user-supplied positional parameter
+type argvBuilder struct {
+	name string
+	call func(ctx context.Context, value, namespace string) error
+}
+
+// argvBuilders covers the argv builder of every tool that passes user values as positional arguments
+var argvBuilders = []argvBuilder{
+	{"ListResources", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "plan", Namespace: namespace})
+		return err
+	}},
+	{"ListInventory", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "vm", ProviderName: value, Namespace: namespace})
+		return err
+	}},
+	{"ListInventory resource type", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: value, ProviderName: "vsphere", Namespace: namespace})
+		return err
+	}},
+	{"GetPlanVms", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleGetPlanVms(ctx, nil, GetPlanVmsInput{PlanName: value, Namespace: namespace})
+		return err
+	}},
+	{"CreatePlan", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{PlanName: value, SourceProvider: "vsphere", Namespace: namespace})
+		return err
+	}},
+	{"CreateProvider", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleCreateProvider(ctx, nil, CreateProviderInput{ProviderName: value, ProviderType: "vsphere", Namespace: namespace})
+		return err
+	}},
+	{"CreateHost", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleCreateHost(ctx, nil, CreateHostInput{HostName: value, Provider: "vsphere", Namespace: namespace})
+		return err
+	}},
+	{"CreateHook", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleCreateHook(ctx, nil, CreateHookInput{HookName: value, Namespace: namespace})
+		return err
+	}},
+	{"ManageMapping", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleManageMapping(ctx, nil, ManageMappingInput{Action: "delete", MappingType: "network", MappingName: value, Namespace: namespace})
+		return err
+	}},
+	{"ManagePlanLifecycle", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleManagePlanLifecycle(ctx, nil, ManagePlanLifecycleInput{Action: "cancel", PlanName: value, VMs: "vm-1", Namespace: namespace})
+		return err
+	}},
+	{"PatchPlan", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandlePatchPlan(ctx, nil, PatchPlanInput{PlanName: value, Namespace: namespace})
+		return err
+	}},
+	{"PatchPlanVm", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandlePatchPlanVm(ctx, nil, PatchPlanVmInput{PlanName: value, VmName: value, Namespace: namespace})
+		return err
+	}},
+	{"PatchProvider", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandlePatchProvider(ctx, nil, PatchProviderInput{ProviderName: value, Namespace: namespace})
+		return err
+	}},
+	{"DeletePlan", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: value, Namespace: namespace})
+		return err
+	}},
+	{"DeleteProvider", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleDeleteProvider(ctx, nil, DeleteProviderInput{ProviderName: value, Namespace: namespace})
+		return err
+	}},
+	{"DeleteHost", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleDeleteHost(ctx, nil, DeleteHostInput{HostName: value, Namespace: namespace})
+		return err
+	}},
+	{"DeleteHook", func(ctx context.Context, value, namespace string) error {
+		_, _, err := HandleDeleteHook(ctx, nil, DeleteHookInput{HookName: value, Namespace: namespace})
+		return err
+	}},
+}
+
+// buildArgv runs a builder against a fake executor and returns the executed argv, nil if it failed
+func buildArgv(builder argvBuilder, value, namespace string) []string {
+	fake := mtvmcp.NewFakeExecutor()
+	ctx := mtvmcp.WithExecutor(context.Background(), fake)
+	if err := builder.call(ctx, value, namespace); err != nil {
+		return nil
+	}
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		return nil
+	}
+	return calls[0].Args
+}
+
+// replaceArg returns argv with every argument equal to old replaced by new
+func replaceArg(argv []string, old, new string) []string {
+	replaced := slices.Clone(argv)
+	for i, arg := range replaced {
+		if arg == old {
+			replaced[i] = new
+		}
+	}
+	return replaced
+}
+
+// checkArgv verifies that the fuzzed values only fill the argv slots of the sentinel values,
+// and that none of those slots can be parsed as a flag
+func checkArgv(t *testing.T, builder argvBuilder, value, namespace string) {
+	t.Helper()
+	argv := buildArgv(builder, value, namespace)
+	if argv == nil {
+		return
+	}
+
+	if strings.HasPrefix(namespace, "-") || (strings.HasPrefix(value, "-") && slices.Contains(argv, value)) {
+		t.Fatalf("%s accepted a value starting with '-': %q", builder.name, argv)
+	}
+
+	// Build the same command with sentinel values to find the slots of the fuzzed values
+	sentinelNamespace := namespace
+	if namespace != "" {
+		sentinelNamespace = fuzzSentinel + "-ns"
+	}
+	if value == sentinelNamespace {
+		return
+	}
+	sentinelArgv := buildArgv(builder, fuzzSentinel, sentinelNamespace)
+	if sentinelArgv == nil {
+		t.Fatalf("%s rejected sentinel values", builder.name)
+	}
+	expected := replaceArg(replaceArg(sentinelArgv, fuzzSentinel, value), sentinelNamespace, namespace)
+	if !slices.Equal(argv, expected) {
+		t.Fatalf("%s built %q for value %q, expected %q", builder.name, argv, value, expected)
+	}
+
+	separator := slices.Index(sentinelArgv, "--")
+	for i, arg := range sentinelArgv {
+		if arg != fuzzSentinel && arg != sentinelNamespace {
+			continue
+		}
+		afterSeparator := separator >= 0 && i > separator
+		flagValue := i > 0 && strings.HasPrefix(sentinelArgv[i-1], "-") && sentinelArgv[i-1] != "--"
+		if !afterSeparator && !flagValue && strings.HasPrefix(argv[i], "-") {
+			t.Fatalf("%s placed %q where it is parsed as a flag: %q", builder.name, argv[i], argv)
+		}
+	}
+}
+
+func FuzzToolArgv(f *testing.F) {
+	for _, seed := range []struct{ value, namespace string }{
+		{"my-plan", "demo"},
+		{"--all", "demo"},
+		{"-A", ""},
+		{"--", "demo"},
+		{"-", ""},
+		{"web", "-A"},
+		{"web", "--all-namespaces"},
+		{"Web VM", "demo"},
+		{"plan --all", "demo"},
+		{"plan=x,vmID!=y", ""},
+		{"demo", "demo"},
+		{"plan", "plan"},
+		{"", ""},
+	} {
+		f.Add(seed.value, seed.namespace)
+	}
+
+	f.Fuzz(func(t *testing.T, value, namespace string) {
+		for _, builder := range argvBuilders {
+			checkArgv(t, builder, value, namespace)
+		}
+	})
+}
+
+func FuzzLabelSelectors(f *testing.F) {
+	for _, seed := range []string{"plan-uid", "a,b", "x,vmID!=y", "uid in (a,b)", "-", "", "vm_1.a"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, value string) {
+		builders := map[string]func(ctx context.Context) error{
+			"GetLogs": func(ctx context.Context) error {
+				_, _, err := HandleGetLogs(ctx, nil, GetLogsInput{PodType: "importer", Namespace: "demo", PlanID: value, MigrationID: value, VMID: value})
+				return err
+			},
+			"GetMigrationStorage": func(ctx context.Context) error {
+				_, _, err := HandleGetMigrationStorage(ctx, nil, GetMigrationStorageInput{ResourceType: "pvc", Namespace: "demo", PlanID: value, MigrationID: value, VMID: value})
+				return err
+			},
+		}
+		for name, call := range builders {
+			fake := mtvmcp.NewFakeExecutor()
+			_ = call(mtvmcp.WithExecutor(context.Background(), fake))
+			for _, command := range fake.Calls() {
+				selector := slices.Index(command.Args, "-l")
+				if selector < 0 || selector+1 >= len(command.Args) {
+					continue
+				}
+				requirements := strings.Split(command.Args[selector+1], ",")
+				if len(requirements) != 3 {
+					t.Fatalf("%s built selector %q with %d requirements for value %q", name, command.Args[selector+1], len(requirements), value)
+				}
+				for _, requirement := range requirements {
+					if key, val, ok := strings.Cut(requirement, "="); !ok || strings.ContainsAny(key+val, "=!(), ") || val != value {
+						t.Fatalf("%s built selector requirement %q for value %q", name, requirement, value)
+					}
+				}
+			}
+		}
+	})
+}
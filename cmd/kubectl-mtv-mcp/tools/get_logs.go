@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
@@ -10,15 +11,18 @@ import (
 
 // GetLogsInput represents the input for GetLogs
 type GetLogsInput struct {
-	PodType     string `json:"pod_type,omitempty" jsonschema:"Type of pod to get logs from ('controller' or 'importer'). Defaults to 'controller'"`
-	Container   string `json:"container,omitempty" jsonschema:"Container name for controller pods (main, inventory). Defaults to 'main'"`
-	Lines       int    `json:"lines,omitempty" jsonschema:"Number of recent log lines to retrieve. Defaults to 100"`
-	Follow      bool   `json:"follow,omitempty" jsonschema:"Follow log output (stream logs). Not recommended for MCP usage"`
-	Namespace   string `json:"namespace,omitempty" jsonschema:"Override namespace (optional, auto-detected for controller)"`
-	PlanID      string `json:"plan_id,omitempty" jsonschema:"Plan UUID for finding importer pods (required for importer type)"`
-	MigrationID string `json:"migration_id,omitempty" jsonschema:"Migration UUID for finding importer pods (required for importer type)"`
-	VMID        string `json:"vm_id,omitempty" jsonschema:"VM ID for finding importer pods (required for importer type)"`
-	DryRun      bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	PodType        string `json:"pod_type,omitempty" jsonschema:"Type of pod to get logs from ('controller' or 'importer'). Defaults to 'controller'"`
+	Container      string `json:"container,omitempty" jsonschema:"Container name for controller pods (main, inventory). Defaults to 'main'"`
+	Lines          int    `json:"lines,omitempty" jsonschema:"Number of recent log lines to retrieve. Defaults to 100"`
+	Follow         bool   `json:"follow,omitempty" jsonschema:"Follow log output (stream logs). Not recommended for MCP usage"`
+	Namespace      string `json:"namespace,omitempty" jsonschema:"Override namespace (optional, auto-detected for controller)"`
+	PlanID         string `json:"plan_id,omitempty" jsonschema:"Plan UUID for finding importer pods (required for importer type)"`
+	MigrationID    string `json:"migration_id,omitempty" jsonschema:"Migration UUID for finding importer pods (required for importer type)"`
+	VMID           string `json:"vm_id,omitempty" jsonschema:"VM ID for finding importer pods (required for importer type)"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum time in seconds for the whole call (optional, defaults to and cannot exceed the server command timeout)"`
+	DryRun         bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster        string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
+	Cursor         string `json:"cursor,omitempty" jsonschema:"The next_cursor of the previous response, to get the next page of large logs (optional)"`
 }
 
 // GetGetLogsTool returns the tool definition
@@ -53,6 +57,13 @@ func GetGetLogsTool() *mcp.Tool {
         plan_id: Plan UUID for finding importer pods (required for importer type)
         migration_id: Migration UUID for finding importer pods (required for importer type)
         vm_id: VM ID for finding importer pods (required for importer type)
+        timeout_seconds: Maximum time in seconds for the whole call (optional, defaults to and cannot exceed the server command timeout)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
+        cursor: The next_cursor of the previous response, to get the next page of large logs (optional)
+
+    Pagination: Logs larger than the server response size limit are split into pages of whole
+    lines. The response reports the total line count and truncated; while next_cursor is set,
+    call again with cursor=next_cursor and the same other arguments to get the next page.
 
     Returns:
         JSON structure containing pod information and logs:
@@ -70,15 +81,45 @@ func GetGetLogsTool() *mcp.Tool {
 
         # Get importer pod logs for specific VM migration
         get_logs("importer", "", 100, False, "demo", "plan-uuid", "migration-uuid", "vm-47")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "Get MTV Pod Logs",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
 	}
 }
 
-func HandleGetLogs(ctx context.Context, req *mcp.CallToolRequest, input GetLogsInput) (*mcp.CallToolResult, any, error) {
+func HandleGetLogs(ctx context.Context, req *mcp.CallToolRequest, input GetLogsInput) (*mcp.CallToolResult, *GetLogsOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply the per-call timeout if requested
+	if input.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = mtvmcp.WithCommandTimeout(ctx, mtvmcp.CallTimeout(input.TimeoutSeconds))
+		defer cancel()
+	}
+
+	// Validate the namespace and the IDs used in label selectors
+	if err := mtvmcp.ValidateNames(input.Namespace, nil); err != nil {
+		return nil, nil, err
+	}
+	if err := mtvmcp.ValidateLabelValues(map[string]string{
+		"plan_id":      input.PlanID,
+		"migration_id": input.MigrationID,
+		"vm_id":        input.VMID,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	podType := input.PodType
 	if podType == "" {
 		podType = "controller"
@@ -94,14 +135,26 @@ func HandleGetLogs(ctx context.Context, req *mcp.CallToolRequest, input GetLogsI
 		lines = 100
 	}
 
+	var out *GetLogsOutput
 	if podType == "controller" {
-		return getControllerLogs(ctx, container, lines, input.Follow, input.Namespace)
+		_, out, err = getControllerLogs(ctx, container, lines, input.Follow, input.Namespace)
 	} else if podType == "importer" {
 		if input.PlanID == "" || input.MigrationID == "" || input.VMID == "" {
-			return nil, "", fmt.Errorf("for importer logs, plan_id, migration_id, and vm_id are required")
+			return nil, nil, fmt.Errorf("for importer logs, plan_id, migration_id, and vm_id are required")
 		}
-		return getImporterLogs(ctx, lines, input.Follow, input.Namespace, input.PlanID, input.MigrationID, input.VMID)
+		_, out, err = getImporterLogs(ctx, lines, input.Follow, input.Namespace, input.PlanID, input.MigrationID, input.VMID)
+	} else {
+		return nil, nil, fmt.Errorf("unknown pod_type '%s'. Supported types: 'controller', 'importer'", podType)
+	}
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, "", fmt.Errorf("unknown pod_type '%s'. Supported types: 'controller', 'importer'", podType)
+	// Split large logs into pages of whole lines
+	out.Logs, out.PageInfo, err = mtvmcp.PaginateText(out.Logs, input.Cursor, mtvmcp.SplitLines,
+		podType, container, strconv.Itoa(lines), input.Namespace, input.PlanID, input.MigrationID, input.VMID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nil, out, nil
 }
@@ -3,6 +3,7 @@ package tools
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
@@ -10,13 +11,16 @@ import (
 
 // GetMigrationStorageInput represents the input for GetMigrationStorage
 type GetMigrationStorageInput struct {
-	ResourceType  string `json:"resource_type,omitempty" jsonschema:"Type of storage resource - 'all', 'pvc', or 'datavolume' (default 'all')"`
-	MigrationID   string `json:"migration_id,omitempty" jsonschema:"Migration UUID to filter by (optional) - get from plan VM status"`
-	PlanID        string `json:"plan_id,omitempty" jsonschema:"Plan UUID to filter by (optional) - get from plan metadata.uid"`
-	VMID          string `json:"vm_id,omitempty" jsonschema:"VM ID to filter by (optional) - e.g., vm-47, vm-73"`
-	Namespace     string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace to search in (optional)"`
-	AllNamespaces bool   `json:"all_namespaces,omitempty" jsonschema:"Search across all namespaces"`
-	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	ResourceType   string `json:"resource_type,omitempty" jsonschema:"Type of storage resource - 'all', 'pvc', or 'datavolume' (default 'all')"`
+	MigrationID    string `json:"migration_id,omitempty" jsonschema:"Migration UUID to filter by (optional) - get from plan VM status"`
+	PlanID         string `json:"plan_id,omitempty" jsonschema:"Plan UUID to filter by (optional) - get from plan metadata.uid"`
+	VMID           string `json:"vm_id,omitempty" jsonschema:"VM ID to filter by (optional) - e.g., vm-47, vm-73"`
+	Namespace      string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace to search in (optional)"`
+	AllNamespaces  bool   `json:"all_namespaces,omitempty" jsonschema:"Search across all namespaces"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum time in seconds for the whole call (optional, defaults to and cannot exceed the server command timeout)"`
+	DryRun         bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster        string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
+	Cursor         string `json:"cursor,omitempty" jsonschema:"The next_cursor of the previous response, to get the next page of a large result (optional)"`
 }
 
 // GetGetMigrationStorageTool returns the tool definition
@@ -56,6 +60,13 @@ func GetGetMigrationStorageTool() *mcp.Tool {
         vm_id: VM ID to filter by (optional) - e.g., vm-47, vm-73
         namespace: Kubernetes namespace to search in (optional)
         all_namespaces: Search across all namespaces
+        timeout_seconds: Maximum time in seconds for the whole call (optional, defaults to and cannot exceed the server command timeout)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
+        cursor: The next_cursor of the previous response, to get the next page of a large result (optional)
+
+    Pagination: Results larger than the server response size limit are split into pages,
+    PVCs first and then DataVolumes. The response reports total and truncated; while
+    next_cursor is set, call again with cursor=next_cursor and the same other arguments.
 
     Returns:
         JSON formatted storage information
@@ -75,15 +86,45 @@ func GetGetMigrationStorageTool() *mcp.Tool {
 
         # Get only DataVolumes for specific plan
         GetMigrationStorage(resource_type="datavolume", plan_id="3943f9a2-d4a4-4326-b25c-57d06ff53c21")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "Get Migration Storage",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
 	}
 }
 
-func HandleGetMigrationStorage(ctx context.Context, req *mcp.CallToolRequest, input GetMigrationStorageInput) (*mcp.CallToolResult, any, error) {
+func HandleGetMigrationStorage(ctx context.Context, req *mcp.CallToolRequest, input GetMigrationStorageInput) (*mcp.CallToolResult, *GetMigrationStorageOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Apply the per-call timeout if requested
+	if input.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = mtvmcp.WithCommandTimeout(ctx, mtvmcp.CallTimeout(input.TimeoutSeconds))
+		defer cancel()
+	}
+
+	// Validate the namespace and the IDs used in label selectors
+	if err := mtvmcp.ValidateNames(input.Namespace, nil); err != nil {
+		return nil, nil, err
+	}
+	if err := mtvmcp.ValidateLabelValues(map[string]string{
+		"migration_id": input.MigrationID,
+		"plan_id":      input.PlanID,
+		"vm_id":        input.VMID,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	resourceType := input.ResourceType
 	if resourceType == "" {
 		resourceType = "all"
@@ -99,35 +140,64 @@ func HandleGetMigrationStorage(ctx context.Context, req *mcp.CallToolRequest, in
 		}
 	}
 	if !found {
-		return nil, "", fmt.Errorf("invalid resource_type '%s'. Valid types: %v", resourceType, validTypes)
+		return nil, nil, fmt.Errorf("invalid resource_type '%s'. Valid types: %v", resourceType, validTypes)
 	}
 
-	if resourceType == "pvc" {
-		return getMigrationPVCs(ctx, input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
-	} else if resourceType == "datavolume" {
-		return getMigrationDataVolumes(ctx, input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
-	} else {
-		// Get both
-		_, pvcData, pvcErr := getMigrationPVCs(ctx, input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
-		_, dvData, dvErr := getMigrationDataVolumes(ctx, input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
-
-		if pvcErr != nil && dvErr != nil {
-			return nil, "", fmt.Errorf("failed to get PVCs: %v; failed to get DataVolumes: %v", pvcErr, dvErr)
+	out := &GetMigrationStorageOutput{}
+	if resourceType == "pvc" || resourceType == "all" {
+		pvcs, err := getMigrationStorage(ctx, "pvc", input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
+		if err != nil && resourceType == "pvc" {
+			return nil, nil, err
 		}
-
-		combined := map[string]interface{}{
-			"pvcs":        map[string]interface{}{},
-			"datavolumes": map[string]interface{}{},
+		out.PVCs = pvcs
+		if err != nil {
+			out.Errors = append(out.Errors, err.Error())
 		}
-
-		if pvcErr == nil {
-			combined["pvcs"] = pvcData
+	}
+	if resourceType == "datavolume" || resourceType == "all" {
+		dvs, err := getMigrationStorage(ctx, "datavolume", input.MigrationID, input.PlanID, input.VMID, input.Namespace, input.AllNamespaces)
+		if err != nil && resourceType == "datavolume" {
+			return nil, nil, err
 		}
-
-		if dvErr == nil {
-			combined["datavolumes"] = dvData
+		out.DataVolumes = dvs
+		if err != nil {
+			out.Errors = append(out.Errors, err.Error())
 		}
+	}
+
+	// Fail only if neither PVCs nor DataVolumes could be retrieved
+	if len(out.Errors) == 2 {
+		return nil, nil, fmt.Errorf("%s; %s", out.Errors[0], out.Errors[1])
+	}
 
-		return nil, combined, nil
+	// Split large results into pages, PVCs first and then DataVolumes
+	items := make([]storageItem, 0, len(out.PVCs)+len(out.DataVolumes))
+	for _, pvc := range out.PVCs {
+		items = append(items, storageItem{resource: pvc})
+	}
+	for _, dv := range out.DataVolumes {
+		items = append(items, storageItem{dataVolume: true, resource: dv})
+	}
+	items, out.PageInfo, err = mtvmcp.Paginate(items, input.Cursor,
+		func(item storageItem) int { return mtvmcp.JSONSize(item.resource) },
+		resourceType, input.MigrationID, input.PlanID, input.VMID, input.Namespace, strconv.FormatBool(input.AllNamespaces))
+	if err != nil {
+		return nil, nil, err
 	}
+	out.PVCs, out.DataVolumes = nil, nil
+	for _, item := range items {
+		if item.dataVolume {
+			out.DataVolumes = append(out.DataVolumes, item.resource)
+		} else {
+			out.PVCs = append(out.PVCs, item.resource)
+		}
+	}
+
+	return nil, out, nil
+}
+
+// storageItem is a PVC or DataVolume in the paginated sequence of storage resources
+type storageItem struct {
+	dataVolume bool
+	resource   StorageResource
 }
@@ -12,6 +12,7 @@ type GetPlanVmsInput struct {
 	PlanName  string `json:"plan_name" jsonschema:"Name of the migration plan to query"`
 	Namespace string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace containing the plan (optional)"`
 	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
 }
 
 // GetGetPlanVmsTool returns the tool definition
@@ -27,6 +28,7 @@ func GetGetPlanVmsTool() *mcp.Tool {
     Args:
         plan_name: Name of the migration plan to query
         namespace: Kubernetes namespace containing the plan (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         JSON formatted VM status information
@@ -36,39 +38,60 @@ func GetGetPlanVmsTool() *mcp.Tool {
         1. Monitor progress: get_plan_vms("my-plan")
         2. Cancel problematic VMs: cancel_plan("my-plan", "failed-vm1,stuck-vm2")
         3. Get detailed logs: get_logs("importer", plan_id="...", migration_id="...", vm_id="...")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "Get Plan VMs",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
 	}
 }
 
-func HandleGetPlanVms(ctx context.Context, req *mcp.CallToolRequest, input GetPlanVmsInput) (*mcp.CallToolResult, any, error) {
+func HandleGetPlanVms(ctx context.Context, req *mcp.CallToolRequest, input GetPlanVmsInput) (*mcp.CallToolResult, *GetPlanVmsOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"plan_name": input.PlanName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"plan_name": input.PlanName,
+	}); err != nil {
+		return nil, nil, err
 	}
 
-	args := []string{"get", "plan", input.PlanName, "--vms"}
+	args := []string{"get", "plan", "--vms"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
 
 	args = append(args, "-o", "json")
+	mtvmcp.AddPositionalArgs(&args, input.PlanName)
 
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Decode the kubectl-mtv JSON output into typed VM records
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	out := &GetPlanVmsOutput{CommandOutput: output}
+	out.VMs, _ = mtvmcp.DecodeStdoutList[PlanVM](&out.CommandOutput, "vms", "items")
+	return nil, out, nil
 }
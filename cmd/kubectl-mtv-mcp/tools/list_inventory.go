@@ -9,14 +9,18 @@ import (
 
 // ListInventoryInput represents the input for ListInventory
 type ListInventoryInput struct {
-	ResourceType  string `json:"resource_type" jsonschema:"Type of inventory resource to list"`
-	ProviderName  string `json:"provider_name,omitempty" jsonschema:"Name of the provider to query (required for most resource types, optional for 'provider' type)"`
-	Namespace     string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace containing the provider (optional)"`
-	AllNamespaces bool   `json:"all_namespaces,omitempty" jsonschema:"Search across all namespaces (optional, only applicable for 'provider' resource type)"`
-	Query         string `json:"query,omitempty" jsonschema:"Optional filter query using SQL-like syntax with WHERE/SELECT/ORDER BY/LIMIT"`
-	OutputFormat  string `json:"output_format,omitempty" jsonschema:"Output format - 'json' for full data or 'planvms' for plan-compatible VM structures (default 'json')"`
-	InventoryURL  string `json:"inventory_url,omitempty" jsonschema:"Base URL for inventory service (optional, auto-discovered if not provided)"`
-	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	ResourceType   string `json:"resource_type" jsonschema:"Type of inventory resource to list"`
+	ProviderName   string `json:"provider_name,omitempty" jsonschema:"Name of the provider to query (required for most resource types, optional for 'provider' type)"`
+	Namespace      string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace containing the provider (optional)"`
+	AllNamespaces  bool   `json:"all_namespaces,omitempty" jsonschema:"Search across all namespaces (optional, only applicable for 'provider' resource type)"`
+	Query          string `json:"query,omitempty" jsonschema:"Optional filter query using SQL-like syntax with WHERE/SELECT/ORDER BY/LIMIT"`
+	OutputFormat   string `json:"output_format,omitempty" jsonschema:"Output format - 'json' for full data or 'planvms' for plan-compatible VM structures (default 'json')"`
+	InventoryURL   string `json:"inventory_url,omitempty" jsonschema:"Base URL for inventory service (optional, auto-discovered if not provided)"`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema:"Maximum time in seconds for the call (optional, defaults to and cannot exceed the server command timeout)"`
+	Refresh        bool   `json:"refresh,omitempty" jsonschema:"If true, query the inventory instead of returning a cached result (optional)"`
+	Cursor         string `json:"cursor,omitempty" jsonschema:"The next_cursor of the previous response, to get the next page of a large result (optional)"`
+	DryRun         bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster        string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
 }
 
 // GetListInventoryTool returns the tool definition
@@ -132,6 +136,14 @@ func GetListInventoryTool() *mcp.Tool {
     - Output can be saved to a file and used directly with create_plan
     - Example: ListInventory("vm", "my-provider", output_format="planvms") > vm-list.yaml
 
+    Caching: Results are cached for a short time per caller, and dropped when a write tool
+    changes the namespace. Cached results are marked cached=true; set refresh=true after
+    changes made outside this server, such as new VMs in the provider.
+
+    Pagination: Results larger than the server response size limit are split into pages.
+    The response reports total and truncated; while next_cursor is set, call again with
+    cursor=next_cursor and the same other arguments to get the next page.
+
     Args:
         resource_type: Type of inventory resource to list
         provider_name: Name of the provider to query (required for most resource types, optional for 'provider' type)
@@ -140,6 +152,10 @@ func GetListInventoryTool() *mcp.Tool {
         query: Optional filter query using SQL-like syntax with WHERE/SELECT/ORDER BY/LIMIT
         output_format: Output format - 'json' for full data or 'planvms' for plan-compatible VM structures (default 'json')
         inventory_url: Base URL for inventory service (optional, auto-discovered if not provided)
+        timeout_seconds: Maximum time in seconds for the call (optional, defaults to and cannot exceed the server command timeout)
+        refresh: If true, query the inventory instead of returning a cached result (optional, default false)
+        cursor: The next_cursor of the previous response, to get the next page of a large result (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         JSON formatted inventory or plan-compatible VM structures (planvms format)
@@ -165,33 +181,57 @@ func GetListInventoryTool() *mcp.Tool {
 
         # Get OpenStack volume types
         ListInventory(resource_type="volumetype", provider_name="openstack-provider")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "List Provider Inventory",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
 	}
 }
 
-func HandleListInventory(ctx context.Context, req *mcp.CallToolRequest, input ListInventoryInput) (*mcp.CallToolResult, any, error) {
+func HandleListInventory(ctx context.Context, req *mcp.CallToolRequest, input ListInventoryInput) (*mcp.CallToolResult, *ListInventoryOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Serve repeated inventory queries from the result cache
+	ctx = mtvmcp.WithCache(ctx, input.Refresh)
+
+	// Apply the per-call timeout if requested
+	if input.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = mtvmcp.WithCommandTimeout(ctx, mtvmcp.CallTimeout(input.TimeoutSeconds))
+		defer cancel()
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"resource_type": input.ResourceType,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	args := []string{"get", "inventory", input.ResourceType}
-
-	// Special handling for provider resource type
-	if input.ResourceType == "provider" {
-		if input.ProviderName != "" {
-			args = append(args, input.ProviderName)
-		}
-	} else {
-		args = append(args, input.ProviderName)
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"provider_name": input.ProviderName,
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := mtvmcp.ValidatePositionalArgs(map[string]string{
+		"resource_type": input.ResourceType,
+	}); err != nil {
+		return nil, nil, err
 	}
 
+	args := []string{"get", "inventory", input.ResourceType}
+
 	if input.AllNamespaces {
 		args = append(args, "-A")
 	} else if input.Namespace != "" {
@@ -202,8 +242,8 @@ func HandleListInventory(ctx context.Context, req *mcp.CallToolRequest, input Li
 		args = append(args, "-q", input.Query)
 	}
 
-	if input.InventoryURL != "" {
-		args = append(args, "--inventory-url", input.InventoryURL)
+	if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); inventoryURL != "" {
+		args = append(args, "--inventory-url", inventoryURL)
 	}
 
 	// Support both json and planvms output formats
@@ -217,15 +257,45 @@ func HandleListInventory(ctx context.Context, req *mcp.CallToolRequest, input Li
 		args = append(args, "-o", "json") // Default to json for unsupported formats
 	}
 
+	// The provider name is optional for the provider resource type
+	if input.ResourceType != "provider" || input.ProviderName != "" {
+		mtvmcp.AddPositionalArgs(&args, input.ProviderName)
+	}
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Decode the kubectl-mtv JSON output into typed inventory records,
+	// planvms output is YAML and is returned as is
+	output, err := mtvmcp.ParseCommandOutput(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &ListInventoryOutput{CommandOutput: output}
+	decoded := false
+	if outputFormat != "planvms" {
+		if input.ResourceType == "vm" {
+			out.VMs, decoded = mtvmcp.DecodeStdoutList[InventoryVM](&out.CommandOutput, "items")
+		} else {
+			out.Items, decoded = mtvmcp.DecodeStdoutList[map[string]any](&out.CommandOutput, "items")
+		}
+	}
+
+	// Split large results into pages of records, or of planvms list entries
+	switch {
+	case decoded && input.ResourceType == "vm":
+		out.VMs, out.PageInfo, err = mtvmcp.Paginate(out.VMs, input.Cursor, mtvmcp.JSONSize[InventoryVM], args...)
+	case decoded:
+		out.Items, out.PageInfo, err = mtvmcp.Paginate(out.Items, input.Cursor, mtvmcp.JSONSize[map[string]any], args...)
+	case outputFormat == "planvms":
+		out.Stdout, out.PageInfo, err = mtvmcp.PaginateText(out.Stdout, input.Cursor, mtvmcp.SplitYAMLList, args...)
+	default:
+		out.Stdout, out.PageInfo, err = mtvmcp.PaginateText(out.Stdout, input.Cursor, mtvmcp.SplitLines, args...)
+	}
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, out, nil
 }
@@ -14,7 +14,9 @@ type ListResourcesInput struct {
 	Namespace     string `json:"namespace,omitempty" jsonschema:"Kubernetes namespace to query (optional, defaults to current namespace)"`
 	AllNamespaces bool   `json:"all_namespaces,omitempty" jsonschema:"List resources across all namespaces"`
 	InventoryURL  string `json:"inventory_url,omitempty" jsonschema:"Base URL for inventory service (optional, only used for provider listings to fetch inventory counts)"`
+	Cursor        string `json:"cursor,omitempty" jsonschema:"The next_cursor of the previous response, to get the next page of a large result (optional)"`
 	DryRun        bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster       string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
 }
 
 // GetListResourcesTool returns the tool definition
@@ -28,11 +30,17 @@ func GetListResourcesTool() *mcp.Tool {
 
     Dry Run Mode: Set dry_run=true to see the command without executing (useful for teaching users)
 
+    Pagination: Results larger than the server response size limit are split into pages.
+    The response reports total and truncated; while next_cursor is set, call again with
+    cursor=next_cursor and the same other arguments to get the next page.
+
     Args:
         resource_type: Type of resource to list - 'provider', 'plan', 'mapping', 'host', or 'hook'
         namespace: Kubernetes namespace to query (optional, defaults to current namespace)
         all_namespaces: List resources across all namespaces
         inventory_url: Base URL for inventory service (optional, only used for provider listings to fetch inventory counts)
+        cursor: The next_cursor of the previous response, to get the next page of a large result (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         JSON formatted resource information
@@ -49,15 +57,31 @@ func GetListResourcesTool() *mcp.Tool {
 
         # List plans in specific namespace
         ListResources(resource_type="plan", namespace="demo")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:          "List MTV Resources",
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
 	}
 }
 
-func HandleListResources(ctx context.Context, req *mcp.CallToolRequest, input ListResourcesInput) (*mcp.CallToolResult, any, error) {
+func HandleListResources(ctx context.Context, req *mcp.CallToolRequest, input ListResourcesInput) (*mcp.CallToolResult, *ListResourcesOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, nil); err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"get"}
 
 	// Validate resource type
@@ -70,25 +94,38 @@ func HandleListResources(ctx context.Context, req *mcp.CallToolRequest, input Li
 		}
 	}
 	if !found {
-		return nil, "", fmt.Errorf("invalid resource_type '%s'. Valid types: %v", input.ResourceType, validTypes)
+		return nil, nil, fmt.Errorf("invalid resource_type '%s'. Valid types: %v", input.ResourceType, validTypes)
 	}
 
 	args = append(args, input.ResourceType)
 	args = append(args, mtvmcp.BuildBaseArgs(input.Namespace, input.AllNamespaces)...)
 
-	if input.ResourceType == "provider" && input.InventoryURL != "" {
-		args = append(args, "--inventory-url", input.InventoryURL)
+	if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); input.ResourceType == "provider" && inventoryURL != "" {
+		args = append(args, "--inventory-url", inventoryURL)
 	}
 
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Decode the kubectl-mtv JSON output into typed resources
+	output, err := mtvmcp.ParseCommandOutput(result)
+	if err != nil {
+		return nil, nil, err
+	}
+	out := &ListResourcesOutput{CommandOutput: output}
+	var decoded bool
+	out.Items, decoded = mtvmcp.DecodeStdoutList[Resource](&out.CommandOutput, "items")
+
+	// Split large results into pages of resources, or of lines when not decoded
+	if decoded {
+		out.Items, out.PageInfo, err = mtvmcp.Paginate(out.Items, input.Cursor, mtvmcp.JSONSize[Resource], args...)
+	} else {
+		out.Stdout, out.PageInfo, err = mtvmcp.PaginateText(out.Stdout, input.Cursor, mtvmcp.SplitLines, args...)
+	}
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, out, nil
 }
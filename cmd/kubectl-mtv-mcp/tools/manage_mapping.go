@@ -28,6 +28,7 @@ type ManageMappingInput struct {
 	DefaultOffloadSecret string `json:"default_offload_secret,omitempty" jsonschema:"Default offload plugin secret name for storage pairs (optional)"`
 	DefaultOffloadVendor string `json:"default_offload_vendor,omitempty" jsonschema:"Default offload plugin vendor for storage pairs (optional)"`
 	DryRun               bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster              string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
 }
 
 // GetManageMappingTool returns the tool definition
@@ -103,6 +104,7 @@ func GetManageMappingTool() *mcp.Tool {
         default_offload_secret: Default offload plugin secret name for storage pairs (optional)
         default_offload_vendor: Default offload plugin vendor for storage pairs (optional)
             • Supported vendors: flashsystem, vantara, ontap, primera3par, pureFlashArray, powerflex, powermax, powerstore, infinibox
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming the mapping operation
@@ -137,22 +139,32 @@ func GetManageMappingTool() *mcp.Tool {
         ManageMapping(action="patch", mapping_type="storage", mapping_name="my-storage-mapping",
                      update_pairs="slow-datastore:standard;volumeMode=Filesystem,fast-datastore:premium;volumeMode=Block;accessMode=ReadWriteOnce",
                      default_offload_plugin="vsphere", default_offload_vendor="ontap")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Manage Network/Storage Mapping",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+		},
 	}
 }
 
-func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input ManageMappingInput) (*mcp.CallToolResult, any, error) {
+func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input ManageMappingInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"action":       input.Action,
 		"mapping_type": input.MappingType,
 		"mapping_name": input.MappingName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Validate action and mapping type
@@ -167,7 +179,7 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 		}
 	}
 	if !actionValid {
-		return nil, "", fmt.Errorf("invalid action '%s'. Valid actions: %v", input.Action, validActions)
+		return nil, nil, fmt.Errorf("invalid action '%s'. Valid actions: %v", input.Action, validActions)
 	}
 
 	typeValid := false
@@ -178,43 +190,50 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 		}
 	}
 	if !typeValid {
-		return nil, "", fmt.Errorf("invalid mapping_type '%s'. Valid types: %v", input.MappingType, validTypes)
+		return nil, nil, fmt.Errorf("invalid mapping_type '%s'. Valid types: %v", input.MappingType, validTypes)
 	}
 
 	// Validate action-specific requirements
 	if input.Action == "create" && (input.SourceProvider == "" || input.TargetProvider == "") {
-		return nil, "", fmt.Errorf("source_provider and target_provider are required for create action")
+		return nil, nil, fmt.Errorf("source_provider and target_provider are required for create action")
 	}
 	if input.Action == "patch" && input.AddPairs == "" && input.UpdatePairs == "" && input.RemovePairs == "" {
-		return nil, "", fmt.Errorf("at least one of add_pairs, update_pairs, or remove_pairs is required for patch action")
+		return nil, nil, fmt.Errorf("at least one of add_pairs, update_pairs, or remove_pairs is required for patch action")
 	}
 
 	// Validate network pairs constraints for network mappings
 	if input.MappingType == "network" {
 		if input.Action == "create" && input.Pairs != "" {
 			if err := mtvmcp.ValidateNetworkPairs(input.Pairs); err != nil {
-				return nil, "", err
+				return nil, nil, err
 			}
 		}
 		if input.Action == "patch" {
 			if input.AddPairs != "" {
 				if err := mtvmcp.ValidateNetworkPairs(input.AddPairs); err != nil {
-					return nil, "", err
+					return nil, nil, err
 				}
 			}
 			if input.UpdatePairs != "" {
 				if err := mtvmcp.ValidateNetworkPairs(input.UpdatePairs); err != nil {
-					return nil, "", err
+					return nil, nil, err
 				}
 			}
 		}
 	}
 
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"mapping_name": input.MappingName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
 	var args []string
 
 	switch input.Action {
 	case "create":
-		args = []string{"create", "mapping", input.MappingType, input.MappingName}
+		args = []string{"create", "mapping", input.MappingType}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
@@ -240,7 +259,7 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				case "block":
 					args = append(args, "--default-volume-mode", "Block")
 				default:
-					return nil, "", fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
+					return nil, nil, fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
 				}
 			}
 			if input.DefaultAccessMode != "" {
@@ -253,7 +272,7 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				case "readonlymany", "rom":
 					args = append(args, "--default-access-mode", "ReadOnlyMany")
 				default:
-					return nil, "", fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
+					return nil, nil, fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
 				}
 			}
 			if input.DefaultOffloadPlugin != "" {
@@ -266,18 +285,18 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				args = append(args, "--default-offload-vendor", input.DefaultOffloadVendor)
 			}
 		}
-		if input.InventoryURL != "" {
-			args = append(args, "--inventory-url", input.InventoryURL)
+		if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); inventoryURL != "" {
+			args = append(args, "--inventory-url", inventoryURL)
 		}
 
 	case "delete":
-		args = []string{"delete", "mapping", input.MappingType, input.MappingName}
+		args = []string{"delete", "mapping", input.MappingType}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
 
 	case "patch":
-		args = []string{"patch", "mapping", input.MappingType, input.MappingName}
+		args = []string{"patch", "mapping", input.MappingType}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
@@ -299,7 +318,7 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				case "block":
 					args = append(args, "--default-volume-mode", "Block")
 				default:
-					return nil, "", fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
+					return nil, nil, fmt.Errorf("invalid default_volume_mode: %s (valid: Filesystem|Block)", input.DefaultVolumeMode)
 				}
 			}
 			if input.DefaultAccessMode != "" {
@@ -312,7 +331,7 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				case "readonlymany", "rom":
 					args = append(args, "--default-access-mode", "ReadOnlyMany")
 				default:
-					return nil, "", fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
+					return nil, nil, fmt.Errorf("invalid default_access_mode: %s (valid: ReadWriteOnce|ReadWriteMany|ReadOnlyMany)", input.DefaultAccessMode)
 				}
 			}
 			if input.DefaultOffloadPlugin != "" {
@@ -325,20 +344,22 @@ func HandleManageMapping(ctx context.Context, req *mcp.CallToolRequest, input Ma
 				args = append(args, "--default-offload-vendor", input.DefaultOffloadVendor)
 			}
 		}
-		if input.InventoryURL != "" {
-			args = append(args, "--inventory-url", input.InventoryURL)
+		if inventoryURL := mtvmcp.ResolveInventoryURL(ctx, input.InventoryURL); inventoryURL != "" {
+			args = append(args, "--inventory-url", inventoryURL)
 		}
 	}
 
+	mtvmcp.AddPositionalArgs(&args, input.MappingName)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
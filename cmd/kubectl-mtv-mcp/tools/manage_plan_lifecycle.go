@@ -17,6 +17,7 @@ type ManagePlanLifecycleInput struct {
 	Cutover   string `json:"cutover,omitempty" jsonschema:"Cutover time in ISO8601 format for start action (optional)"`
 	VMs       string `json:"vms,omitempty" jsonschema:"VM names for cancel action - comma-separated or @filename (required for cancel)"`
 	DryRun    bool   `json:"dry_run,omitempty" jsonschema:"If true, shows commands instead of executing (educational mode)"`
+	Cluster   string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
 }
 
 // GetManagePlanLifecycleTool returns the tool definition
@@ -66,6 +67,7 @@ func GetManagePlanLifecycleTool() *mcp.Tool {
         namespace: Kubernetes namespace containing the plan (optional)
         cutover: Cutover time in ISO8601 format for start action (optional)
         vms: VM names for cancel action - comma-separated or @filename (required for cancel)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming the lifecycle action
@@ -94,22 +96,32 @@ func GetManagePlanLifecycleTool() *mcp.Tool {
 
         # Unarchive plan
         ManagePlanLifecycle(action="unarchive", plan_name="production-migration")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Manage Plan Lifecycle",
+			DestructiveHint: mtvmcp.BoolPtr(true),
+		},
 	}
 }
 
 // HandleManagePlanLifecycle handles plan lifecycle operations
-func HandleManagePlanLifecycle(ctx context.Context, req *mcp.CallToolRequest, input ManagePlanLifecycleInput) (*mcp.CallToolResult, any, error) {
+func HandleManagePlanLifecycle(ctx context.Context, req *mcp.CallToolRequest, input ManagePlanLifecycleInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"action":    input.Action,
 		"plan_name": input.PlanName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
 	// Validate action
@@ -122,20 +134,34 @@ func HandleManagePlanLifecycle(ctx context.Context, req *mcp.CallToolRequest, in
 		}
 	}
 	if !found {
-		return nil, "", fmt.Errorf("invalid action '%s'. Valid actions: %v", input.Action, validActions)
+		return nil, nil, fmt.Errorf("invalid action '%s'. Valid actions: %v", input.Action, validActions)
 	}
 
 	// Validate action-specific requirements
 	if input.Action == "cancel" && input.VMs == "" {
-		return nil, "", fmt.Errorf("the 'vms' parameter is required for cancel action")
+		return nil, nil, fmt.Errorf("the 'vms' parameter is required for cancel action")
+	}
+
+	// The start action accepts several space-separated plan names
+	planNames := []string{input.PlanName}
+	if input.Action == "start" {
+		planNames = strings.Fields(input.PlanName)
+	}
+
+	// Validate the names passed to kubectl-mtv
+	for _, planName := range planNames {
+		if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+			"plan_name": planName,
+		}); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	var args []string
 
 	switch input.Action {
 	case "start":
-		planNames := strings.Fields(input.PlanName)
-		args = append([]string{"start", "plan"}, planNames...)
+		args = []string{"start", "plan"}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
@@ -144,39 +170,41 @@ func HandleManagePlanLifecycle(ctx context.Context, req *mcp.CallToolRequest, in
 		}
 
 	case "cancel":
-		args = []string{"cancel", "plan", input.PlanName, "--vms", input.VMs}
+		args = []string{"cancel", "plan", "--vms", input.VMs}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
 
 	case "cutover":
-		args = []string{"cutover", "plan", input.PlanName}
+		args = []string{"cutover", "plan"}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
 
 	case "archive":
-		args = []string{"archive", "plan", input.PlanName}
+		args = []string{"archive", "plan"}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
 
 	case "unarchive":
-		args = []string{"unarchive", "plan", input.PlanName}
+		args = []string{"unarchive", "plan"}
 		if input.Namespace != "" {
 			args = append(args, "-n", input.Namespace)
 		}
 	}
 
+	mtvmcp.AddPositionalArgs(&args, planNames...)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
@@ -40,6 +40,7 @@ type PatchPlanInput struct {
 	ConvertorNodeSelector          string `json:"convertor_node_selector,omitempty"`
 	ConvertorAffinity              string `json:"convertor_affinity,omitempty"`
 	DryRun                         bool   `json:"dry_run,omitempty"`
+	Cluster                        string `json:"cluster,omitempty"`
 }
 
 // GetPatchPlanTool returns the tool definition
@@ -114,6 +115,7 @@ func GetPatchPlanTool() *mcp.Tool {
         convertor_labels: Labels for virt-v2v convertor pods - 'key1=value1,key2=value2' format (optional)
         convertor_node_selector: Node selector for convertor pod scheduling - 'key1=value1,key2=value2' format (optional)
         convertor_affinity: Convertor affinity using KARL syntax - e.g. 'REQUIRE pods(app=storage) on node' (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming plan patch
@@ -133,23 +135,40 @@ func GetPatchPlanTool() *mcp.Tool {
 
         # Archive plan and add description
         patch_plan(plan_name="my-plan", archived=true, description="Completed production migration")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Patch Plan",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandlePatchPlan(ctx context.Context, req *mcp.CallToolRequest, input PatchPlanInput) (*mcp.CallToolResult, any, error) {
+func HandlePatchPlan(ctx context.Context, req *mcp.CallToolRequest, input PatchPlanInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"plan_name": input.PlanName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"plan_name": input.PlanName,
+	}); err != nil {
+		return nil, nil, err
 	}
 
-	args := []string{"patch", "plan", input.PlanName}
+	args := []string{"patch", "plan"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
@@ -168,7 +187,7 @@ func HandlePatchPlan(ctx context.Context, req *mcp.CallToolRequest, input PatchP
 		case "cold", "warm", "live", "conversion":
 			args = append(args, "--migration-type", mt)
 		default:
-			return nil, "", fmt.Errorf("invalid migration_type: %s (valid: cold|warm|live|conversion)", input.MigrationType)
+			return nil, nil, fmt.Errorf("invalid migration_type: %s (valid: cold|warm|live|conversion)", input.MigrationType)
 		}
 	}
 	if input.TargetLabels != "" {
@@ -189,7 +208,7 @@ func HandlePatchPlan(ctx context.Context, req *mcp.CallToolRequest, input PatchP
 		case "on", "off", "auto":
 			args = append(args, "--target-power-state", ps)
 		default:
-			return nil, "", fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
+			return nil, nil, fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
 		}
 	}
 	if input.Description != "" {
@@ -231,15 +250,17 @@ func HandlePatchPlan(ctx context.Context, req *mcp.CallToolRequest, input PatchP
 		args = append(args, "--convertor-affinity", input.ConvertorAffinity)
 	}
 
+	mtvmcp.AddPositionalArgs(&args, input.PlanName)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
@@ -28,6 +28,7 @@ type PatchPlanVmInput struct {
 	ClearHooks              bool   `json:"clear_hooks,omitempty"`
 	DeleteVMOnFailMigration *bool  `json:"delete_vm_on_fail_migration,omitempty"`
 	DryRun                  bool   `json:"dry_run,omitempty"`
+	Cluster                 string `json:"cluster,omitempty"`
 }
 
 // GetPatchPlanVmTool returns the tool definition
@@ -124,6 +125,7 @@ func GetPatchPlanVmTool() *mcp.Tool {
         add_post_hook: Add a post-migration hook to this VM (optional)
         remove_hook: Remove a hook from this VM by hook name (optional)
         clear_hooks: Remove all hooks from this VM (optional, default False)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming VM patch
@@ -137,24 +139,46 @@ func GetPatchPlanVmTool() *mcp.Tool {
 
         # Use custom PVC naming template
         patch_plan_vm("my-plan", "storage-vm", pvc_name_template="{{.VmName}}-disk-{{.DiskIndex}}")`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Patch Plan VM",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandlePatchPlanVm(ctx context.Context, req *mcp.CallToolRequest, input PatchPlanVmInput) (*mcp.CallToolResult, any, error) {
+func HandlePatchPlanVm(ctx context.Context, req *mcp.CallToolRequest, input PatchPlanVmInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"plan_name": input.PlanName,
 		"vm_name":   input.VmName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"plan_name": input.PlanName,
+	}); err != nil {
+		return nil, nil, err
+	}
+	if err := mtvmcp.ValidatePositionalArgs(map[string]string{
+		"vm_name": input.VmName,
+	}); err != nil {
+		return nil, nil, err
 	}
 
-	args := []string{"patch", "planvm", input.PlanName, input.VmName}
+	args := []string{"patch", "planvm"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
@@ -188,7 +212,7 @@ func HandlePatchPlanVm(ctx context.Context, req *mcp.CallToolRequest, input Patc
 		case "on", "off", "auto":
 			args = append(args, "--target-power-state", ps)
 		default:
-			return nil, "", fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
+			return nil, nil, fmt.Errorf("invalid target_power_state: %s (valid: on|off|auto)", input.TargetPowerState)
 		}
 	}
 
@@ -209,15 +233,17 @@ func HandlePatchPlanVm(ctx context.Context, req *mcp.CallToolRequest, input Patc
 		args = append(args, "--clear-hooks")
 	}
 
+	mtvmcp.AddPositionalArgs(&args, input.PlanName, input.VmName)
+
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	return nil, data, nil
+	return nil, &output, nil
 }
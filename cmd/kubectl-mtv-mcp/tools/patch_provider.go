@@ -2,6 +2,8 @@ package tools
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -26,6 +28,7 @@ type PatchProviderInput struct {
 	ProviderProjectName    string `json:"provider_project_name,omitempty"`
 	ProviderRegionName     string `json:"provider_region_name,omitempty"`
 	DryRun                 bool   `json:"dry_run,omitempty"`
+	Cluster                string `json:"cluster,omitempty"`
 }
 
 // GetPatchProviderTool returns the tool definition
@@ -43,6 +46,11 @@ func GetPatchProviderTool() *mcp.Tool {
     - vSphere VDDK: Init image, AIO optimization, buffer settings
     - OpenStack: Domain, project, and region names
 
+    Credentials:
+    - username, password, token and cacert are written to the secret referenced by the
+      provider, never passed on the kubectl-mtv command line
+    - Other settings are patched first; the credentials are only updated if the patch succeeds
+
     Certificate Loading:
     - Direct content: Pass certificate content as string
     - File loading: Use @filename syntax to load certificate from file
@@ -68,6 +76,7 @@ func GetPatchProviderTool() *mcp.Tool {
         provider_domain_name: OpenStack domain name (OpenStack only) (optional)
         provider_project_name: OpenStack project name (OpenStack only) (optional)
         provider_region_name: OpenStack region name (OpenStack only) (optional)
+        cluster: Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)
 
     Returns:
         Command output confirming provider patch
@@ -83,44 +92,56 @@ func GetPatchProviderTool() *mcp.Tool {
         # Enable VDDK optimization and increase buffer settings
         patch_provider(provider_name="my-vsphere", use_vddk_aio_optimization=true,
                       vddk_buf_count=32, vddk_buf_size_in_64k=128)`,
+		Annotations: &mcp.ToolAnnotations{
+			Title:           "Patch Provider",
+			DestructiveHint: mtvmcp.BoolPtr(false),
+		},
 	}
 }
 
-func HandlePatchProvider(ctx context.Context, req *mcp.CallToolRequest, input PatchProviderInput) (*mcp.CallToolResult, any, error) {
+func HandlePatchProvider(ctx context.Context, req *mcp.CallToolRequest, input PatchProviderInput) (*mcp.CallToolResult, *mtvmcp.CommandOutput, error) {
 	// Enable dry run mode if requested
 	if input.DryRun {
 		ctx = mtvmcp.WithDryRun(ctx, true)
 	}
 
+	// Route the commands to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	// Validate required parameters
 	if err := mtvmcp.ValidateRequiredParams(map[string]string{
 		"provider_name": input.ProviderName,
 	}); err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
 
-	args := []string{"patch", "provider", input.ProviderName}
+	// Validate the names passed to kubectl-mtv
+	if err := mtvmcp.ValidateNames(input.Namespace, map[string]string{
+		"provider_name": input.ProviderName,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	// Update the credentials in the provider Secret, never on the command line
+	secret, err := patchProviderCredentialSecret(ctx, input)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	args := []string{"patch", "provider"}
 
 	if input.Namespace != "" {
 		args = append(args, "-n", input.Namespace)
 	}
+	settings := len(args)
 
 	// Add authentication parameters
 	if input.URL != "" {
 		args = append(args, "--url", input.URL)
 	}
-	if input.Username != "" {
-		args = append(args, "--username", input.Username)
-	}
-	if input.Password != "" {
-		args = append(args, "--password", input.Password)
-	}
-	if input.Cacert != "" {
-		args = append(args, "--cacert", input.Cacert)
-	}
-	if input.Token != "" {
-		args = append(args, "--token", input.Token)
-	}
 
 	// Add security parameters
 	mtvmcp.AddBooleanFlag(&args, "provider-insecure-skip-tls", input.InsecureSkipTLS)
@@ -148,15 +169,96 @@ func HandlePatchProvider(ctx context.Context, req *mcp.CallToolRequest, input Pa
 		args = append(args, "--provider-region-name", input.ProviderRegionName)
 	}
 
-	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
+	// Only the credentials change, the provider itself is not patched
+	if secret != nil && len(args) == settings {
+		output, err := applyCredentialSecret(ctx, secret)
+		if err != nil {
+			return nil, nil, err
+		}
+		return nil, &output, nil
+	}
+
+	mtvmcp.AddPositionalArgs(&args, input.ProviderName)
+
+	// Patch the provider before its credentials, so a failed patch changes nothing
+	output, err := runWithCredentialSecret(ctx, nil, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+	if secret != nil {
+		applied, err := applyCredentialSecret(ctx, secret)
+		if err != nil {
+			return nil, nil, credentialsNotUpdatedError(input.ProviderName, err)
+		}
+		if mtvmcp.GetDryRun(ctx) {
+			output.Stdout = output.Stdout + "\n" + applied.Stdout
+		}
 	}
 
-	// Unmarshal the full CommandResponse to provide complete diagnostic information
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Return the full command result to provide complete diagnostic information
+	return nil, output, nil
+}
+
+// credentialsNotUpdatedError reports that the provider was patched but updating its
+// credentials failed, keeping the command error classification
+func credentialsNotUpdatedError(providerName string, err error) error {
+	message := fmt.Sprintf("provider %s was patched, but its credentials were not updated", providerName)
+	var cmdErr *mtvmcp.CommandError
+	if errors.As(err, &cmdErr) {
+		cmdErr.Message = message + ": " + cmdErr.Message
+		cmdErr.Hint = "Retry PatchProvider with only the credentials. " + cmdErr.Hint
+		return cmdErr
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
+
+// patchProviderCredentialSecret returns the update of the Secret referenced by the provider
+// with the credentials to patch, or nil if no credentials are given
+func patchProviderCredentialSecret(ctx context.Context, input PatchProviderInput) (*credentialSecret, error) {
+	if input.Username == "" && input.Password == "" && input.Token == "" && input.Cacert == "" {
+		return nil, nil
+	}
+	cacert, err := loadCacert(input.Cacert)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	return nil, data, nil
+
+	secret := &credentialSecret{Namespace: input.Namespace, Data: map[string]string{}, Update: true}
+
+	// Look up the provider Secret; in dry run mode only the update command is shown
+	var provider struct {
+		Spec struct {
+			Type   string `json:"type"`
+			Secret struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"secret"`
+		} `json:"spec"`
+	}
+	if !mtvmcp.GetDryRun(ctx) {
+		args := []string{"get", "providers.forklift.konveyor.io", input.ProviderName, "-o", "json"}
+		if input.Namespace != "" {
+			args = append(args, "-n", input.Namespace)
+		}
+		stdout, err := runKubectlStdout(ctx, args)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(stdout), &provider); err != nil {
+			return nil, fmt.Errorf("failed to parse provider: %w", err)
+		}
+		if provider.Spec.Secret.Name == "" {
+			return nil, fmt.Errorf("provider %s has no credentials secret to update", input.ProviderName)
+		}
+		secret.Name = provider.Spec.Secret.Name
+		if provider.Spec.Secret.Namespace != "" {
+			secret.Namespace = provider.Spec.Secret.Namespace
+		}
+	}
+
+	setSecretValue(secret.Data, providerUserKey(provider.Spec.Type), input.Username)
+	setSecretValue(secret.Data, "password", input.Password)
+	setSecretValue(secret.Data, "token", input.Token)
+	setSecretValue(secret.Data, "cacert", cacert)
+	return secret, nil
 }
@@ -0,0 +1,859 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// mtv builds the expected kubectl-mtv command
+func mtv(args ...string) mtvmcp.Command {
+	return mtvmcp.Command{Name: "kubectl-mtv", Args: args}
+}
+
+// kubectl builds the expected kubectl command
+func kubectl(args ...string) mtvmcp.Command {
+	return mtvmcp.Command{Name: "kubectl", Args: args}
+}
+
+// kubectlStdin builds the expected kubectl command with stdin
+func kubectlStdin(stdin string, args ...string) mtvmcp.Command {
+	return mtvmcp.Command{Name: "kubectl", Args: args, Stdin: stdin}
+}
+
+// fixSecretNameSuffix makes the names of new credential Secrets predictable
+func fixSecretNameSuffix(t *testing.T) {
+	t.Helper()
+	suffix := secretNameSuffix
+	secretNameSuffix = func() string { return "abcde" }
+	t.Cleanup(func() { secretNameSuffix = suffix })
+}
+
+// formatCommands renders commands for readable test failures
+func formatCommands(cmds []mtvmcp.Command) string {
+	var lines []string
+	for _, cmd := range cmds {
+		lines = append(lines, cmd.Name+" "+strings.Join(cmd.Args, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// argvTest describes the argv a tool handler is expected to build
+type argvTest struct {
+	name     string
+	setup    func(fake *mtvmcp.FakeExecutor)
+	call     func(ctx context.Context) error
+	expected []mtvmcp.Command
+	wantErr  bool
+}
+
+// runArgvTests runs each handler against a fake executor and compares the executed commands
+func runArgvTests(t *testing.T, tests []argvTest) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := mtvmcp.NewFakeExecutor()
+			if tt.setup != nil {
+				tt.setup(fake)
+			}
+			ctx := mtvmcp.WithExecutor(context.Background(), fake)
+
+			err := tt.call(ctx)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Expected an error, got none")
+				}
+				if calls := fake.Calls(); len(calls) != 0 {
+					t.Errorf("Expected no commands on validation error, got:\n%s", formatCommands(calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			calls := fake.Calls()
+			if !reflect.DeepEqual(calls, tt.expected) {
+				t.Errorf("Unexpected commands.\nExpected:\n%s\nGot:\n%s", formatCommands(tt.expected), formatCommands(calls))
+			}
+		})
+	}
+}
+
+func TestReadToolsArgv(t *testing.T) {
+	runArgvTests(t, []argvTest{
+		{
+			name: "ListResources plans in all namespaces",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "plan", AllNamespaces: true})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("get", "plan", "-A", "-o", "json")},
+		},
+		{
+			name: "ListResources providers with inventory URL",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "provider", Namespace: "demo", InventoryURL: "https://inv"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("get", "provider", "-n", "demo", "-o", "json", "--inventory-url", "https://inv")},
+		},
+		{
+			name: "ListResources rejects invalid type",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "secret"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "ListInventory VMs with query",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{
+					ResourceType: "vm", ProviderName: "vsphere", Namespace: "demo", Query: "WHERE name LIKE 'web%'", OutputFormat: "planvms",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("get", "inventory", "vm", "-n", "demo", "-q", "WHERE name LIKE 'web%'", "-o", "planvms", "--", "vsphere")},
+		},
+		{
+			name: "ListInventory providers without provider name",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "provider", AllNamespaces: true, OutputFormat: "yaml"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("get", "inventory", "provider", "-A", "-o", "json")},
+		},
+		{
+			name: "GetPlanVms",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleGetPlanVms(ctx, nil, GetPlanVmsInput{PlanName: "my-plan", Namespace: "demo"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("get", "plan", "--vms", "-n", "demo", "-o", "json", "--", "my-plan")},
+		},
+		{
+			name: "GetLogs controller auto-detects namespace and pod",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl-mtv", []string{"version", "-o", "json"}, mtvmcp.FakeResponse{Stdout: `{"operatorNamespace":"openshift-mtv"}`})
+				fake.On("kubectl", []string{"get", "pods", "-n", "openshift-mtv", "-l", "app=forklift-controller", "-o", "jsonpath={.items[0].metadata.name}"},
+					mtvmcp.FakeResponse{Stdout: "forklift-controller-abc"})
+				fake.On("kubectl", []string{"get", "pod", "-n", "openshift-mtv", "forklift-controller-abc", "-o", "json"}, mtvmcp.FakeResponse{Stdout: `{}`})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandleGetLogs(ctx, nil, GetLogsInput{Container: "inventory", Lines: 50})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				mtv("version", "-o", "json"),
+				kubectl("get", "pods", "-n", "openshift-mtv", "-l", "app=forklift-controller", "-o", "jsonpath={.items[0].metadata.name}"),
+				kubectl("get", "pod", "-n", "openshift-mtv", "forklift-controller-abc", "-o", "json"),
+				kubectl("logs", "-n", "openshift-mtv", "forklift-controller-abc", "-c", "inventory", "--tail", "50"),
+			},
+		},
+		{
+			name: "GetMigrationStorage PVCs with labels and describe",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl", []string{"get", "pvc", "-n", "demo", "-l", "migration=m1,plan=p1,vmID=vm-47", "-o", "json"},
+					mtvmcp.FakeResponse{Stdout: `{"items":[{"metadata":{"name":"pvc-a"}}]}`})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandleGetMigrationStorage(ctx, nil, GetMigrationStorageInput{
+					ResourceType: "pvc", MigrationID: "m1", PlanID: "p1", VMID: "vm-47", Namespace: "demo",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				kubectl("get", "pvc", "-n", "demo", "-l", "migration=m1,plan=p1,vmID=vm-47", "-o", "json"),
+				kubectl("describe", "pvc", "-n", "demo", "pvc-a"),
+			},
+		},
+	})
+}
+
+func TestCreateToolsArgv(t *testing.T) {
+	fixSecretNameSuffix(t)
+	runArgvTests(t, []argvTest{
+		{
+			name: "CreatePlan flag ordering",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{
+					PlanName:                "my-plan",
+					SourceProvider:          "vsphere",
+					Namespace:               "demo",
+					TargetProvider:          "host",
+					NetworkPairs:            "VM Network:default",
+					DefaultVolumeMode:       "fs",
+					VMs:                     "vm-1,vm-2",
+					Description:             "test plan",
+					PreserveStaticIPs:       mtvmcp.BoolPtr(true),
+					MigrateSharedDisks:      mtvmcp.BoolPtr(false),
+					MigrationType:           "Warm",
+					Warm:                    mtvmcp.BoolPtr(true),
+					TargetPowerState:        "On",
+					RunPreflightInspection:  mtvmcp.BoolPtr(false),
+					ConvertorNodeSelector:   "role=worker",
+					DeleteVMOnFailMigration: mtvmcp.BoolPtr(true),
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv(
+				"create", "plan", "-n", "demo",
+				"--source", "vsphere", "--target", "host",
+				"--network-pairs", "VM Network:default",
+				"--default-volume-mode", "Filesystem",
+				"--vms", "vm-1,vm-2",
+				"--description", "test plan",
+				"--preserve-static-ips",
+				"--migrate-shared-disks=false",
+				"--delete-vm-on-fail-migration",
+				"--migration-type", "warm",
+				"--target-power-state", "on",
+				"--run-preflight-inspection=false",
+				"--convertor-node-selector", "role=worker",
+				"--", "my-plan",
+			)},
+		},
+		{
+			name: "CreatePlan warm flag only without migration type",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{PlanName: "p", SourceProvider: "src", Warm: mtvmcp.BoolPtr(false)})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("create", "plan", "--source", "src", "--warm=false", "--", "p")},
+		},
+		{
+			name: "CreatePlan rejects storage mapping for conversion",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{PlanName: "p", SourceProvider: "src", MigrationType: "conversion", StorageMapping: "sm"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "CreatePlan rejects duplicate network targets",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{PlanName: "p", SourceProvider: "src", NetworkPairs: "a:default,b:default"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "CreateProvider vSphere",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl", []string{"get", "providers.forklift.konveyor.io", "-o", "json", "-n", "demo"}, mtvmcp.FakeResponse{Stdout: `{"items": [
+					{"metadata": {"name": "vsphere", "uid": "uid-1"}, "spec": {"secret": {"name": "vsphere-credentials-abcde", "namespace": "demo"}}},
+					{"metadata": {"name": "other", "uid": "uid-2"}, "spec": {"secret": {"name": "other-credentials", "namespace": "demo"}}}
+				]}`})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateProvider(ctx, nil, CreateProviderInput{
+					ProviderName: "vsphere", ProviderType: "vsphere", Namespace: "demo", URL: "https://vcenter",
+					Username: "admin", Password: "secret", InsecureSkipTLS: mtvmcp.BoolPtr(true), VDDKBufCount: 16,
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				kubectlStdin(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"vsphere-credentials-abcde","namespace":"demo",`+
+					`"labels":{"createdForProviderType":"vsphere","createdForResourceType":"providers"}},"type":"Opaque",`+
+					`"data":{"insecureSkipVerify":"dHJ1ZQ==","password":"c2VjcmV0","url":"aHR0cHM6Ly92Y2VudGVy","user":"YWRtaW4="}}`,
+					"apply", "--server-side", "--field-manager", "kubectl-mtv-mcp", "-n", "demo", "-f", "-"),
+				mtv(
+					"create", "provider", "--type", "vsphere", "-n", "demo", "--secret", "vsphere-credentials-abcde",
+					"--url", "https://vcenter", "--provider-insecure-skip-tls", "--vddk-buf-count", "16", "--", "vsphere",
+				),
+				kubectl("get", "providers.forklift.konveyor.io", "-o", "json", "-n", "demo"),
+				kubectl("patch", "secret", "vsphere-credentials-abcde", "--type", "merge", "-p",
+					`{"metadata":{"ownerReferences":[{"apiVersion":"forklift.konveyor.io/v1beta1","kind":"Provider","name":"vsphere","uid":"uid-1"}]}}`,
+					"-n", "demo"),
+			},
+		},
+		{
+			name: "CreateProvider OpenShift with existing secret",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateProvider(ctx, nil, CreateProviderInput{
+					ProviderName: "host", ProviderType: "openshift", Secret: "ocp-creds", URL: "https://api:6443",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("create", "provider", "--type", "openshift", "--secret", "ocp-creds", "--url", "https://api:6443", "--", "host")},
+		},
+		{
+			name: "CreateProvider rejects existing secret with credentials",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateProvider(ctx, nil, CreateProviderInput{
+					ProviderName: "host", ProviderType: "openshift", Secret: "ocp-creds", Token: "sha256~x",
+				})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "CreateHost with credentials",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl", []string{"get", "hosts.forklift.konveyor.io", "-o", "json", "-n", "demo"}, mtvmcp.FakeResponse{Stdout: `{"items": [
+					{"metadata": {"name": "esxi-1-host", "uid": "uid-3"}, "spec": {"secret": {"name": "esxi-1-credentials-abcde", "namespace": "demo"}}}
+				]}`})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateHost(ctx, nil, CreateHostInput{
+					HostName: "esxi-1", Provider: "vsphere", Namespace: "demo", Username: "root", Password: "esxi-pw",
+					NetworkAdapter: "Management Network",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				kubectlStdin(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"esxi-1-credentials-abcde","namespace":"demo",`+
+					`"labels":{"createdForResourceType":"hosts"}},"type":"Opaque","data":{"password":"ZXN4aS1wdw==","user":"cm9vdA=="}}`,
+					"apply", "--server-side", "--field-manager", "kubectl-mtv-mcp", "-n", "demo", "-f", "-"),
+				mtv(
+					"create", "host", "-n", "demo", "--provider", "vsphere", "--existing-secret", "esxi-1-credentials-abcde",
+					"--network-adapter", "Management Network", "--", "esxi-1",
+				),
+				kubectl("get", "hosts.forklift.konveyor.io", "-o", "json", "-n", "demo"),
+				kubectl("patch", "secret", "esxi-1-credentials-abcde", "--type", "merge", "-p",
+					`{"metadata":{"ownerReferences":[{"apiVersion":"forklift.konveyor.io/v1beta1","kind":"Host","name":"esxi-1-host","uid":"uid-3"}]}}`,
+					"-n", "demo"),
+			},
+		},
+		{
+			name: "CreateHost",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateHost(ctx, nil, CreateHostInput{
+					HostName: "esxi-1", Provider: "vsphere", ExistingSecret: "esxi-creds", IPAddress: "10.0.0.1",
+					HostInsecureSkipTLS: mtvmcp.BoolPtr(false),
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv(
+				"create", "host", "--provider", "vsphere", "--existing-secret", "esxi-creds",
+				"--ip-address", "10.0.0.1", "--host-insecure-skip-tls=false", "--", "esxi-1",
+			)},
+		},
+		{
+			name: "CreateHook",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreateHook(ctx, nil, CreateHookInput{HookName: "pre", Namespace: "demo", Playbook: "@hook.yaml", Deadline: 300})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("create", "hook", "-n", "demo", "--playbook", "@hook.yaml", "--deadline", "300", "--", "pre")},
+		},
+		{
+			name: "ManageMapping create storage",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManageMapping(ctx, nil, ManageMappingInput{
+					Action: "create", MappingType: "storage", MappingName: "sm", SourceProvider: "src", TargetProvider: "dst",
+					Pairs: "ds1:standard", DefaultAccessMode: "rwx",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv(
+				"create", "mapping", "storage", "--source", "src", "--target", "dst",
+				"--storage-pairs", "ds1:standard", "--default-access-mode", "ReadWriteMany", "--", "sm",
+			)},
+		},
+		{
+			name: "ManageMapping patch network",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManageMapping(ctx, nil, ManageMappingInput{
+					Action: "patch", MappingType: "network", MappingName: "nm", Namespace: "demo", RemovePairs: "VM Network",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("patch", "mapping", "network", "-n", "demo", "--remove-pairs", "VM Network", "--", "nm")},
+		},
+	})
+}
+
+func TestLifecycleAndPatchToolsArgv(t *testing.T) {
+	runArgvTests(t, []argvTest{
+		{
+			name: "ManagePlanLifecycle start multiple plans with cutover",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManagePlanLifecycle(ctx, nil, ManagePlanLifecycleInput{
+					Action: "start", PlanName: "plan1 plan2", Namespace: "demo", Cutover: "2025-01-01T00:00:00Z",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("start", "plan", "-n", "demo", "--cutover", "2025-01-01T00:00:00Z", "--", "plan1", "plan2")},
+		},
+		{
+			name: "ManagePlanLifecycle start rejects any invalid plan name",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManagePlanLifecycle(ctx, nil, ManagePlanLifecycleInput{Action: "start", PlanName: "plan1 -A"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "ManagePlanLifecycle cancel VMs",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManagePlanLifecycle(ctx, nil, ManagePlanLifecycleInput{Action: "cancel", PlanName: "plan1", VMs: "vm-1"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("cancel", "plan", "--vms", "vm-1", "--", "plan1")},
+		},
+		{
+			name: "ManagePlanLifecycle cancel requires VMs",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManagePlanLifecycle(ctx, nil, ManagePlanLifecycleInput{Action: "cancel", PlanName: "plan1"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "PatchPlan boolean flags",
+			call: func(ctx context.Context) error {
+				_, _, err := HandlePatchPlan(ctx, nil, PatchPlanInput{
+					PlanName: "p", Namespace: "demo", MigrationType: "cold", Archived: mtvmcp.BoolPtr(false),
+					UseCompatibilityMode: mtvmcp.BoolPtr(true), Warm: mtvmcp.BoolPtr(true),
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("patch", "plan", "-n", "demo", "--migration-type", "cold", "--use-compatibility-mode", "--archived=false", "--", "p")},
+		},
+		{
+			name: "PatchPlanVm hooks",
+			call: func(ctx context.Context) error {
+				_, _, err := HandlePatchPlanVm(ctx, nil, PatchPlanVmInput{
+					PlanName: "p", VmName: "vm-1", TargetName: "web", AddPreHook: "pre", ClearHooks: true,
+					DeleteVMOnFailMigration: mtvmcp.BoolPtr(false),
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv(
+				"patch", "planvm", "--target-name", "web", "--delete-vm-on-fail-migration=false",
+				"--add-pre-hook", "pre", "--clear-hooks", "--", "p", "vm-1",
+			)},
+		},
+		{
+			name: "PatchProvider",
+			call: func(ctx context.Context) error {
+				_, _, err := HandlePatchProvider(ctx, nil, PatchProviderInput{
+					ProviderName: "vsphere", URL: "https://new", InsecureSkipTLS: mtvmcp.BoolPtr(false), ProviderRegionName: "r2",
+				})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("patch", "provider", "--url", "https://new", "--provider-insecure-skip-tls=false", "--provider-region-name", "r2", "--", "vsphere")},
+		},
+		{
+			name: "PatchProvider credentials",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl", []string{"get", "providers.forklift.konveyor.io", "vsphere", "-o", "json", "-n", "demo"}, mtvmcp.FakeResponse{
+					Stdout: `{"spec": {"type": "vsphere", "secret": {"name": "vsphere-xyz12", "namespace": "demo"}}}`,
+				})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandlePatchProvider(ctx, nil, PatchProviderInput{ProviderName: "vsphere", Namespace: "demo", Username: "admin", Password: "pass"})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				kubectl("get", "providers.forklift.konveyor.io", "vsphere", "-o", "json", "-n", "demo"),
+				kubectlStdin(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"vsphere-xyz12","namespace":"demo"},"data":{"password":"cGFzcw==","user":"YWRtaW4="}}`,
+					"apply", "--server-side", "--field-manager", "kubectl-mtv-mcp-password-user", "--force-conflicts", "-n", "demo", "-f", "-"),
+			},
+		},
+		{
+			name: "PatchProvider token and URL",
+			setup: func(fake *mtvmcp.FakeExecutor) {
+				fake.On("kubectl", []string{"get", "providers.forklift.konveyor.io", "host", "-o", "json"}, mtvmcp.FakeResponse{
+					Stdout: `{"spec": {"type": "openshift", "secret": {"name": "host-secret", "namespace": "konveyor-forklift"}}}`,
+				})
+			},
+			call: func(ctx context.Context) error {
+				_, _, err := HandlePatchProvider(ctx, nil, PatchProviderInput{ProviderName: "host", URL: "https://api:6443", Token: "secret"})
+				return err
+			},
+			expected: []mtvmcp.Command{
+				kubectl("get", "providers.forklift.konveyor.io", "host", "-o", "json"),
+				mtv("patch", "provider", "--url", "https://api:6443", "--", "host"),
+				kubectlStdin(`{"apiVersion":"v1","kind":"Secret","metadata":{"name":"host-secret","namespace":"konveyor-forklift"},"data":{"token":"c2VjcmV0"}}`,
+					"apply", "--server-side", "--field-manager", "kubectl-mtv-mcp-token", "--force-conflicts", "-n", "konveyor-forklift", "-f", "-"),
+			},
+		},
+	})
+}
+
+func TestCredentialSecretCleanup(t *testing.T) {
+	fixSecretNameSuffix(t)
+	input := CreateProviderInput{ProviderName: "vsphere", ProviderType: "vsphere", URL: "https://vcenter", Username: "admin", Password: "secret"}
+	createArgs := []string{"create", "provider", "--type", "vsphere", "--secret", "vsphere-credentials-abcde", "--url", "https://vcenter", "--", "vsphere"}
+	deleteArgs := []string{"delete", "secret", "vsphere-credentials-abcde", "--ignore-not-found"}
+
+	t.Run("deleted when the create fails", func(t *testing.T) {
+		fake := mtvmcp.NewFakeExecutor()
+		fake.On("kubectl-mtv", createArgs, mtvmcp.FakeResponse{
+			Stderr:   `Error from server (AlreadyExists): providers.forklift.konveyor.io "vsphere" already exists`,
+			ExitCode: 1,
+		})
+
+		_, _, err := HandleCreateProvider(mtvmcp.WithExecutor(context.Background(), fake), nil, input)
+		var cmdErr *mtvmcp.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Type != mtvmcp.ErrorTypeAlreadyExists {
+			t.Fatalf("Expected the create error, got %v", err)
+		}
+		calls := fake.Calls()
+		if len(calls) != 3 || calls[2].Name != "kubectl" || !reflect.DeepEqual(calls[2].Args, deleteArgs) {
+			t.Errorf("Expected the secret to be deleted, got:\n%s", formatCommands(calls))
+		}
+	})
+
+	t.Run("kept when the create succeeds", func(t *testing.T) {
+		fake := mtvmcp.NewFakeExecutor()
+		if _, _, err := HandleCreateProvider(mtvmcp.WithExecutor(context.Background(), fake), nil, input); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		for _, call := range fake.Calls() {
+			if call.Args[0] == "delete" {
+				t.Errorf("Unexpected secret deletion: %s", formatCommands([]mtvmcp.Command{call}))
+			}
+		}
+	})
+
+	t.Run("not created in dry run mode", func(t *testing.T) {
+		fake := mtvmcp.NewFakeExecutor()
+		input := input
+		input.DryRun = true
+		_, output, err := HandleCreateProvider(mtvmcp.WithExecutor(context.Background(), fake), nil, input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(fake.Calls()) != 0 {
+			t.Errorf("Expected no commands in dry run mode, got:\n%s", formatCommands(fake.Calls()))
+		}
+		if !strings.Contains(output.Stdout, "kubectl apply --server-side") || !strings.Contains(output.Stdout, "--secret vsphere-credentials-abcde") ||
+			strings.Contains(output.Stdout, "--password") || strings.Contains(output.Stdout, "admin") {
+			t.Errorf("Expected the apply and create commands without credentials, got %s", output.Stdout)
+		}
+	})
+}
+
+func TestPatchProviderCredentialsAfterPatch(t *testing.T) {
+	getArgs := []string{"get", "providers.forklift.konveyor.io", "host", "-o", "json"}
+	patchArgs := []string{"patch", "provider", "--url", "https://api:6443", "--", "host"}
+	input := PatchProviderInput{ProviderName: "host", URL: "https://api:6443", Token: "secret"}
+	newFake := func() *mtvmcp.FakeExecutor {
+		fake := mtvmcp.NewFakeExecutor()
+		fake.On("kubectl", getArgs, mtvmcp.FakeResponse{
+			Stdout: `{"spec": {"type": "openshift", "secret": {"name": "host-secret", "namespace": "konveyor-forklift"}}}`,
+		})
+		return fake
+	}
+
+	t.Run("credentials kept when the patch fails", func(t *testing.T) {
+		fake := newFake()
+		fake.On("kubectl-mtv", patchArgs, mtvmcp.FakeResponse{Stderr: "Error: invalid argument", ExitCode: 1})
+
+		if _, _, err := HandlePatchProvider(mtvmcp.WithExecutor(context.Background(), fake), nil, input); err == nil {
+			t.Fatalf("Expected the patch error")
+		}
+		for _, call := range fake.Calls() {
+			if call.Args[0] == "apply" {
+				t.Errorf("Expected the credentials not to be updated, got:\n%s", formatCommands(fake.Calls()))
+			}
+		}
+	})
+
+	t.Run("reported when the credentials update fails", func(t *testing.T) {
+		fake := newFake()
+		fake.Default = &mtvmcp.FakeResponse{Stderr: `Error from server (Forbidden): secrets "host-secret" is forbidden`, ExitCode: 1}
+		fake.On("kubectl-mtv", patchArgs, mtvmcp.FakeResponse{})
+
+		_, _, err := HandlePatchProvider(mtvmcp.WithExecutor(context.Background(), fake), nil, input)
+		var cmdErr *mtvmcp.CommandError
+		if !errors.As(err, &cmdErr) || cmdErr.Type != mtvmcp.ErrorTypeForbidden ||
+			!strings.Contains(cmdErr.Message, "provider host was patched, but its credentials were not updated") {
+			t.Errorf("Expected a classified error saying the provider was patched, got %v", err)
+		}
+	})
+}
+
+func TestDeleteToolsArgv(t *testing.T) {
+	runArgvTests(t, []argvTest{
+		{
+			name: "DeletePlan with options",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: "p", Namespace: "demo", SkipArchive: true, CleanAll: true})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "plan", "-n", "demo", "--skip-archive", "--clean-all", "--", "p")},
+		},
+		{
+			name: "DeletePlan all plans",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{AllPlans: true, Namespace: "demo"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "plan", "--all", "-n", "demo")},
+		},
+		{
+			name: "DeletePlan requires plan name",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "DeletePlan rejects flag-like plan name",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: "--all", Namespace: "demo"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "DeletePlan rejects invalid namespace",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: "p", Namespace: "-A"})
+				return err
+			},
+			wantErr: true,
+		},
+		{
+			name: "DeleteProvider",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeleteProvider(ctx, nil, DeleteProviderInput{ProviderName: "vsphere"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "provider", "--", "vsphere")},
+		},
+		{
+			name: "DeleteHost all hosts",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeleteHost(ctx, nil, DeleteHostInput{AllHosts: true, Namespace: "demo"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "host", "--all", "-n", "demo")},
+		},
+		{
+			name: "DeleteHook",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeleteHook(ctx, nil, DeleteHookInput{HookName: "pre", Namespace: "demo"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "hook", "-n", "demo", "--", "pre")},
+		},
+		{
+			name: "DeleteMapping",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleManageMapping(ctx, nil, ManageMappingInput{Action: "delete", MappingType: "network", MappingName: "nm"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("delete", "mapping", "network", "--", "nm")},
+		},
+	})
+}
+
+func TestDryRunDoesNotExecute(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	ctx := mtvmcp.WithExecutor(context.Background(), fake)
+
+	if _, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: "p", DryRun: true}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := fake.Calls(); len(calls) != 0 {
+		t.Errorf("Expected no commands in dry run mode, got:\n%s", formatCommands(calls))
+	}
+}
+
+func TestGetImporterLogsReplay(t *testing.T) {
+	replay, err := mtvmcp.NewReplayExecutor("testdata/importer-logs")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	ctx := mtvmcp.WithExecutor(context.Background(), replay)
+
+	_, output, err := HandleGetLogs(ctx, nil, GetLogsInput{
+		PodType: "importer", Namespace: "demo", PlanID: "plan-uid", MigrationID: "mig-uid", VMID: "vm-47",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if output.Pod.Metadata.Name != "importer-prime-pvc-uid-1" || output.Pod.Status.Phase != "Running" {
+		t.Errorf("Unexpected importer pod %+v", output.Pod)
+	}
+	if !strings.Contains(output.Logs, "Starting importer") {
+		t.Errorf("Expected importer logs from fixtures, got %q", output.Logs)
+	}
+}
+
+func TestStructuredOutputs(t *testing.T) {
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "plan", "-n", "demo", "-o", "json"}, mtvmcp.FakeResponse{Stdout: `[
+		{"kind": "Plan", "metadata": {"name": "my-plan", "namespace": "demo"},
+		 "spec": {"warm": true},
+		 "status": {"conditions": [{"type": "Ready", "status": "True", "category": "Required"}],
+		            "migration": {"vms": [{"id": "vm-1", "name": "web", "phase": "Completed"}]}}}
+	]`})
+	fake.On("kubectl-mtv", []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}, mtvmcp.FakeResponse{Stdout: `[
+		{"id": "vm-1", "name": "web", "powerState": "poweredOn", "cpuCount": 2, "memoryMB": 4096,
+		 "concerns": [{"category": "Warning", "label": "Changed Block Tracking (CBT) not enabled"}],
+		 "datastores": ["ds1"]}
+	]`})
+	fake.On("kubectl-mtv", []string{"get", "plan", "--vms", "-o", "json", "--", "broken"}, mtvmcp.FakeResponse{
+		Stderr: "Error: plan broken not found", ExitCode: 1,
+	})
+	ctx := mtvmcp.WithExecutor(context.Background(), fake)
+
+	_, resources, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "plan", Namespace: "demo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(resources.Items) != 1 || resources.Stdout != "" {
+		t.Fatalf("Expected one decoded plan and no raw stdout, got %+v", resources)
+	}
+	plan := resources.Items[0]
+	if plan.Metadata.Name != "my-plan" || plan.Status.Conditions[0].Type != "Ready" || plan.Status.Migration.VMs[0].Phase != "Completed" {
+		t.Errorf("Unexpected decoded plan %+v", plan)
+	}
+
+	_, inventory, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "vm", ProviderName: "vsphere", Namespace: "demo"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(inventory.VMs) != 1 {
+		t.Fatalf("Expected one decoded VM, got %+v", inventory)
+	}
+	vm := inventory.VMs[0]
+	if vm.PowerState != "poweredOn" || vm.MemoryMB != 4096 || len(vm.Concerns) != 1 {
+		t.Errorf("Unexpected decoded VM %+v", vm)
+	}
+	if _, ok := vm.Details["datastores"]; !ok || len(vm.Details) != 1 {
+		t.Errorf("Expected provider specific fields in details, got %v", vm.Details)
+	}
+
+	// Failed commands are returned as classified errors
+	_, _, err = HandleGetPlanVms(ctx, nil, GetPlanVmsInput{PlanName: "broken"})
+	var cmdErr *mtvmcp.CommandError
+	if !errors.As(err, &cmdErr) {
+		t.Fatalf("Expected a command error, got %v", err)
+	}
+	if cmdErr.Type != mtvmcp.ErrorTypeNotFound || cmdErr.ReturnValue != 1 || cmdErr.Hint == "" {
+		t.Errorf("Unexpected command error %+v", cmdErr)
+	}
+}
+
+func TestPaginatedOutputs(t *testing.T) {
+	mtvmcp.SetMaxResponseBytes(150)
+	t.Cleanup(func() { mtvmcp.SetMaxResponseBytes(mtvmcp.DefaultMaxResponseBytes) })
+
+	fake := mtvmcp.NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}, mtvmcp.FakeResponse{Stdout: `[
+		{"id": "vm-1", "name": "web", "powerState": "poweredOn", "cpuCount": 2, "memoryMB": 4096},
+		{"id": "vm-2", "name": "db", "powerState": "poweredOn", "cpuCount": 4, "memoryMB": 8192},
+		{"id": "vm-3", "name": "cache", "powerState": "poweredOff", "cpuCount": 1, "memoryMB": 2048}
+	]`})
+	ctx := mtvmcp.WithExecutor(context.Background(), fake)
+
+	// Follow next_cursor through the inventory pages
+	input := ListInventoryInput{ResourceType: "vm", ProviderName: "vsphere", Namespace: "demo"}
+	var names []string
+	for page := 0; page < 3; page++ {
+		_, output, err := HandleListInventory(ctx, nil, input)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if output.Total != 3 || !output.Truncated {
+			t.Errorf("Expected a truncated result of 3 VMs, got %+v", output.PageInfo)
+		}
+		for _, vm := range output.VMs {
+			names = append(names, vm.Name)
+		}
+		if output.NextCursor == "" {
+			break
+		}
+		input.Cursor = output.NextCursor
+	}
+	if !reflect.DeepEqual(names, []string{"web", "db", "cache"}) {
+		t.Errorf("Expected every VM once across the pages, got %v", names)
+	}
+
+	// A cursor cannot be used with other arguments
+	_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "vm", ProviderName: "other", Namespace: "demo", Cursor: input.Cursor})
+	if err == nil || !strings.Contains(err.Error(), "invalid_cursor") {
+		t.Errorf("Expected an invalid cursor error, got %v", err)
+	}
+
+	// Logs are split into whole lines
+	mtvmcp.SetMaxResponseBytes(80)
+	logsInput := GetLogsInput{PodType: "importer", Namespace: "demo", PlanID: "plan-uid", MigrationID: "mig-uid", VMID: "vm-47"}
+	var logs []string
+	for page := 0; page < 2; page++ {
+		replay, err := mtvmcp.NewReplayExecutor("testdata/importer-logs")
+		if err != nil {
+			t.Fatalf("Failed to load fixtures: %v", err)
+		}
+		_, output, err := HandleGetLogs(mtvmcp.WithExecutor(context.Background(), replay), nil, logsInput)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if output.Total != 2 || !output.Truncated {
+			t.Errorf("Expected a truncated result of 2 lines, got %+v", output.PageInfo)
+		}
+		logs = append(logs, output.Logs)
+		logsInput.Cursor = output.NextCursor
+	}
+	if !strings.Contains(logs[0], "Starting importer") || !strings.Contains(logs[1], "42.00") || logsInput.Cursor != "" {
+		t.Errorf("Expected one log line per page, got %q", logs)
+	}
+}
+
+func TestClusterRouting(t *testing.T) {
+	mtvmcp.SetClusterRegistry(&mtvmcp.ClusterRegistry{
+		Default: "prod",
+		Clusters: []mtvmcp.Cluster{
+			{Name: "prod", Context: "prod-admin"},
+			{Name: "lab", Context: "lab", InventoryURL: "https://inventory.lab.example.com"},
+		},
+	})
+	t.Cleanup(func() { mtvmcp.SetClusterRegistry(nil) })
+
+	runArgvTests(t, []argvTest{
+		{
+			name: "default cluster",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListResources(ctx, nil, ListResourcesInput{ResourceType: "plan", Namespace: "demo"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("--context", "prod-admin", "get", "plan", "-n", "demo", "-o", "json")},
+		},
+		{
+			name: "named cluster with inventory URL",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "vm", ProviderName: "vsphere", Cluster: "lab"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("--context", "lab", "get", "inventory", "vm", "--inventory-url", "https://inventory.lab.example.com", "-o", "json", "--", "vsphere")},
+		},
+		{
+			name: "explicit inventory URL wins",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleListInventory(ctx, nil, ListInventoryInput{ResourceType: "vm", ProviderName: "vsphere", Cluster: "lab", InventoryURL: "https://other"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("--context", "lab", "get", "inventory", "vm", "--inventory-url", "https://other", "-o", "json", "--", "vsphere")},
+		},
+		{
+			name: "write tool on a named cluster",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleDeletePlan(ctx, nil, DeletePlanInput{PlanName: "my-plan", Cluster: "lab"})
+				return err
+			},
+			expected: []mtvmcp.Command{mtv("--context", "lab", "delete", "plan", "--", "my-plan")},
+		},
+		{
+			name: "unknown cluster",
+			call: func(ctx context.Context) error {
+				_, _, err := HandleCreatePlan(ctx, nil, CreatePlanInput{PlanName: "p", SourceProvider: "s", Cluster: "missing"})
+				return err
+			},
+			wantErr: true,
+		},
+	})
+}
@@ -0,0 +1,217 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
+)
+
+// ObjectMeta is the Kubernetes object metadata returned by the tools
+type ObjectMeta struct {
+	Name              string            `json:"name"`
+	Namespace         string            `json:"namespace,omitempty"`
+	UID               string            `json:"uid,omitempty"`
+	CreationTimestamp string            `json:"creationTimestamp,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+	Annotations       map[string]string `json:"annotations,omitempty"`
+	OwnerReferences   []OwnerReference  `json:"ownerReferences,omitempty"`
+}
+
+// OwnerReference identifies the owner of a Kubernetes object
+type OwnerReference struct {
+	Kind string `json:"kind,omitempty"`
+	Name string `json:"name,omitempty"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// Condition is a Forklift or Kubernetes status condition
+type Condition struct {
+	Type               string `json:"type"`
+	Status             string `json:"status"`
+	Category           string `json:"category,omitempty" jsonschema:"Forklift condition category (Required, Advisory, Critical, Error, Warn)"`
+	Reason             string `json:"reason,omitempty"`
+	Message            string `json:"message,omitempty"`
+	LastTransitionTime string `json:"lastTransitionTime,omitempty"`
+}
+
+// Resource is an MTV custom resource (provider, plan, mapping, host or hook)
+type Resource struct {
+	APIVersion string          `json:"apiVersion,omitempty"`
+	Kind       string          `json:"kind,omitempty"`
+	Metadata   ObjectMeta      `json:"metadata"`
+	Spec       map[string]any  `json:"spec,omitempty"`
+	Status     *ResourceStatus `json:"status,omitempty"`
+}
+
+// ResourceStatus is the status of an MTV custom resource
+type ResourceStatus struct {
+	Phase              string           `json:"phase,omitempty"`
+	ObservedGeneration int64            `json:"observedGeneration,omitempty"`
+	Conditions         []Condition      `json:"conditions,omitempty"`
+	Migration          *MigrationStatus `json:"migration,omitempty" jsonschema:"Migration status, for plans"`
+}
+
+// MigrationStatus is the status of the latest migration of a plan
+type MigrationStatus struct {
+	Started   string   `json:"started,omitempty"`
+	Completed string   `json:"completed,omitempty"`
+	VMs       []PlanVM `json:"vms,omitempty"`
+}
+
+// PlanVM is the migration status of a VM in a plan
+type PlanVM struct {
+	ID         string         `json:"id,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	Phase      string         `json:"phase,omitempty"`
+	Started    string         `json:"started,omitempty"`
+	Completed  string         `json:"completed,omitempty"`
+	Error      *MigrationErr  `json:"error,omitempty"`
+	Conditions []Condition    `json:"conditions,omitempty"`
+	Pipeline   []PipelineStep `json:"pipeline,omitempty"`
+}
+
+// MigrationErr is the error reported for a failed VM migration or pipeline step
+type MigrationErr struct {
+	Phase   string   `json:"phase,omitempty"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// PipelineStep is a step of a VM migration pipeline
+type PipelineStep struct {
+	Name        string        `json:"name"`
+	Description string        `json:"description,omitempty"`
+	Phase       string        `json:"phase,omitempty"`
+	Started     string        `json:"started,omitempty"`
+	Completed   string        `json:"completed,omitempty"`
+	Progress    *StepProgress `json:"progress,omitempty"`
+	Error       *MigrationErr `json:"error,omitempty"`
+}
+
+// StepProgress is the progress of a pipeline step
+type StepProgress struct {
+	Completed int64 `json:"completed"`
+	Total     int64 `json:"total"`
+}
+
+// InventoryVM is a VM record from the provider inventory.
+// Common fields are typed; provider specific fields are kept in Details.
+type InventoryVM struct {
+	ID         string         `json:"id"`
+	Name       string         `json:"name"`
+	Path       string         `json:"path,omitempty"`
+	PowerState string         `json:"powerState,omitempty"`
+	CPUCount   int            `json:"cpuCount,omitempty"`
+	MemoryMB   int            `json:"memoryMB,omitempty"`
+	GuestID    string         `json:"guestId,omitempty"`
+	GuestName  string         `json:"guestName,omitempty"`
+	IPAddress  string         `json:"ipAddress,omitempty"`
+	Firmware   string         `json:"firmware,omitempty"`
+	IsTemplate bool           `json:"isTemplate,omitempty"`
+	Concerns   []Concern      `json:"concerns,omitempty" jsonschema:"Migration concerns found by the inventory validation"`
+	Details    map[string]any `json:"details,omitempty" jsonschema:"Provider specific VM fields"`
+}
+
+// inventoryVMFields are the JSON fields decoded into typed InventoryVM fields
+var inventoryVMFields = []string{
+	"id", "name", "path", "powerState", "cpuCount", "memoryMB", "guestId",
+	"guestName", "ipAddress", "firmware", "isTemplate", "concerns",
+}
+
+// UnmarshalJSON decodes an inventory VM, keeping untyped fields in Details
+func (vm *InventoryVM) UnmarshalJSON(data []byte) error {
+	type plain InventoryVM
+	if err := json.Unmarshal(data, (*plain)(vm)); err != nil {
+		return err
+	}
+
+	var details map[string]any
+	if err := json.Unmarshal(data, &details); err != nil {
+		return err
+	}
+	for _, field := range inventoryVMFields {
+		delete(details, field)
+	}
+	if len(details) > 0 {
+		vm.Details = details
+	}
+	return nil
+}
+
+// Concern is a migration concern reported by the inventory for a VM
+type Concern struct {
+	Category   string `json:"category"`
+	Label      string `json:"label"`
+	Assessment string `json:"assessment,omitempty"`
+}
+
+// StorageResource is a PVC or DataVolume used by a migration
+type StorageResource struct {
+	Kind     string         `json:"kind,omitempty"`
+	Metadata ObjectMeta     `json:"metadata"`
+	Spec     map[string]any `json:"spec,omitempty"`
+	Status   map[string]any `json:"status,omitempty"`
+	Describe string         `json:"describe,omitempty" jsonschema:"kubectl describe output for the resource"`
+}
+
+// PodInfo is a summary of a pod
+type PodInfo struct {
+	Metadata ObjectMeta `json:"metadata"`
+	Spec     struct {
+		NodeName   string `json:"nodeName,omitempty"`
+		Containers []struct {
+			Name  string `json:"name"`
+			Image string `json:"image,omitempty"`
+		} `json:"containers,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Phase             string            `json:"phase,omitempty"`
+		StartTime         string            `json:"startTime,omitempty"`
+		ContainerStatuses []ContainerStatus `json:"containerStatuses,omitempty"`
+	} `json:"status"`
+}
+
+// ContainerStatus is the status of a container in a pod
+type ContainerStatus struct {
+	Name         string         `json:"name"`
+	Ready        bool           `json:"ready"`
+	RestartCount int            `json:"restartCount"`
+	State        map[string]any `json:"state,omitempty"`
+}
+
+// ListResourcesOutput is the result of the ListResources tool
+type ListResourcesOutput struct {
+	mtvmcp.CommandOutput
+	mtvmcp.PageInfo
+	Items []Resource `json:"items,omitempty" jsonschema:"The listed MTV resources"`
+}
+
+// ListInventoryOutput is the result of the ListInventory tool
+type ListInventoryOutput struct {
+	mtvmcp.CommandOutput
+	mtvmcp.PageInfo
+	VMs   []InventoryVM    `json:"vms,omitempty" jsonschema:"VM records, for resource_type vm"`
+	Items []map[string]any `json:"items,omitempty" jsonschema:"Inventory records, for other resource types"`
+}
+
+// GetPlanVmsOutput is the result of the GetPlanVms tool
+type GetPlanVmsOutput struct {
+	mtvmcp.CommandOutput
+	VMs []PlanVM `json:"vms,omitempty" jsonschema:"The VMs of the plan with their migration status"`
+}
+
+// GetLogsOutput is the result of the GetLogs tool
+type GetLogsOutput struct {
+	Pod      PodInfo              `json:"pod"`
+	Logs     string               `json:"logs"`
+	Snapshot *mtvmcp.SnapshotInfo `json:"snapshot,omitempty" jsonschema:"Set when the result is served from an offline snapshot instead of a live cluster"`
+	mtvmcp.PageInfo
+}
+
+// GetMigrationStorageOutput is the result of the GetMigrationStorage tool
+type GetMigrationStorageOutput struct {
+	PVCs        []StorageResource    `json:"pvcs,omitempty" jsonschema:"The PVCs of the migration"`
+	DataVolumes []StorageResource    `json:"datavolumes,omitempty" jsonschema:"The DataVolumes of the migration"`
+	Errors      []string             `json:"errors,omitempty" jsonschema:"Errors retrieving one of the resource types"`
+	Snapshot    *mtvmcp.SnapshotInfo `json:"snapshot,omitempty" jsonschema:"Set when the result is served from an offline snapshot instead of a live cluster"`
+	mtvmcp.PageInfo
+}
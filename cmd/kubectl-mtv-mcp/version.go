@@ -7,18 +7,46 @@ import (
 	"github.com/yaacov/kubectl-mtv-mcp/pkg/mtvmcp"
 )
 
+// VersionInfo is the version information reported by kubectl-mtv
+type VersionInfo struct {
+	ClientVersion     string `json:"clientVersion,omitempty"`
+	OperatorVersion   string `json:"operatorVersion,omitempty"`
+	OperatorStatus    string `json:"operatorStatus,omitempty"`
+	OperatorNamespace string `json:"operatorNamespace,omitempty"`
+	InventoryURL      string `json:"inventoryURL,omitempty"`
+	InventoryStatus   string `json:"inventoryStatus,omitempty"`
+}
+
+// GetVersionOutput is the result of the GetVersion tool
+type GetVersionOutput struct {
+	mtvmcp.CommandOutput
+	Version *VersionInfo `json:"version,omitempty" jsonschema:"kubectl-mtv and MTV operator version information"`
+}
+
 func handleGetVersion(ctx context.Context, req *mcp.CallToolRequest, input struct {
 	RandomString string `json:"random_string"`
-}) (*mcp.CallToolResult, any, error) {
+	Cluster      string `json:"cluster,omitempty" jsonschema:"Name of the cluster from the cluster registry to run on (optional, defaults to the registry default cluster, see ListClusters)"`
+}) (*mcp.CallToolResult, *GetVersionOutput, error) {
+	// Route the command to the requested cluster
+	ctx, err := mtvmcp.WithCluster(ctx, input.Cluster)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	args := []string{"version", "-o", "json"}
 	result, err := mtvmcp.RunKubectlMTVCommand(ctx, args)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
 	}
-	// Unmarshal the JSON string into a native object for the MCP SDK
-	data, err := mtvmcp.UnmarshalJSONResponse(result)
+	// Decode the kubectl-mtv JSON output into typed version information
+	output, err := mtvmcp.ParseCommandOutput(result)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, err
+	}
+	out := &GetVersionOutput{CommandOutput: output}
+	var version VersionInfo
+	if out.DecodeStdout(&version) {
+		out.Version = &version
 	}
-	return nil, data, nil
+	return nil, out, nil
 }
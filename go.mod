@@ -5,6 +5,7 @@ go 1.23.0
 require (
 	github.com/kballard/go-shellquote v0.0.0-20180428030007-95032a82bc51
 	github.com/modelcontextprotocol/go-sdk v1.0.0
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
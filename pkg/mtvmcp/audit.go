@@ -0,0 +1,332 @@
+package mtvmcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditTrailKey is the context key for the commands run by an audited tool call
+const auditTrailKey contextKey = "audit_trail"
+
+// DefaultAuditQueryLimit is the default number of records returned by an audit log query
+const DefaultAuditQueryLimit = 100
+
+// maxAuditLineSize bounds a single audit record when reading the log
+const maxAuditLineSize = 4 * 1024 * 1024
+
+// sensitiveInputKeys are tool input fields whose values are redacted in audit records
+var sensitiveInputKeys = map[string]bool{
+	"password": true,
+	"token":    true,
+}
+
+// AuditCommand is a command run by an audited tool call
+type AuditCommand struct {
+	Command  string `json:"command"`
+	ExitCode int    `json:"exit_code"`
+}
+
+// AuditRecord is a single entry of the audit log. Records are chained: each
+// record holds the hash of the previous one, and its own hash covers every
+// other field, so edited, removed or reordered records break the chain.
+type AuditRecord struct {
+	Seq        int64          `json:"seq"`
+	Time       time.Time      `json:"time"`
+	SessionID  string         `json:"session_id,omitempty"`
+	User       string         `json:"user"`
+	Tool       string         `json:"tool"`
+	Namespace  string         `json:"namespace,omitempty"`
+	Input      map[string]any `json:"input,omitempty"`
+	Commands   []AuditCommand `json:"commands,omitempty"`
+	ExitCode   int            `json:"exit_code"`
+	ErrorType  string         `json:"error_type,omitempty"`
+	DurationMS int64          `json:"duration_ms"`
+	PrevHash   string         `json:"prev_hash"`
+	Hash       string         `json:"hash"`
+}
+
+// computeHash returns the hash of the record, excluding its Hash field
+func (r AuditRecord) computeHash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditFilter selects audit records; zero fields match every record
+type AuditFilter struct {
+	Since     time.Time
+	Until     time.Time
+	Tool      string
+	Namespace string
+	// Limit returns only the most recent matching records, defaults to DefaultAuditQueryLimit
+	Limit int
+}
+
+// matches reports whether the record passes the filter
+func (f AuditFilter) matches(r AuditRecord) bool {
+	if !f.Since.IsZero() && r.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && r.Time.After(f.Until) {
+		return false
+	}
+	if f.Tool != "" && !strings.EqualFold(f.Tool, r.Tool) {
+		return false
+	}
+	return f.Namespace == "" || f.Namespace == r.Namespace
+}
+
+// AuditQueryResult holds the records matching a query and the integrity of the whole log
+type AuditQueryResult struct {
+	Records []AuditRecord `json:"records,omitempty"`
+	// Total is the number of matching records, before the limit is applied
+	Total int `json:"total"`
+	// ChainValid is false if any record of the log was edited, removed or reordered
+	ChainValid bool   `json:"chain_valid"`
+	ChainError string `json:"chain_error,omitempty"`
+	// LastHash is the hash of the last record, which can be stored elsewhere to detect truncation
+	LastHash string `json:"last_hash,omitempty"`
+}
+
+// AuditLog is an append-only, hash-chained JSONL log of mutating tool calls
+type AuditLog struct {
+	path string
+
+	mu       sync.Mutex
+	file     *os.File
+	seq      int64
+	lastHash string
+}
+
+// OpenAuditLog opens or creates the audit log at path and resumes its hash chain
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	log := &AuditLog{path: path, file: file}
+
+	// Resume from the last record, even if the chain is broken, so that new
+	// records stay verifiable from that point on
+	err = readAuditLog(path, func(record AuditRecord) error {
+		log.seq = record.Seq
+		log.lastHash = record.Hash
+		return nil
+	})
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	return log, nil
+}
+
+// Path returns the path of the audit log file
+func (l *AuditLog) Path() string {
+	return l.path
+}
+
+// Close closes the audit log file
+func (l *AuditLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Append chains the record to the log and writes it. Seq, PrevHash and Hash are set by Append.
+func (l *AuditLog) Append(record AuditRecord) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Seq = l.seq + 1
+	record.PrevHash = l.lastHash
+	hash, err := record.computeHash()
+	if err != nil {
+		return err
+	}
+	record.Hash = hash
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit log: %w", err)
+	}
+	l.seq = record.Seq
+	l.lastHash = record.Hash
+	return nil
+}
+
+// Query verifies the hash chain of the whole log and returns the records matching filter
+func (l *AuditLog) Query(filter AuditFilter) (AuditQueryResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultAuditQueryLimit
+	}
+
+	result := AuditQueryResult{ChainValid: true}
+	var prev *AuditRecord
+	err := readAuditLog(l.path, func(record AuditRecord) error {
+		if result.ChainValid {
+			if chainErr := verifyAuditRecord(prev, record); chainErr != "" {
+				result.ChainValid = false
+				result.ChainError = chainErr
+			}
+		}
+		prev = &record
+		result.LastHash = record.Hash
+
+		if filter.matches(record) {
+			result.Total++
+			result.Records = append(result.Records, record)
+			if len(result.Records) > limit {
+				result.Records = result.Records[1:]
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return AuditQueryResult{}, err
+	}
+	if result.ChainValid && result.LastHash != l.lastHash {
+		result.ChainValid = false
+		result.ChainError = "the log does not end with the last record written by this server, it was truncated or replaced"
+	}
+	return result, nil
+}
+
+// verifyAuditRecord checks that record follows prev in the chain, returning a description of any break
+func verifyAuditRecord(prev *AuditRecord, record AuditRecord) string {
+	if hash, err := record.computeHash(); err != nil || hash != record.Hash {
+		return fmt.Sprintf("record %d was modified: its hash does not match its content", record.Seq)
+	}
+	if prev == nil {
+		if record.Seq != 1 || record.PrevHash != "" {
+			return fmt.Sprintf("the log starts at record %d, earlier records were removed", record.Seq)
+		}
+		return ""
+	}
+	if record.Seq != prev.Seq+1 || record.PrevHash != prev.Hash {
+		return fmt.Sprintf("record %d does not follow record %d, records were removed or reordered", record.Seq, prev.Seq)
+	}
+	return ""
+}
+
+// readAuditLog calls fn for each record of the log at path
+func readAuditLog(path string, fn func(record AuditRecord) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxAuditLineSize)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var record AuditRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("invalid audit record on line %d: %w", lineNumber, err)
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return nil
+}
+
+var (
+	defaultAuditLogMu sync.RWMutex
+	defaultAuditLog   *AuditLog
+)
+
+// SetDefaultAuditLog sets the server-wide audit log, nil disables auditing
+func SetDefaultAuditLog(log *AuditLog) {
+	defaultAuditLogMu.Lock()
+	defer defaultAuditLogMu.Unlock()
+	defaultAuditLog = log
+}
+
+// DefaultAuditLog returns the server-wide audit log, or nil if auditing is disabled
+func DefaultAuditLog() *AuditLog {
+	defaultAuditLogMu.RLock()
+	defer defaultAuditLogMu.RUnlock()
+	return defaultAuditLog
+}
+
+// SanitizeInput returns a copy of a tool input with the values of sensitive fields redacted
+func SanitizeInput(input map[string]any) map[string]any {
+	if input == nil {
+		return nil
+	}
+	sanitized := make(map[string]any, len(input))
+	for key, value := range input {
+		if sensitiveInputKeys[strings.ToLower(key)] {
+			if s, ok := value.(string); !ok || s != "" {
+				value = "****"
+			}
+		} else if nested, ok := value.(map[string]any); ok {
+			value = SanitizeInput(nested)
+		}
+		sanitized[key] = value
+	}
+	return sanitized
+}
+
+// AuditTrail collects the commands run by a tool call
+type AuditTrail struct {
+	mu       sync.Mutex
+	commands []AuditCommand
+}
+
+// WithAuditTrail returns a context in which runCommand records every command it runs in the trail
+func WithAuditTrail(ctx context.Context) (context.Context, *AuditTrail) {
+	trail := &AuditTrail{}
+	return context.WithValue(ctx, auditTrailKey, trail), trail
+}
+
+// Commands returns the commands recorded so far
+func (t *AuditTrail) Commands() []AuditCommand {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]AuditCommand(nil), t.commands...)
+}
+
+// recordAuditCommand adds a command to the audit trail of the context, if any
+func recordAuditCommand(ctx context.Context, command string, exitCode int) {
+	if trail, ok := ctx.Value(auditTrailKey).(*AuditTrail); ok {
+		trail.mu.Lock()
+		defer trail.mu.Unlock()
+		trail.commands = append(trail.commands, AuditCommand{Command: command, ExitCode: exitCode})
+	}
+}
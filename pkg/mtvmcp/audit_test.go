@@ -0,0 +1,179 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuditLogChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	records := []AuditRecord{
+		{Time: start, User: "alice", Tool: "CreatePlan", Namespace: "demo"},
+		{Time: start.Add(time.Hour), User: "bob", Tool: "DeletePlan", Namespace: "demo"},
+		{Time: start.Add(2 * time.Hour), User: "alice", Tool: "DeletePlan", Namespace: "prod"},
+	}
+	for _, record := range records[:2] {
+		if err := auditLog.Append(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = auditLog.Close()
+
+	// Reopening resumes the chain
+	auditLog, err = OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = auditLog.Close() })
+	if err := auditLog.Append(records[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		filter    AuditFilter
+		wantUsers []string
+		wantTotal int
+	}{
+		{"all", AuditFilter{}, []string{"alice", "bob", "alice"}, 3},
+		{"tool", AuditFilter{Tool: "deleteplan"}, []string{"bob", "alice"}, 2},
+		{"namespace", AuditFilter{Namespace: "demo"}, []string{"alice", "bob"}, 2},
+		{"time range", AuditFilter{Since: start.Add(30 * time.Minute), Until: start.Add(90 * time.Minute)}, []string{"bob"}, 1},
+		{"limit keeps the most recent", AuditFilter{Limit: 1}, []string{"alice"}, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := auditLog.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.ChainValid {
+				t.Fatalf("Expected a valid chain, got %s", result.ChainError)
+			}
+			var users []string
+			for _, record := range result.Records {
+				users = append(users, record.User)
+			}
+			if strings.Join(users, ",") != strings.Join(tt.wantUsers, ",") || result.Total != tt.wantTotal {
+				t.Errorf("Expected %v (total %d), got %v (total %d)", tt.wantUsers, tt.wantTotal, users, result.Total)
+			}
+		})
+	}
+
+	result, _ := auditLog.Query(AuditFilter{})
+	if result.Records[2].Seq != 3 || result.Records[2].PrevHash != result.Records[1].Hash || result.LastHash != result.Records[2].Hash {
+		t.Errorf("Unexpected chain %+v", result.Records)
+	}
+}
+
+func TestAuditLogTampering(t *testing.T) {
+	tests := []struct {
+		name      string
+		tamper    func(lines []string) []string
+		wantError string
+	}{
+		{
+			name: "edited record",
+			tamper: func(lines []string) []string {
+				lines[1] = strings.Replace(lines[1], `"user":"bob"`, `"user":"eve"`, 1)
+				return lines
+			},
+			wantError: "record 2 was modified",
+		},
+		{
+			name:      "removed record",
+			tamper:    func(lines []string) []string { return append(lines[:1], lines[2:]...) },
+			wantError: "record 3 does not follow record 1",
+		},
+		{
+			name:      "removed first record",
+			tamper:    func(lines []string) []string { return lines[1:] },
+			wantError: "the log starts at record 2",
+		},
+		{
+			name:      "truncated",
+			tamper:    func(lines []string) []string { return lines[:2] },
+			wantError: "truncated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.jsonl")
+			auditLog, err := OpenAuditLog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { _ = auditLog.Close() })
+			for _, user := range []string{"alice", "bob", "carol"} {
+				if err := auditLog.Append(AuditRecord{Time: time.Now().UTC(), User: user, Tool: "DeletePlan"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(strings.Split(strings.TrimSpace(string(data)), "\n"))
+			if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := auditLog.Query(AuditFilter{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.ChainValid || !strings.Contains(result.ChainError, tt.wantError) {
+				t.Errorf("Expected a broken chain with %q, got valid=%v %q", tt.wantError, result.ChainValid, result.ChainError)
+			}
+		})
+	}
+}
+
+func TestSanitizeInput(t *testing.T) {
+	input := map[string]any{
+		"provider_name": "vsphere",
+		"password":      "secret",
+		"Token":         "abc",
+		"username":      "admin",
+		"nested":        map[string]any{"password": "secret"},
+		"empty":         map[string]any{"token": ""},
+	}
+	sanitized := SanitizeInput(input)
+	if sanitized["password"] != "****" || sanitized["Token"] != "****" || sanitized["provider_name"] != "vsphere" || sanitized["username"] != "admin" {
+		t.Errorf("Unexpected sanitized input %v", sanitized)
+	}
+	if nested := sanitized["nested"].(map[string]any); nested["password"] != "****" {
+		t.Errorf("Expected nested password to be redacted, got %v", nested)
+	}
+	if empty := sanitized["empty"].(map[string]any); empty["token"] != "" {
+		t.Errorf("Expected an empty token to stay empty, got %v", empty)
+	}
+	if input["password"] != "secret" {
+		t.Errorf("SanitizeInput modified its input")
+	}
+}
+
+func TestAuditTrail(t *testing.T) {
+	setTestKubeconfig(t)
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"delete", "plan", "web"}, FakeResponse{ExitCode: 1, Stderr: "not found"})
+	ctx, trail := WithAuditTrail(WithExecutor(context.Background(), fake))
+
+	if _, err := RunKubectlMTVCommand(ctx, []string{"delete", "plan", "web"}); err != nil {
+		t.Fatal(err)
+	}
+	commands := trail.Commands()
+	if len(commands) != 1 || commands[0].Command != "kubectl-mtv delete plan web" || commands[0].ExitCode != 1 {
+		t.Errorf("Unexpected audit trail %+v", commands)
+	}
+}
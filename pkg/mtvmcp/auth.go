@@ -0,0 +1,245 @@
+package mtvmcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// userInfoKey is the context key for the authenticated user
+	userInfoKey contextKey = "user_info"
+	// impersonationKey is the context key for the user commands impersonate
+	impersonationKey contextKey = "impersonation"
+)
+
+// Token review modes
+const (
+	// TokenReviewNone accepts bearer tokens without verifying them
+	TokenReviewNone = "none"
+	// TokenReviewSelfSubject verifies a token by asking the API server who the token
+	// belongs to (SelfSubjectReview, kubectl auth whoami), using the token itself
+	TokenReviewSelfSubject = "self-subject-review"
+	// TokenReviewTokenReview verifies a token with a TokenReview created with the
+	// server's own credentials, which need the system:auth-delegator role
+	TokenReviewTokenReview = "token-review"
+)
+
+// tokenReviewCacheTTL is how long a verified token is trusted before it is reviewed again
+const tokenReviewCacheTTL = time.Minute
+
+// tokenReviewCacheSize bounds the number of cached token reviews
+const tokenReviewCacheSize = 1024
+
+// ErrUnauthenticated is returned when a bearer token is rejected by the API server
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// UserInfo is the identity of an authenticated user
+type UserInfo struct {
+	Username string   `json:"username"`
+	UID      string   `json:"uid,omitempty"`
+	Groups   []string `json:"groups,omitempty"`
+}
+
+// WithUserInfo adds the authenticated user to the context
+func WithUserInfo(ctx context.Context, user UserInfo) context.Context {
+	return context.WithValue(ctx, userInfoKey, user)
+}
+
+// GetUserInfo retrieves the authenticated user from the context
+func GetUserInfo(ctx context.Context) (UserInfo, bool) {
+	if ctx == nil {
+		return UserInfo{}, false
+	}
+	user, ok := ctx.Value(userInfoKey).(UserInfo)
+	return user, ok
+}
+
+// WithImpersonation makes every command run with the returned context impersonate
+// user with --as and --as-group. The server credentials need the impersonate verb.
+func WithImpersonation(ctx context.Context, user UserInfo) context.Context {
+	return context.WithValue(ctx, impersonationKey, user)
+}
+
+// GetImpersonation retrieves the impersonated user from the context
+func GetImpersonation(ctx context.Context) (UserInfo, bool) {
+	if ctx == nil {
+		return UserInfo{}, false
+	}
+	user, ok := ctx.Value(impersonationKey).(UserInfo)
+	return user, ok && user.Username != ""
+}
+
+// ImpersonationArgs returns the kubectl flags that impersonate the user
+func (u UserInfo) ImpersonationArgs() []string {
+	if u.Username == "" {
+		return nil
+	}
+	args := []string{"--as", u.Username}
+	for _, group := range u.Groups {
+		args = append(args, "--as-group", group)
+	}
+	return args
+}
+
+// ValidateTokenReviewMode checks that the token review mode is known
+func ValidateTokenReviewMode(mode string) error {
+	switch mode {
+	case TokenReviewNone, TokenReviewSelfSubject, TokenReviewTokenReview:
+		return nil
+	}
+	return fmt.Errorf("invalid token review mode '%s'. Valid modes: %s, %s, %s", mode, TokenReviewNone, TokenReviewSelfSubject, TokenReviewTokenReview)
+}
+
+// tokenReviewEntry is a cached successful token review
+type tokenReviewEntry struct {
+	user    UserInfo
+	expires time.Time
+}
+
+// TokenReviewer verifies bearer tokens against the Kubernetes API server.
+// Successful reviews are cached for a short time, keyed by the token digest,
+// so that a session does not trigger a review for every request.
+type TokenReviewer struct {
+	mode string
+
+	mu    sync.Mutex
+	cache map[string]tokenReviewEntry
+	now   func() time.Time
+}
+
+// NewTokenReviewer creates a TokenReviewer for the given mode
+func NewTokenReviewer(mode string) (*TokenReviewer, error) {
+	if err := ValidateTokenReviewMode(mode); err != nil {
+		return nil, err
+	}
+	return &TokenReviewer{mode: mode, cache: make(map[string]tokenReviewEntry), now: time.Now}, nil
+}
+
+// Review returns the user the token belongs to. It returns an error wrapping
+// ErrUnauthenticated if the API server rejects the token.
+func (r *TokenReviewer) Review(ctx context.Context, token string) (UserInfo, error) {
+	if token == "" {
+		return UserInfo{}, fmt.Errorf("%w: no bearer token", ErrUnauthenticated)
+	}
+	if r.mode == TokenReviewNone {
+		return UserInfo{}, nil
+	}
+
+	sum := sha256.Sum256([]byte(token))
+	digest := hex.EncodeToString(sum[:])
+
+	r.mu.Lock()
+	entry, ok := r.cache[digest]
+	r.mu.Unlock()
+	if ok && r.now().Before(entry.expires) {
+		return entry.user, nil
+	}
+
+	var user UserInfo
+	var err error
+	if r.mode == TokenReviewTokenReview {
+		user, err = tokenReview(ctx, token)
+	} else {
+		user, err = selfSubjectReview(ctx, token)
+	}
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.cache) >= tokenReviewCacheSize {
+		for key, cached := range r.cache {
+			if !r.now().Before(cached.expires) {
+				delete(r.cache, key)
+			}
+		}
+		if len(r.cache) >= tokenReviewCacheSize {
+			r.cache = make(map[string]tokenReviewEntry)
+		}
+	}
+	r.cache[digest] = tokenReviewEntry{user: user, expires: r.now().Add(tokenReviewCacheTTL)}
+	return user, nil
+}
+
+// selfSubjectReview asks the API server who the token belongs to, authenticating with the token
+func selfSubjectReview(ctx context.Context, token string) (UserInfo, error) {
+	ctx = WithKubeToken(WithServerCommand(WithExecutor(WithDryRun(ctx, false), liveExecutor(ctx))), token)
+	result, err := runCommand(ctx, "kubectl", []string{"auth", "whoami", "-o", "json"}, "")
+	if err != nil {
+		return UserInfo{}, err
+	}
+	output, err := ParseCommandOutput(result)
+	if err != nil {
+		return UserInfo{}, reviewError(err)
+	}
+
+	var review struct {
+		Status struct {
+			UserInfo UserInfo `json:"userInfo"`
+		} `json:"status"`
+	}
+	if !output.DecodeStdout(&review) || review.Status.UserInfo.Username == "" {
+		return UserInfo{}, fmt.Errorf("failed to parse SelfSubjectReview response")
+	}
+	return review.Status.UserInfo, nil
+}
+
+// tokenReview verifies the token with a TokenReview created with the server's own credentials.
+// The review is passed on stdin, so the token is never on the command line, and runs
+// outside recording and replay, since the response echoes the token. Like the
+// SelfSubjectReview, it does not count against the subprocess limits.
+func tokenReview(ctx context.Context, token string) (UserInfo, error) {
+	manifest, err := json.Marshal(map[string]interface{}{
+		"apiVersion": "authentication.k8s.io/v1",
+		"kind":       "TokenReview",
+		"spec":       map[string]interface{}{"token": token},
+	})
+	if err != nil {
+		return UserInfo{}, fmt.Errorf("failed to marshal TokenReview: %w", err)
+	}
+
+	// Drop any request identity, the review is created by the server itself
+	ctx = WithImpersonation(WithKubeToken(WithServerCommand(WithExecutor(WithDryRun(ctx, false), liveExecutor(ctx))), ""), UserInfo{})
+	result, err := runCommand(ctx, "kubectl", []string{"create", "-f", "-", "-o", "json"}, string(manifest))
+	if err != nil {
+		return UserInfo{}, err
+	}
+	output, err := ParseCommandOutput(result)
+	if err != nil {
+		return UserInfo{}, err
+	}
+
+	var review struct {
+		Status struct {
+			Authenticated bool     `json:"authenticated"`
+			User          UserInfo `json:"user"`
+			Error         string   `json:"error"`
+		} `json:"status"`
+	}
+	if !output.DecodeStdout(&review) {
+		return UserInfo{}, fmt.Errorf("failed to parse TokenReview response")
+	}
+	if !review.Status.Authenticated {
+		if review.Status.Error != "" {
+			return UserInfo{}, fmt.Errorf("%w: %s", ErrUnauthenticated, review.Status.Error)
+		}
+		return UserInfo{}, fmt.Errorf("%w: token was not authenticated", ErrUnauthenticated)
+	}
+	return review.Status.User, nil
+}
+
+// reviewError maps an unauthorized SelfSubjectReview command error to ErrUnauthenticated
+func reviewError(err error) error {
+	var commandErr *CommandError
+	if errors.As(err, &commandErr) && commandErr.Type == ErrorTypeUnauthorized {
+		return fmt.Errorf("%w: %s", ErrUnauthenticated, commandErr.Message)
+	}
+	return err
+}
@@ -0,0 +1,265 @@
+package mtvmcp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenReviewerSelfSubjectReview(t *testing.T) {
+	setTestKubeconfig(t)
+
+	whoami := []string{"auth", "whoami", "-o", "json"}
+	tests := []struct {
+		name     string
+		response FakeResponse
+		want     UserInfo
+		unauth   bool
+	}{
+		{
+			name: "valid token",
+			response: FakeResponse{Stdout: `{"kind": "SelfSubjectReview", "status": {"userInfo": {
+				"username": "jdoe", "uid": "1234", "groups": ["migration-admins", "system:authenticated"]}}}`},
+			want: UserInfo{Username: "jdoe", UID: "1234", Groups: []string{"migration-admins", "system:authenticated"}},
+		},
+		{
+			name:     "invalid token",
+			response: FakeResponse{Stderr: "error: You must be logged in to the server (Unauthorized)", ExitCode: 1},
+			unauth:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeExecutor()
+			fake.Default = nil
+			fake.On("kubectl", whoami, tt.response)
+			ctx := WithExecutor(context.Background(), fake)
+
+			reviewer, err := NewTokenReviewer(TokenReviewSelfSubject)
+			if err != nil {
+				t.Fatal(err)
+			}
+			user, err := reviewer.Review(ctx, "request-token")
+			if tt.unauth {
+				if !errors.Is(err, ErrUnauthenticated) {
+					t.Errorf("Expected ErrUnauthenticated, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if user.Username != tt.want.Username || user.UID != tt.want.UID || !slices.Equal(user.Groups, tt.want.Groups) {
+				t.Errorf("Expected %+v, got %+v", tt.want, user)
+			}
+
+			// The review runs with the reviewed token, and is cached
+			if _, err := reviewer.Review(ctx, "request-token"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			calls := fake.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("Expected one cached review, got %d calls", len(calls))
+			}
+			if len(calls[0].Env) != 1 || !strings.HasPrefix(calls[0].Env[0], "KUBECONFIG=") {
+				t.Errorf("Expected the review to authenticate with the token kubeconfig, got %v", calls[0].Env)
+			}
+
+			// Expired reviews are repeated
+			reviewer.now = func() time.Time { return time.Now().Add(2 * tokenReviewCacheTTL) }
+			if _, err := reviewer.Review(ctx, "request-token"); err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if calls := fake.Calls(); len(calls) != 2 {
+				t.Errorf("Expected an expired review to be repeated, got %d calls", len(calls))
+			}
+		})
+	}
+}
+
+func TestTokenReviewerTokenReview(t *testing.T) {
+	create := []string{"create", "-f", "-", "-o", "json"}
+	tests := []struct {
+		name     string
+		response FakeResponse
+		want     string
+		unauth   bool
+	}{
+		{
+			name: "authenticated",
+			response: FakeResponse{Stdout: `{"kind": "TokenReview", "status": {"authenticated": true,
+				"user": {"username": "system:serviceaccount:demo:migrator", "groups": ["system:serviceaccounts"]}}}`},
+			want: "system:serviceaccount:demo:migrator",
+		},
+		{
+			name:     "not authenticated",
+			response: FakeResponse{Stdout: `{"kind": "TokenReview", "status": {"authenticated": false, "error": "token has expired"}}`},
+			unauth:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := NewFakeExecutor()
+			fake.Default = nil
+			fake.On("kubectl", create, tt.response)
+			// A token already in the context must not be used to create the review
+			ctx := WithKubeToken(WithExecutor(context.Background(), fake), "other-token")
+
+			reviewer, err := NewTokenReviewer(TokenReviewTokenReview)
+			if err != nil {
+				t.Fatal(err)
+			}
+			user, err := reviewer.Review(ctx, "request-token")
+			if tt.unauth {
+				if !errors.Is(err, ErrUnauthenticated) || !strings.Contains(err.Error(), "token has expired") {
+					t.Errorf("Expected ErrUnauthenticated with the review error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if user.Username != tt.want {
+				t.Errorf("Expected user %s, got %+v", tt.want, user)
+			}
+
+			calls := fake.Calls()
+			if len(calls) != 1 {
+				t.Fatalf("Expected one call, got %d", len(calls))
+			}
+			if !strings.Contains(calls[0].Stdin, `"token":"request-token"`) {
+				t.Errorf("Expected the TokenReview on stdin, got %q", calls[0].Stdin)
+			}
+			if len(calls[0].Env) != 0 {
+				t.Errorf("Expected the review to use the server credentials, got %v", calls[0].Env)
+			}
+		})
+	}
+}
+
+func TestTokenReviewNotRecorded(t *testing.T) {
+	dir := t.TempDir()
+	fake := NewFakeExecutor()
+	fake.Default = nil
+	// The API server echoes the reviewed token in spec
+	fake.On("kubectl", []string{"create", "-f", "-", "-o", "json"}, FakeResponse{Stdout: `{"kind": "TokenReview",
+		"spec": {"token": "request-token"},
+		"status": {"authenticated": true, "user": {"username": "alice"}}}`})
+
+	recorder, err := NewRecordingExecutor(fake, dir)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	reviewer, err := NewTokenReviewer(TokenReviewTokenReview)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviewer.Review(WithExecutor(context.Background(), recorder), "request-token"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		if strings.Contains(string(data), "request-token") {
+			t.Errorf("Fixture %s contains the token", file)
+		}
+	}
+	if len(files) != 0 {
+		t.Errorf("Expected the review not to be recorded, got %d fixtures", len(files))
+	}
+	if len(fake.Calls()) != 1 {
+		t.Errorf("Expected the review to run on the live executor, got %d calls", len(fake.Calls()))
+	}
+
+	// Replay and must-gather cannot answer reviews, every token must reach the cluster
+	for _, executor := range []Executor{&ReplayExecutor{}, &MustGatherExecutor{}} {
+		if _, ok := liveExecutor(WithExecutor(context.Background(), executor)).(SubprocessExecutor); !ok {
+			t.Errorf("Expected reviews with %T to run as subprocesses", executor)
+		}
+	}
+
+	// The echoed token is also redacted from the command output
+	if output := redactOutput(`{"kind": "TokenReview", "spec": {"token": "request-token"}}`); strings.Contains(output, "request-token") {
+		t.Errorf("Expected the TokenReview token to be redacted, got %s", output)
+	}
+}
+
+func TestTokenReviewIgnoresLimits(t *testing.T) {
+	SetLimits(Limits{MaxConcurrent: 1, Rate: 0.001, Burst: 1})
+	t.Cleanup(func() { SetLimits(Limits{MaxConcurrent: DefaultMaxConcurrentSubprocesses}) })
+
+	// Take the only subprocess slot and the whole rate limit budget of the caller
+	release, err := limiter.acquire(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	fake := NewFakeExecutor()
+	fake.Default = nil
+	fake.On("kubectl", []string{"create", "-f", "-", "-o", "json"}, FakeResponse{Stdout: `{"kind": "TokenReview",
+		"status": {"authenticated": true, "user": {"username": "alice"}}}`})
+	reviewer, err := NewTokenReviewer(TokenReviewTokenReview)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := reviewer.Review(WithExecutor(context.Background(), fake), "request-token")
+	if err != nil || user.Username != "alice" {
+		t.Fatalf("Expected the review to succeed on a saturated server, got %+v, %v", user, err)
+	}
+}
+
+func TestTokenReviewerRejectsMissingToken(t *testing.T) {
+	reviewer, err := NewTokenReviewer(TokenReviewSelfSubject)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reviewer.Review(context.Background(), ""); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("Expected ErrUnauthenticated for a missing token, got %v", err)
+	}
+	if _, err := NewTokenReviewer("bogus"); err == nil {
+		t.Errorf("Expected an error for an unknown mode")
+	}
+}
+
+func TestImpersonation(t *testing.T) {
+	fake := NewFakeExecutor()
+	ctx := WithExecutor(context.Background(), fake)
+	user := UserInfo{Username: "jdoe", Groups: []string{"migration-admins", "dev"}}
+
+	// The request token is not used when impersonating
+	ctx = WithImpersonation(WithKubeToken(ctx, "request-token"), user)
+	if _, err := RunKubectlMTVCommand(ctx, []string{"get", "plan", "-o", "json"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("Expected one call, got %d", len(calls))
+	}
+	expected := []string{"--as", "jdoe", "--as-group", "migration-admins", "--as-group", "dev", "get", "plan", "-o", "json"}
+	if !slices.Equal(calls[0].Args, expected) {
+		t.Errorf("Expected args %v, got %v", expected, calls[0].Args)
+	}
+	if len(calls[0].Env) != 0 {
+		t.Errorf("Expected the server credentials when impersonating, got %v", calls[0].Env)
+	}
+
+	if args := (UserInfo{}).ImpersonationArgs(); args != nil {
+		t.Errorf("Expected no flags for an empty user, got %v", args)
+	}
+}
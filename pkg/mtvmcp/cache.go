@@ -0,0 +1,228 @@
+package mtvmcp
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheModeKey is the context key for the result cache mode of commands
+const cacheModeKey contextKey = "cache_mode"
+
+// DefaultCacheTTL is the default time a read-only command result is served from the cache
+const DefaultCacheTTL = 30 * time.Second
+
+// DefaultCacheMaxBytes is the default bound on the total size of the cached results
+const DefaultCacheMaxBytes = 64 << 20
+
+// allNamespaces is the namespace of cached commands that list all namespaces
+const allNamespaces = "*"
+
+// cacheMode selects how commands run with a context use the result cache
+type cacheMode int
+
+const (
+	// cacheOff runs the command without the cache, the default for commands that may write
+	cacheOff cacheMode = iota
+	// cacheUse serves the result from the cache if present, and caches a new result
+	cacheUse
+	// cacheRefresh runs the command and replaces the cached result
+	cacheRefresh
+)
+
+// CacheOptions configures the result cache of read-only commands
+type CacheOptions struct {
+	// TTL is how long a result is served from the cache, 0 disables the cache
+	TTL time.Duration
+	// MaxBytes bounds the total size of the cached stdout and stderr; results larger
+	// than the bound are not cached. Defaults to DefaultCacheMaxBytes.
+	MaxBytes int64
+}
+
+// WithCache marks the commands run with the context as read-only, so successful results
+// are cached per caller. If refresh is true, cached results are ignored and replaced.
+func WithCache(ctx context.Context, refresh bool) context.Context {
+	mode := cacheUse
+	if refresh {
+		mode = cacheRefresh
+	}
+	return context.WithValue(ctx, cacheModeKey, mode)
+}
+
+// getCacheMode returns the result cache mode of the context
+func getCacheMode(ctx context.Context) cacheMode {
+	mode, _ := ctx.Value(cacheModeKey).(cacheMode)
+	return mode
+}
+
+// cacheEntry is a cached command result
+type cacheEntry struct {
+	key       string
+	namespace string
+	response  CommandResponse
+	size      int64
+	expires   time.Time
+}
+
+// resultCache is a TTL and size bounded LRU cache of command results
+type resultCache struct {
+	mu      sync.Mutex
+	opts    CacheOptions
+	entries map[string]*list.Element
+	// lru holds the entries, most recently used first
+	lru  *list.List
+	size int64
+	// gen counts invalidations, so a result read before one is not cached after it
+	gen uint64
+	now func() time.Time
+}
+
+// newResultCache creates a cache with opts
+func newResultCache(opts CacheOptions) *resultCache {
+	c := &resultCache{now: time.Now}
+	c.setOptions(opts)
+	return c
+}
+
+var commandCache = newResultCache(CacheOptions{})
+
+// SetCacheOptions sets the server-wide result cache options and empties the cache
+func SetCacheOptions(opts CacheOptions) {
+	commandCache.setOptions(opts)
+}
+
+// InvalidateCache drops the cached results of a namespace, of all namespaces and of the
+// default namespace. An empty namespace may be any namespace and drops every result.
+func InvalidateCache(namespace string) {
+	commandCache.invalidate(namespace)
+}
+
+// setOptions replaces the options and empties the cache
+func (c *resultCache) setOptions(opts CacheOptions) {
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultCacheMaxBytes
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.opts = opts
+	c.entries = make(map[string]*list.Element)
+	c.lru = list.New()
+	c.size = 0
+	c.gen++
+}
+
+// enabled reports whether results are cached
+func (c *resultCache) enabled() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.opts.TTL > 0
+}
+
+// generation returns the invalidation generation, taken before running a command to cache
+func (c *resultCache) generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.gen
+}
+
+// get returns the cached result of key, if it has not expired
+func (c *resultCache) get(key string) (CommandResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return CommandResponse{}, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !c.now().Before(entry.expires) {
+		c.remove(element)
+		return CommandResponse{}, false
+	}
+	c.lru.MoveToFront(element)
+	return entry.response, true
+}
+
+// put caches the result of key, evicting the least recently used results over the size bound.
+// A result read before an invalidation, at an older generation, may be stale and is dropped.
+func (c *resultCache) put(key, namespace string, generation uint64, response CommandResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.opts.TTL <= 0 || generation != c.gen {
+		return
+	}
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	size := int64(len(key) + len(response.Command) + len(response.Stdout) + len(response.Stderr))
+	if size > c.opts.MaxBytes {
+		return
+	}
+	for c.size+size > c.opts.MaxBytes {
+		c.remove(c.lru.Back())
+	}
+
+	entry := &cacheEntry{key: key, namespace: namespace, response: response, size: size, expires: c.now().Add(c.opts.TTL)}
+	c.entries[key] = c.lru.PushFront(entry)
+	c.size += size
+}
+
+// invalidate drops the results that may show resources of namespace
+func (c *resultCache) invalidate(namespace string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	for element := c.lru.Front(); element != nil; {
+		next := element.Next()
+		entryNamespace := element.Value.(*cacheEntry).namespace
+		if namespace == "" || entryNamespace == namespace || entryNamespace == "" || entryNamespace == allNamespaces {
+			c.remove(element)
+		}
+		element = next
+	}
+}
+
+// remove drops an entry; the caller holds c.mu
+func (c *resultCache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+}
+
+// cacheKey returns the cache key of a command: the caller identity and the redacted argv,
+// including the cluster and impersonation arguments, so callers never share results
+func cacheKey(ctx context.Context, name string, args []string) string {
+	identity := ""
+	if token, ok := GetKubeToken(ctx); ok && token != "" {
+		sum := sha256.Sum256([]byte(token))
+		identity = "token:" + hex.EncodeToString(sum[:])
+	}
+
+	fullArgs := kubeConfigFor(ctx).Args()
+	if impersonated, ok := GetImpersonation(ctx); ok {
+		fullArgs = append(fullArgs, impersonated.ImpersonationArgs()...)
+	}
+	fullArgs = append(fullArgs, args...)
+	return identity + "\x00" + formatShellCommand(name, fullArgs)
+}
+
+// commandNamespace returns the namespace a command reads, allNamespaces for
+// --all-namespaces, or "" for the default namespace
+func commandNamespace(args []string) string {
+	for i, arg := range args {
+		switch {
+		case arg == "--":
+			return ""
+		case arg == "-A" || arg == "--all-namespaces":
+			return allNamespaces
+		case (arg == "-n" || arg == "--namespace") && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(arg, "--namespace="):
+			return strings.TrimPrefix(arg, "--namespace=")
+		}
+	}
+	return ""
+}
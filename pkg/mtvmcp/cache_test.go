@@ -0,0 +1,203 @@
+package mtvmcp
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestResultCache(t *testing.T) {
+	now := time.Now()
+	c := newResultCache(CacheOptions{TTL: time.Minute, MaxBytes: 100})
+	c.now = func() time.Time { return now }
+
+	response := func(stdout string) CommandResponse {
+		return CommandResponse{Command: "c", Stdout: stdout}
+	}
+
+	c.put("a", "demo", c.generation(), response("first"))
+	if got, ok := c.get("a"); !ok || got.Stdout != "first" {
+		t.Fatalf("Expected a cached result, got %+v, %v", got, ok)
+	}
+
+	// Expired results are dropped
+	now = now.Add(time.Minute)
+	if _, ok := c.get("a"); ok || c.size != 0 {
+		t.Errorf("Expected the result to expire, size %d", c.size)
+	}
+
+	// The least recently used results are evicted over the size bound
+	c.put("a", "demo", c.generation(), response(string(make([]byte, 40))))
+	c.put("b", "demo", c.generation(), response(string(make([]byte, 40))))
+	c.get("a")
+	c.put("c", "other", c.generation(), response(string(make([]byte, 40))))
+	if _, ok := c.get("b"); ok {
+		t.Errorf("Expected b to be evicted")
+	}
+	if _, ok := c.get("a"); !ok {
+		t.Errorf("Expected a to be kept")
+	}
+
+	// Results larger than the bound are not cached
+	c.put("d", "demo", c.generation(), response(string(make([]byte, 200))))
+	if _, ok := c.get("d"); ok {
+		t.Errorf("Expected an oversized result not to be cached")
+	}
+}
+
+func TestResultCacheInvalidate(t *testing.T) {
+	tests := []struct {
+		namespace string
+		kept      []string
+	}{
+		{namespace: "demo", kept: []string{"other"}},
+		{namespace: "other", kept: []string{"demo"}},
+		{namespace: "", kept: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			c := newResultCache(CacheOptions{TTL: time.Minute})
+			for _, namespace := range []string{"demo", "other", "", allNamespaces} {
+				c.put(namespace, namespace, c.generation(), CommandResponse{})
+			}
+			c.invalidate(tt.namespace)
+			if len(c.entries) != len(tt.kept) {
+				t.Fatalf("Expected %v to be kept, got %d entries", tt.kept, len(c.entries))
+			}
+			for _, key := range tt.kept {
+				if _, ok := c.get(key); !ok {
+					t.Errorf("Expected %s to be kept", key)
+				}
+			}
+		})
+	}
+}
+
+// invalidatingExecutor invalidates the cache while a command runs, like a concurrent write
+type invalidatingExecutor struct {
+	next      Executor
+	namespace string
+}
+
+func (e invalidatingExecutor) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	InvalidateCache(e.namespace)
+	return e.next.Execute(ctx, cmd)
+}
+
+func TestResultCacheInvalidatedDuringRead(t *testing.T) {
+	c := newResultCache(CacheOptions{TTL: time.Minute})
+	generation := c.generation()
+	c.invalidate("other")
+	c.put("a", "demo", generation, CommandResponse{})
+	if _, ok := c.get("a"); ok {
+		t.Errorf("Expected a result read before an invalidation not to be cached")
+	}
+
+	// A command that finishes after a write invalidated the cache is not cached
+	setTestKubeconfig(t)
+	SetCacheOptions(CacheOptions{TTL: time.Minute})
+	t.Cleanup(func() { SetCacheOptions(CacheOptions{}) })
+	args := []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", args, FakeResponse{Stdout: "[]"})
+
+	racing := WithCache(WithExecutor(context.Background(), invalidatingExecutor{next: fake, namespace: "demo"}), false)
+	if _, err := RunKubectlMTVCommand(racing, args); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := RunKubectlMTVCommand(WithCache(WithExecutor(context.Background(), fake), false), args); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if calls := len(fake.Calls()); calls != 2 {
+		t.Errorf("Expected the stale result not to be served, got %d commands", calls)
+	}
+}
+
+func TestCommandNamespace(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}, "demo"},
+		{[]string{"get", "inventory", "provider", "-A", "-o", "json"}, allNamespaces},
+		{[]string{"get", "pods", "--namespace=demo"}, "demo"},
+		{[]string{"get", "plan", "-o", "json"}, ""},
+		{[]string{"get", "inventory", "vm", "--", "-n"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := commandNamespace(tt.args); got != tt.expected {
+			t.Errorf("commandNamespace(%q) = %q, expected %q", tt.args, got, tt.expected)
+		}
+	}
+}
+
+func TestRunCommandCache(t *testing.T) {
+	setTestKubeconfig(t)
+	SetCacheOptions(CacheOptions{TTL: time.Minute})
+	t.Cleanup(func() { SetCacheOptions(CacheOptions{}) })
+
+	args := []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "vsphere"}
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", args, FakeResponse{Stdout: "[]"})
+	fake.On("kubectl-mtv", []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "missing"}, FakeResponse{ExitCode: 1})
+	ctx := WithCache(WithExecutor(context.Background(), fake), false)
+
+	run := func(ctx context.Context, args []string) CommandResponse {
+		t.Helper()
+		result, err := RunKubectlMTVCommand(ctx, args)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		var response CommandResponse
+		if err := json.Unmarshal([]byte(result), &response); err != nil {
+			t.Fatal(err)
+		}
+		return response
+	}
+	expectCalls := func(expected int) {
+		t.Helper()
+		if calls := len(fake.Calls()); calls != expected {
+			t.Fatalf("Expected %d commands to run, got %d", expected, calls)
+		}
+	}
+
+	if response := run(ctx, args); response.Cached {
+		t.Errorf("Expected the first result not to be cached")
+	}
+	if response := run(ctx, args); !response.Cached || response.Stdout != "[]" {
+		t.Errorf("Expected a cached result, got %+v", response)
+	}
+	expectCalls(1)
+
+	// Other tokens, refreshes and contexts without the cache run the command
+	tokenCtx := WithKubeToken(ctx, "token")
+	run(tokenCtx, args)
+	run(WithKubeToken(ctx, "other"), args)
+	if response := run(tokenCtx, args); !response.Cached {
+		t.Errorf("Expected a cached result for the same token")
+	}
+	expectCalls(3)
+	if response := run(WithCache(ctx, true), args); response.Cached {
+		t.Errorf("Expected a refreshed result")
+	}
+	expectCalls(4)
+	run(WithExecutor(context.Background(), fake), args)
+	expectCalls(5)
+
+	// Failed commands are not cached
+	missing := []string{"get", "inventory", "vm", "-n", "demo", "-o", "json", "--", "missing"}
+	run(ctx, missing)
+	run(ctx, missing)
+	expectCalls(7)
+
+	// Invalidating the namespace drops the result
+	InvalidateCache("other")
+	run(ctx, args)
+	expectCalls(7)
+	InvalidateCache("demo")
+	run(ctx, args)
+	expectCalls(8)
+}
@@ -0,0 +1,180 @@
+package mtvmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// clusterKey is the context key for the cluster selected for a tool call
+const clusterKey contextKey = "cluster"
+
+// Cluster is a named target cluster of the cluster registry
+type Cluster struct {
+	// Name identifies the cluster in the cluster tool input
+	Name string `json:"name"`
+	// Kubeconfig is the kubeconfig file of the cluster (optional, defaults to the server kubeconfig)
+	Kubeconfig string `json:"kubeconfig,omitempty"`
+	// Context is the kubeconfig context of the cluster
+	Context string `json:"context,omitempty"`
+	// Server is the API server address of the cluster (optional)
+	Server string `json:"server,omitempty"`
+	// InventoryURL is the MTV inventory service URL of the cluster (optional, auto-discovered if not set)
+	InventoryURL string `json:"inventoryURL,omitempty"`
+	// Description is a human readable description of the cluster
+	Description string `json:"description,omitempty"`
+}
+
+// ClusterRegistry maps cluster names to kubeconfig contexts and inventory URLs
+type ClusterRegistry struct {
+	// Default is the cluster used when a tool call does not name one (optional)
+	Default  string    `json:"default,omitempty"`
+	Clusters []Cluster `json:"clusters"`
+}
+
+var (
+	clusterRegistryMu sync.RWMutex
+	clusterRegistry   *ClusterRegistry
+)
+
+// LoadClusterRegistry loads a cluster registry from a YAML or JSON file, for example:
+//
+//	default: prod
+//	clusters:
+//	- name: prod
+//	  context: prod-admin
+//	  inventoryURL: https://forklift-inventory.apps.prod.example.com
+//	- name: lab
+//	  kubeconfig: /home/user/.kube/lab.yaml
+//	  context: lab
+func LoadClusterRegistry(path string) (*ClusterRegistry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster registry: %w", err)
+	}
+
+	var registry ClusterRegistry
+	if err := yaml.UnmarshalStrict(data, &registry); err != nil {
+		return nil, fmt.Errorf("failed to parse cluster registry: %w", err)
+	}
+
+	if len(registry.Clusters) == 0 {
+		return nil, fmt.Errorf("cluster registry %s defines no clusters", path)
+	}
+	seen := make(map[string]bool)
+	for _, cluster := range registry.Clusters {
+		if cluster.Name == "" {
+			return nil, fmt.Errorf("cluster registry: cluster without a name")
+		}
+		if seen[cluster.Name] {
+			return nil, fmt.Errorf("cluster registry: duplicate cluster '%s'", cluster.Name)
+		}
+		if cluster.Context == "" && cluster.Server == "" {
+			return nil, fmt.Errorf("cluster registry: cluster '%s' needs a context or a server", cluster.Name)
+		}
+		seen[cluster.Name] = true
+	}
+	if registry.Default != "" && !seen[registry.Default] {
+		return nil, fmt.Errorf("cluster registry: default cluster '%s' is not defined", registry.Default)
+	}
+
+	return &registry, nil
+}
+
+// Names returns the sorted names of the registered clusters
+func (r *ClusterRegistry) Names() []string {
+	var names []string
+	for _, cluster := range r.Clusters {
+		names = append(names, cluster.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the cluster with the given name
+func (r *ClusterRegistry) Get(name string) (Cluster, bool) {
+	for _, cluster := range r.Clusters {
+		if cluster.Name == name {
+			return cluster, true
+		}
+	}
+	return Cluster{}, false
+}
+
+// SetClusterRegistry sets the server-wide cluster registry, nil disables cluster selection
+func SetClusterRegistry(registry *ClusterRegistry) {
+	clusterRegistryMu.Lock()
+	defer clusterRegistryMu.Unlock()
+	clusterRegistry = registry
+}
+
+// GetClusterRegistry returns the server-wide cluster registry, or nil if none is loaded
+func GetClusterRegistry() *ClusterRegistry {
+	clusterRegistryMu.RLock()
+	defer clusterRegistryMu.RUnlock()
+	return clusterRegistry
+}
+
+// WithCluster routes every command run with the returned context to the named
+// cluster of the registry. An empty name selects the registry default cluster,
+// or keeps the server kubeconfig when there is no default.
+func WithCluster(ctx context.Context, name string) (context.Context, error) {
+	registry := GetClusterRegistry()
+	if name == "" {
+		if registry == nil || registry.Default == "" {
+			return ctx, nil
+		}
+		name = registry.Default
+	}
+
+	if registry == nil {
+		return ctx, clusterValidationError(fmt.Sprintf("Unknown cluster '%s': no cluster registry is configured", name), nil)
+	}
+	cluster, ok := registry.Get(name)
+	if !ok {
+		return ctx, clusterValidationError(fmt.Sprintf("Unknown cluster '%s'. Valid clusters: %s", name, strings.Join(registry.Names(), ", ")), registry.Names())
+	}
+	return context.WithValue(ctx, clusterKey, cluster), nil
+}
+
+// GetCluster retrieves the cluster selected with WithCluster from the context
+func GetCluster(ctx context.Context) (Cluster, bool) {
+	if ctx == nil {
+		return Cluster{}, false
+	}
+	cluster, ok := ctx.Value(clusterKey).(Cluster)
+	return cluster, ok
+}
+
+// ResolveInventoryURL returns inventoryURL, or the inventory URL of the selected
+// cluster when inventoryURL is empty
+func ResolveInventoryURL(ctx context.Context, inventoryURL string) string {
+	if inventoryURL != "" {
+		return inventoryURL
+	}
+	cluster, _ := GetCluster(ctx)
+	return cluster.InventoryURL
+}
+
+// clusterValidationError returns an unknown cluster error in the ValidationError format
+func clusterValidationError(message string, valid []string) error {
+	validationErr := struct {
+		Error    string   `json:"error"`
+		Type     string   `json:"type"`
+		Message  string   `json:"message"`
+		Clusters []string `json:"valid_clusters,omitempty"`
+	}{
+		Error:    "validation_error",
+		Type:     "unknown_cluster",
+		Message:  message,
+		Clusters: valid,
+	}
+	jsonData, _ := json.MarshalIndent(validationErr, "", "  ")
+	return fmt.Errorf("%s", string(jsonData))
+}
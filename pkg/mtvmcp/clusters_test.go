@@ -0,0 +1,129 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadClusterRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{
+			name: "valid YAML",
+			config: `default: prod
+clusters:
+- name: prod
+  context: prod-admin
+  inventoryURL: https://inventory.prod.example.com
+- name: lab
+  kubeconfig: /tmp/lab.yaml
+  context: lab
+`,
+		},
+		{
+			name:   "valid JSON",
+			config: `{"clusters": [{"name": "prod", "server": "https://api.prod.example.com:6443"}]}`,
+		},
+		{
+			name:    "no clusters",
+			config:  `clusters: []`,
+			wantErr: "defines no clusters",
+		},
+		{
+			name:    "duplicate name",
+			config:  "clusters:\n- name: prod\n  context: a\n- name: prod\n  context: b\n",
+			wantErr: "duplicate cluster 'prod'",
+		},
+		{
+			name:    "no context or server",
+			config:  "clusters:\n- name: prod\n",
+			wantErr: "needs a context or a server",
+		},
+		{
+			name:    "unknown default",
+			config:  "default: lab\nclusters:\n- name: prod\n  context: prod\n",
+			wantErr: "default cluster 'lab' is not defined",
+		},
+		{
+			name:    "unknown field",
+			config:  "clusters:\n- name: prod\n  contxt: prod\n",
+			wantErr: "failed to parse",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "clusters.yaml")
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := LoadClusterRegistry(path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWithCluster(t *testing.T) {
+	t.Cleanup(func() { SetClusterRegistry(nil) })
+
+	SetClusterRegistry(nil)
+	ctx, err := WithCluster(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := GetCluster(ctx); ok {
+		t.Errorf("Expected no cluster without a registry")
+	}
+	if _, err := WithCluster(context.Background(), "prod"); err == nil {
+		t.Errorf("Expected an error for a named cluster without a registry")
+	}
+
+	SetClusterRegistry(&ClusterRegistry{
+		Default: "prod",
+		Clusters: []Cluster{
+			{Name: "prod", Context: "prod-admin", InventoryURL: "https://inventory.prod.example.com"},
+			{Name: "lab", Kubeconfig: "/tmp/lab.yaml", Server: "https://api.lab.example.com:6443"},
+		},
+	})
+
+	ctx, err = WithCluster(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if cluster, _ := GetCluster(ctx); cluster.Name != "prod" {
+		t.Errorf("Expected the default cluster prod, got %q", cluster.Name)
+	}
+	if url := ResolveInventoryURL(ctx, ""); url != "https://inventory.prod.example.com" {
+		t.Errorf("Expected the cluster inventory URL, got %q", url)
+	}
+	if got := strings.Join(kubeConfigFor(ctx).Args(), " "); got != "--context prod-admin" {
+		t.Errorf("Unexpected kubectl flags for prod: %s", got)
+	}
+
+	ctx, err = WithCluster(context.Background(), "lab")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if got := strings.Join(kubeConfigFor(ctx).Args(), " "); got != "--kubeconfig /tmp/lab.yaml --server https://api.lab.example.com:6443" {
+		t.Errorf("Unexpected kubectl flags for lab: %s", got)
+	}
+
+	_, err = WithCluster(context.Background(), "missing")
+	if err == nil || !strings.Contains(err.Error(), `"unknown_cluster"`) || !strings.Contains(err.Error(), "lab, prod") {
+		t.Errorf("Expected an unknown_cluster error listing the clusters, got %v", err)
+	}
+}
@@ -1,12 +1,13 @@
 package mtvmcp
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"math"
 	"strings"
+	"sync"
 	"time"
 
 	shellquote "github.com/kballard/go-shellquote"
@@ -20,8 +21,32 @@ const (
 	kubeconfigTokenKey contextKey = "kubeconfig_token"
 	// dryRunKey is the context key for dry run mode
 	dryRunKey contextKey = "dry_run"
+	// commandTimeoutKey is the context key for the per-call command timeout
+	commandTimeoutKey contextKey = "command_timeout"
 )
 
+// DefaultCommandTimeout is the default timeout for a single kubectl/kubectl-mtv command
+const DefaultCommandTimeout = 120 * time.Second
+
+var (
+	defaultTimeoutMu sync.RWMutex
+	defaultTimeout   = DefaultCommandTimeout
+)
+
+// SetDefaultTimeout sets the server-wide timeout for a single command, 0 disables the timeout
+func SetDefaultTimeout(timeout time.Duration) {
+	defaultTimeoutMu.Lock()
+	defer defaultTimeoutMu.Unlock()
+	defaultTimeout = timeout
+}
+
+// getDefaultTimeout returns the server-wide timeout for a single command
+func getDefaultTimeout() time.Duration {
+	defaultTimeoutMu.RLock()
+	defer defaultTimeoutMu.RUnlock()
+	return defaultTimeout
+}
+
 // WithKubeToken adds a Kubernetes token to the context
 func WithKubeToken(ctx context.Context, token string) context.Context {
 	return context.WithValue(ctx, kubeconfigTokenKey, token)
@@ -50,12 +75,42 @@ func GetDryRun(ctx context.Context) bool {
 	return ok && dryRun
 }
 
+// CallTimeout returns the timeout of a tool call that asks for seconds. The server-wide
+// timeout is an upper bound, so a caller can only shorten it.
+func CallTimeout(seconds int) time.Duration {
+	timeout := time.Duration(math.MaxInt64)
+	if seconds < int(timeout/time.Second) {
+		timeout = time.Duration(seconds) * time.Second
+	}
+	if serverTimeout := getDefaultTimeout(); serverTimeout > 0 && timeout > serverTimeout {
+		timeout = serverTimeout
+	}
+	return timeout
+}
+
+// WithCommandTimeout bounds the tool call by timeout. Every command run with the
+// returned context uses this timeout instead of the server-wide default.
+func WithCommandTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx = context.WithValue(ctx, commandTimeoutKey, timeout)
+	return context.WithTimeout(ctx, timeout)
+}
+
+// GetCommandTimeout retrieves the per-call command timeout from the context
+func GetCommandTimeout(ctx context.Context) (time.Duration, bool) {
+	if ctx == nil {
+		return 0, false
+	}
+	timeout, ok := ctx.Value(commandTimeoutKey).(time.Duration)
+	return timeout, ok
+}
+
 // CommandResponse represents the structured response from command execution
 type CommandResponse struct {
 	Command     string `json:"command"`
 	ReturnValue int    `json:"return_value"`
 	Stdout      string `json:"stdout"`
 	Stderr      string `json:"stderr"`
+	Cached      bool   `json:"cached,omitempty"`
 }
 
 // ValidationError represents a structured validation error
@@ -162,25 +217,47 @@ func ValidateNetworkPairs(pairsStr string) error {
 
 // RunKubectlMTVCommand executes a kubectl-mtv command and returns structured JSON
 // It accepts a context which may contain a Kubernetes token for authentication.
-// If a token is present in the context, it will be passed via the --token flag.
+// If a token is present in the context, it is passed through a temporary kubeconfig,
+// never on the command line.
 // If no token is present, it falls back to the default kubeconfig behavior.
+// If the context carries an impersonated user, the command runs with --as/--as-group.
 // If dry run mode is enabled in the context, it returns a teaching response instead of executing.
+// If the context is marked with WithCache, a recent successful result may be served from the cache.
+// The command is killed when the context is cancelled or the command timeout expires.
 func RunKubectlMTVCommand(ctx context.Context, args []string) (string, error) {
-	// Check if we have a token in the context and prepend --token flag
-	if token, ok := GetKubeToken(ctx); ok && token != "" {
-		// Insert --token flag at the beginning of args (after subcommand if present)
-		// This ensures it's processed before any other flags
-		args = append([]string{"--token", token}, args...)
-	}
+	return runCommand(ctx, "kubectl-mtv", args, "")
+}
+
+// RunKubectlCommand executes a kubectl command and returns structured JSON
+// It accepts a context which may contain a Kubernetes token for authentication.
+// If a token is present in the context, it is passed through a temporary kubeconfig,
+// never on the command line.
+// If no token is present, it falls back to the default kubeconfig behavior.
+// If the context carries an impersonated user, the command runs with --as/--as-group.
+// If dry run mode is enabled in the context, it returns a teaching response instead of executing.
+// If the context is marked with WithCache, a recent successful result may be served from the cache.
+// The command is killed when the context is cancelled or the command timeout expires.
+func RunKubectlCommand(ctx context.Context, args []string) (string, error) {
+	return runCommand(ctx, "kubectl", args, "")
+}
 
+// RunKubectlCommandWithStdin executes a kubectl command with stdin and returns structured JSON.
+// It behaves like RunKubectlCommand; stdin is never echoed, recorded or audited, so it can
+// carry data that must stay off the command line, such as a Secret manifest for "apply -f -".
+func RunKubectlCommandWithStdin(ctx context.Context, args []string, stdin string) (string, error) {
+	return runCommand(ctx, "kubectl", args, stdin)
+}
+
+// runCommand executes a kubectl or kubectl-mtv command with stdin and returns structured JSON
+func runCommand(ctx context.Context, name string, args []string, stdin string) (string, error) {
 	// Check if we're in dry run mode
 	if GetDryRun(ctx) {
 		// In dry run mode, just return the command that would be executed
 		// The AI will explain it in context
 		response := CommandResponse{
-			Command:     formatShellCommand("kubectl-mtv", args),
+			Command:     formatShellCommand(name, args),
 			ReturnValue: 0,
-			Stdout:      formatShellCommand("kubectl-mtv", args),
+			Stdout:      formatShellCommand(name, args),
 			Stderr:      "",
 		}
 
@@ -192,110 +269,111 @@ func RunKubectlMTVCommand(ctx context.Context, args []string) (string, error) {
 		return string(jsonData), nil
 	}
 
-	cmd := exec.Command("kubectl-mtv", args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	// Set timeout of 120 seconds
-	timer := time.AfterFunc(120*time.Second, func() {
-		_ = cmd.Process.Kill()
-	})
-	defer timer.Stop()
-
-	err := cmd.Run()
-
-	response := CommandResponse{
-		Command: formatShellCommand("kubectl-mtv", args),
-		Stdout:  stdout.String(),
-		Stderr:  stderr.String(),
-	}
-
-	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			response.ReturnValue = exitErr.ExitCode()
-		} else {
-			response.ReturnValue = -1
-			if response.Stderr == "" {
-				response.Stderr = err.Error()
+	// Serve read-only commands from the cache, before they count against the limits
+	var key string
+	var generation uint64
+	if mode := getCacheMode(ctx); mode != cacheOff && stdin == "" && commandCache.enabled() {
+		key = cacheKey(ctx, name, args)
+		generation = commandCache.generation()
+		if response, ok := commandCache.get(key); ok && mode == cacheUse {
+			response.Cached = true
+			jsonData, err := json.MarshalIndent(response, "", "  ")
+			if err != nil {
+				return "", fmt.Errorf("failed to marshal response: %w", err)
 			}
+			return string(jsonData), nil
 		}
-	} else {
-		response.ReturnValue = 0
 	}
 
-	jsonData, err := json.MarshalIndent(response, "", "  ")
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal response: %w", err)
+	// Bound the command by the per-call timeout, or the server-wide default
+	timeout := getDefaultTimeout()
+	if callTimeout, ok := GetCommandTimeout(ctx); ok {
+		timeout = callTimeout
 	}
-
-	return string(jsonData), nil
-}
-
-// RunKubectlCommand executes a kubectl command and returns structured JSON
-// It accepts a context which may contain a Kubernetes token for authentication.
-// If a token is present in the context, it will be passed via the --token flag.
-// If no token is present, it falls back to the default kubeconfig behavior.
-// If dry run mode is enabled in the context, it returns a teaching response instead of executing.
-func RunKubectlCommand(ctx context.Context, args []string) (string, error) {
-	// Check if we have a token in the context and prepend --token flag
-	if token, ok := GetKubeToken(ctx); ok && token != "" {
-		// Insert --token flag at the beginning of args (after subcommand if present)
-		// This ensures it's processed before any other flags
-		args = append([]string{"--token", token}, args...)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	// Check if we're in dry run mode
-	if GetDryRun(ctx) {
-		// In dry run mode, just return the command that would be executed
-		// The AI will explain it in context
-		response := CommandResponse{
-			Command:     formatShellCommand("kubectl", args),
-			ReturnValue: 0,
-			Stdout:      formatShellCommand("kubectl", args),
-			Stderr:      "",
+	// Refuse the command if the caller exceeds its rate limit or the server is saturated
+	serverCommand := isServerCommand(ctx)
+	if !serverCommand {
+		release, err := limiter.acquire(ctx, getRateLimitKey(ctx))
+		if err != nil {
+			return "", err
 		}
+		defer release()
+	}
 
-		jsonData, err := json.MarshalIndent(response, "", "  ")
+	command := Command{Name: name, Args: args, Stdin: stdin}
+
+	// Pass the request token through a temporary kubeconfig, so it is not
+	// visible in the process list. The temporary kubeconfig already selects the
+	// configured kubeconfig and context, so only the server override is passed.
+	// Impersonated requests run with the server credentials instead of the token.
+	kubeConfig := kubeConfigFor(ctx)
+	impersonated, impersonating := GetImpersonation(ctx)
+	if token, ok := GetKubeToken(ctx); ok && token != "" && !impersonating {
+		env, cleanup, err := writeTokenKubeconfig(token, kubeConfig)
 		if err != nil {
-			return "", fmt.Errorf("failed to marshal response: %w", err)
+			return "", err
 		}
-
-		return string(jsonData), nil
+		defer cleanup()
+		command.Env = env
+		kubeConfig = KubeConfig{Server: kubeConfig.Server}
+	}
+	kubeArgs := kubeConfig.Args()
+	if impersonating {
+		kubeArgs = append(kubeArgs, impersonated.ImpersonationArgs()...)
+	}
+	if len(kubeArgs) > 0 {
+		command.Args = append(kubeArgs, args...)
 	}
 
-	cmd := exec.Command("kubectl", args...)
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	if !serverCommand {
+		subprocessesInFlight.add(1)
+		defer subprocessesInFlight.add(-1)
+	}
+	untrack := trackRunningCommand(formatShellCommand(name, command.Args))
+	start := time.Now()
+	result, err := GetExecutor(ctx).Execute(ctx, command)
+	untrack()
 
-	// Set timeout of 120 seconds
-	timer := time.AfterFunc(120*time.Second, func() {
-		_ = cmd.Process.Kill()
-	})
-	defer timer.Stop()
+	response := CommandResponse{
+		Command:     formatShellCommand(name, command.Args),
+		ReturnValue: result.ExitCode,
+		Stdout:      redactOutput(result.Stdout),
+		Stderr:      redactOutput(result.Stderr),
+	}
 
-	err := cmd.Run()
+	// A command that did not run to completion is recorded with exit code -1,
+	// so the audit log shows writes that may have partly reached the cluster
+	recordResult := func(exitCode int) {
+		if !serverCommand {
+			recordSubprocess(name, exitCode, time.Since(start))
+		}
+		recordAuditCommand(ctx, response.Command, exitCode)
+	}
 
-	response := CommandResponse{
-		Command: formatShellCommand("kubectl", args),
-		Stdout:  stdout.String(),
-		Stderr:  stderr.String(),
+	// A cancelled request (client cancellation or disconnect) is not a command result
+	if errors.Is(ctx.Err(), context.Canceled) {
+		recordResult(-1)
+		return "", fmt.Errorf("command cancelled: %s: %w", response.Command, ctx.Err())
 	}
 
 	if err != nil {
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			response.ReturnValue = exitErr.ExitCode()
-		} else {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
 			response.ReturnValue = -1
-			if response.Stderr == "" {
-				response.Stderr = err.Error()
-			}
+			response.Stderr = strings.TrimSpace(response.Stderr + "\n" + fmt.Sprintf("command timed out after %s", timeout))
+		} else {
+			recordResult(-1)
+			return "", fmt.Errorf("failed to run command %s: %w", response.Command, err)
 		}
-	} else {
-		response.ReturnValue = 0
+	}
+	recordResult(response.ReturnValue)
+	if key != "" && response.ReturnValue == 0 {
+		commandCache.put(key, commandNamespace(args), generation, response)
 	}
 
 	jsonData, err := json.MarshalIndent(response, "", "  ")
@@ -311,21 +389,29 @@ func RunKubectlCommand(ctx context.Context, args []string) (string, error) {
 // Note: This is for display/logging only. Actual command execution uses exec.Command()
 // which handles arguments directly without shell interpretation.
 func formatShellCommand(cmd string, args []string) string {
+	// Use shellquote.Join to properly quote all arguments
+	quotedArgs := shellquote.Join(sanitizeArgs(args)...)
+	return cmd + " " + quotedArgs
+}
+
+// sanitizeArgs returns a copy of args with the values of sensitive flags replaced by ****
+func sanitizeArgs(args []string) []string {
 	// Sensitive flags that should have their values redacted
-	sensitiveFlags := map[string]bool{
-		"--password": true,
-		"--token":    true,
-	}
+	sensitiveFlags := getRedactor().flags
 
 	// Build the display command with sanitization
 	sanitizedArgs := []string{}
 	sanitizeNext := false
 
 	for _, arg := range args {
+		flag, _, hasValue := strings.Cut(arg, "=")
 		if sanitizeNext {
 			// Replace sensitive value with ****
-			sanitizedArgs = append(sanitizedArgs, "****")
+			sanitizedArgs = append(sanitizedArgs, RedactedValue)
 			sanitizeNext = false
+		} else if sensitiveFlags[flag] && hasValue {
+			// A sensitive flag with an inline value
+			sanitizedArgs = append(sanitizedArgs, flag+"="+RedactedValue)
 		} else if sensitiveFlags[arg] {
 			// This is a sensitive flag, add it and mark next arg for sanitization
 			sanitizedArgs = append(sanitizedArgs, arg)
@@ -336,9 +422,7 @@ func formatShellCommand(cmd string, args []string) string {
 		}
 	}
 
-	// Use shellquote.Join to properly quote all arguments
-	quotedArgs := shellquote.Join(sanitizedArgs...)
-	return cmd + " " + quotedArgs
+	return sanitizedArgs
 }
 
 // ExtractStdoutFromResponse extracts stdout from a structured JSON response
@@ -384,6 +468,8 @@ func BuildBaseArgs(namespace string, allNamespaces bool) []string {
 
 // UnmarshalJSONResponse unmarshals a JSON string response into a native object
 // This is needed because the MCP SDK expects a native object, not a JSON string
+//
+// Deprecated: use ParseCommandOutput, which returns a typed CommandOutput.
 func UnmarshalJSONResponse(responseJSON string) (map[string]interface{}, error) {
 	var result map[string]interface{}
 	if err := json.Unmarshal([]byte(responseJSON), &result); err != nil {
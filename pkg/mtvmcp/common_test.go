@@ -1,9 +1,12 @@
 package mtvmcp
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestValidateNetworkPairs(t *testing.T) {
@@ -158,3 +161,48 @@ func TestValidateNetworkPairsWhitespace(t *testing.T) {
 		})
 	}
 }
+
+func TestRunningCommands(t *testing.T) {
+	setTestKubeconfig(t)
+	var running []string
+	ctx := WithExecutor(context.Background(), executorFunc(func(ctx context.Context, cmd Command) (ExecResult, error) {
+		running = RunningCommands()
+		return ExecResult{}, nil
+	}))
+
+	if _, err := RunKubectlMTVCommand(ctx, []string{"create", "provider", "--password", "secret"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(running) != 1 || running[0] != `kubectl-mtv create provider --password \*\*\*\*` {
+		t.Errorf("Expected the sanitized running command, got %v", running)
+	}
+	if after := RunningCommands(); len(after) != 0 {
+		t.Errorf("Expected no running commands after the command returned, got %v", after)
+	}
+}
+
+func TestCallTimeout(t *testing.T) {
+	t.Cleanup(func() { SetDefaultTimeout(DefaultCommandTimeout) })
+
+	tests := []struct {
+		name          string
+		serverTimeout time.Duration
+		seconds       int
+		expected      time.Duration
+	}{
+		{name: "shorter than the server timeout", serverTimeout: time.Minute, seconds: 10, expected: 10 * time.Second},
+		{name: "capped at the server timeout", serverTimeout: time.Minute, seconds: 3600, expected: time.Minute},
+		{name: "overflowing value capped", serverTimeout: time.Minute, seconds: math.MaxInt, expected: time.Minute},
+		{name: "no server timeout", serverTimeout: 0, seconds: 3600, expected: time.Hour},
+		{name: "overflowing value without server timeout", serverTimeout: 0, seconds: math.MaxInt, expected: time.Duration(math.MaxInt64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetDefaultTimeout(tt.serverTimeout)
+			if got := CallTimeout(tt.seconds); got != tt.expected {
+				t.Errorf("CallTimeout(%d) = %v, expected %v", tt.seconds, got, tt.expected)
+			}
+		})
+	}
+}
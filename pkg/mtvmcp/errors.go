@@ -0,0 +1,102 @@
+package mtvmcp
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// Command error types
+const (
+	ErrorTypeNotFound             = "not_found"
+	ErrorTypeForbidden            = "forbidden"
+	ErrorTypeUnauthorized         = "unauthorized"
+	ErrorTypeAlreadyExists        = "already_exists"
+	ErrorTypeConflict             = "conflict"
+	ErrorTypeInventoryUnavailable = "inventory_unavailable"
+	ErrorTypeTimeout              = "timeout"
+	ErrorTypeValidationFailed     = "validation_failed"
+	ErrorTypeCommandFailed        = "command_failed"
+)
+
+// errorHints are short remediation hints for each command error type
+var errorHints = map[string]string{
+	ErrorTypeNotFound:             "Check the resource name and namespace. Use ListResources or ListInventory to find existing resources.",
+	ErrorTypeForbidden:            "The caller lacks RBAC permissions for this action. Ask a cluster admin to grant access, or use a token with the required role.",
+	ErrorTypeUnauthorized:         "Authentication failed. Check that the token or kubeconfig credentials are valid and not expired.",
+	ErrorTypeAlreadyExists:        "A resource with this name already exists. Choose a different name, or patch the existing resource.",
+	ErrorTypeConflict:             "The resource was modified concurrently. Fetch the latest version and retry.",
+	ErrorTypeInventoryUnavailable: "The MTV inventory service is not reachable. Check the forklift-controller inventory container with GetLogs, or pass inventory_url.",
+	ErrorTypeTimeout:              "The command did not finish in time. Retry with a larger timeout_seconds, or narrow the query.",
+	ErrorTypeValidationFailed:     "The request was rejected as invalid. Check the parameter values against the tool description.",
+	ErrorTypeCommandFailed:        "Check stderr for details.",
+}
+
+// errorPatterns classifies stderr, the first matching pattern wins
+var errorPatterns = []struct {
+	errorType string
+	pattern   *regexp.Regexp
+}{
+	{ErrorTypeTimeout, regexp.MustCompile(`(?i)command timed out|context deadline exceeded|\(Timeout\)|timed out waiting`)},
+	{ErrorTypeInventoryUnavailable, regexp.MustCompile(`(?i)inventory.*(connection refused|no such host|unavailable|timeout|EOF|status 50[234]|failed to (get|fetch|connect)|could not|unable to)|(route|service).*inventory.*not found`)},
+	{ErrorTypeUnauthorized, regexp.MustCompile(`(?i)\(Unauthorized\)|\bunauthorized\b|must be logged in|provide credentials`)},
+	{ErrorTypeForbidden, regexp.MustCompile(`(?i)\(Forbidden\)|\bis forbidden\b|\bforbidden:`)},
+	{ErrorTypeAlreadyExists, regexp.MustCompile(`(?i)\(AlreadyExists\)|already exists`)},
+	{ErrorTypeConflict, regexp.MustCompile(`(?i)\(Conflict\)|the object has been modified`)},
+	{ErrorTypeNotFound, regexp.MustCompile(`(?i)\(NotFound\)|not found|doesn't have a resource type`)},
+	{ErrorTypeValidationFailed, regexp.MustCompile(`(?i)\(Invalid\)|\bis invalid\b|admission webhook|denied the request|unknown flag|unknown command|invalid argument|required flag|validation failed|\binvalid\b`)},
+}
+
+// CommandError represents a kubectl or kubectl-mtv command that exited with an error,
+// classified by its stderr. Its message is a JSON document, like ValidationError.
+type CommandError struct {
+	Type        string `json:"type"`
+	Message     string `json:"message"`
+	Hint        string `json:"hint"`
+	Command     string `json:"command"`
+	ReturnValue int    `json:"return_value"`
+	Stderr      string `json:"stderr,omitempty"`
+}
+
+// Error returns the error as a JSON document
+func (e *CommandError) Error() string {
+	type body CommandError
+	jsonData, _ := json.MarshalIndent(struct {
+		Error string `json:"error"`
+		*body
+	}{Error: "command_error", body: (*body)(e)}, "", "  ")
+	return string(jsonData)
+}
+
+// ClassifyStderr returns the error type of a failed command from its stderr
+func ClassifyStderr(stderr string) string {
+	for _, p := range errorPatterns {
+		if p.pattern.MatchString(stderr) {
+			return p.errorType
+		}
+	}
+	return ErrorTypeCommandFailed
+}
+
+// NewCommandError builds a classified CommandError for a failed command
+func NewCommandError(command string, returnValue int, stderr string) *CommandError {
+	errorType := ClassifyStderr(stderr)
+
+	// Use the first non-empty stderr line as the message
+	message := "command failed"
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			message = line
+			break
+		}
+	}
+
+	return &CommandError{
+		Type:        errorType,
+		Message:     message,
+		Hint:        errorHints[errorType],
+		Command:     command,
+		ReturnValue: returnValue,
+		Stderr:      strings.TrimSpace(stderr),
+	}
+}
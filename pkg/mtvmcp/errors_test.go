@@ -0,0 +1,96 @@
+package mtvmcp
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestClassifyStderr(t *testing.T) {
+	tests := []struct {
+		name     string
+		stderr   string
+		expected string
+	}{
+		{
+			name:     "resource not found",
+			stderr:   `Error from server (NotFound): plans.forklift.konveyor.io "my-plan" not found`,
+			expected: ErrorTypeNotFound,
+		},
+		{
+			name:     "kubectl-mtv not found",
+			stderr:   "Error: provider 'vsphere' not found in namespace 'demo'",
+			expected: ErrorTypeNotFound,
+		},
+		{
+			name:     "forbidden",
+			stderr:   `Error from server (Forbidden): plans.forklift.konveyor.io is forbidden: User "dev" cannot list resource "plans"`,
+			expected: ErrorTypeForbidden,
+		},
+		{
+			name:     "unauthorized",
+			stderr:   "error: You must be logged in to the server (Unauthorized)",
+			expected: ErrorTypeUnauthorized,
+		},
+		{
+			name:     "already exists",
+			stderr:   `Error from server (AlreadyExists): providers.forklift.konveyor.io "vsphere" already exists`,
+			expected: ErrorTypeAlreadyExists,
+		},
+		{
+			name:     "conflict",
+			stderr:   `Operation cannot be fulfilled on plans.forklift.konveyor.io "my-plan": the object has been modified; please apply your changes to the latest version and try again`,
+			expected: ErrorTypeConflict,
+		},
+		{
+			name:     "inventory unavailable",
+			stderr:   `Error: failed to fetch inventory: Get "https://inventory.openshift-mtv.svc:8443/providers": dial tcp 10.0.0.1:8443: connect: connection refused`,
+			expected: ErrorTypeInventoryUnavailable,
+		},
+		{
+			name:     "timeout",
+			stderr:   "command timed out after 2m0s",
+			expected: ErrorTypeTimeout,
+		},
+		{
+			name:     "admission webhook",
+			stderr:   `Error from server: admission webhook "validate.plan" denied the request: target namespace is required`,
+			expected: ErrorTypeValidationFailed,
+		},
+		{
+			name:     "unknown flag",
+			stderr:   "Error: unknown flag: --bogus",
+			expected: ErrorTypeValidationFailed,
+		},
+		{
+			name:     "unclassified",
+			stderr:   "panic: runtime error",
+			expected: ErrorTypeCommandFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyStderr(tt.stderr); got != tt.expected {
+				t.Errorf("ClassifyStderr(%q) = %s, expected %s", tt.stderr, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCommandErrorJSON(t *testing.T) {
+	err := NewCommandError("kubectl-mtv get plan x -o json", 1, "\nError from server (NotFound): plans \"x\" not found\n")
+
+	var decoded map[string]interface{}
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &decoded); jsonErr != nil {
+		t.Fatalf("Error message is not valid JSON: %v", jsonErr)
+	}
+	if decoded["error"] != "command_error" || decoded["type"] != ErrorTypeNotFound {
+		t.Errorf("Unexpected error JSON: %v", decoded)
+	}
+	if decoded["message"] != `Error from server (NotFound): plans "x" not found` {
+		t.Errorf("Expected the first stderr line as message, got %v", decoded["message"])
+	}
+	if decoded["hint"] == "" {
+		t.Errorf("Expected a remediation hint")
+	}
+}
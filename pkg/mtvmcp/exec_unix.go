@@ -0,0 +1,21 @@
+//go:build !windows
+
+package mtvmcp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// configureProcessTree starts the command in its own process group and kills
+// the whole group when the command context is done
+func configureProcessTree(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		// A negative pid signals every process in the group
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
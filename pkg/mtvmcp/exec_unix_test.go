@@ -0,0 +1,67 @@
+//go:build !windows
+
+package mtvmcp
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installFakeKubectl puts a fake kubectl script that sleeps on the PATH
+func installFakeKubectl(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	script := "#!/bin/sh\nsleep 30\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0o755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func TestRunKubectlCommandTimeout(t *testing.T) {
+	installFakeKubectl(t)
+
+	ctx, cancel := WithCommandTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	output, err := RunKubectlCommand(ctx, []string{"get", "pods"})
+	if err != nil {
+		t.Fatalf("Expected a timed out response, got error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected command to be killed on timeout, took %s", elapsed)
+	}
+
+	var response CommandResponse
+	if err := json.Unmarshal([]byte(output), &response); err != nil {
+		t.Fatalf("Expected valid JSON response, got: %s", output)
+	}
+	if response.ReturnValue != -1 {
+		t.Errorf("Expected return value -1, got %d", response.ReturnValue)
+	}
+	if !strings.Contains(response.Stderr, "timed out") {
+		t.Errorf("Expected stderr to mention the timeout, got: %s", response.Stderr)
+	}
+}
+
+func TestRunKubectlCommandCancelled(t *testing.T) {
+	installFakeKubectl(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := RunKubectlCommand(ctx, []string{"get", "pods"})
+	if err == nil {
+		t.Fatalf("Expected an error for a cancelled command")
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("Expected command to be killed on cancellation, took %s", elapsed)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package mtvmcp
+
+import (
+	"os/exec"
+	"strconv"
+)
+
+// configureProcessTree kills the command and its child processes when the
+// command context is done
+func configureProcessTree(cmd *exec.Cmd) {
+	cmd.Cancel = func() error {
+		if cmd.Process == nil {
+			return nil
+		}
+		// taskkill /T terminates the process tree, fall back to killing the process itself
+		if err := exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run(); err != nil {
+			return cmd.Process.Kill()
+		}
+		return nil
+	}
+}
@@ -0,0 +1,184 @@
+package mtvmcp
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// executorKey is the context key for the command executor
+const executorKey contextKey = "executor"
+
+// processWaitDelay bounds how long to wait for output pipes after a command is killed
+const processWaitDelay = 5 * time.Second
+
+// Command describes a single kubectl or kubectl-mtv invocation
+type Command struct {
+	Name string
+	Args []string
+	// Env holds extra KEY=VALUE environment variables for the command
+	Env []string
+	// Stdin is written to the standard input of the command
+	Stdin string
+}
+
+// ExecResult holds the raw output of an executed command
+type ExecResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// Executor runs kubectl and kubectl-mtv commands.
+// Implementations return a non-nil error only when the command could not be
+// run to completion because the context is done; command failures are reported
+// through ExecResult.ExitCode and ExecResult.Stderr.
+type Executor interface {
+	Execute(ctx context.Context, cmd Command) (ExecResult, error)
+}
+
+var (
+	defaultExecutorMu sync.RWMutex
+	defaultExecutor   Executor = SubprocessExecutor{}
+)
+
+// SetDefaultExecutor sets the server-wide executor used when the context has none
+func SetDefaultExecutor(executor Executor) {
+	defaultExecutorMu.Lock()
+	defer defaultExecutorMu.Unlock()
+	defaultExecutor = executor
+}
+
+// WithExecutor adds a command executor to the context
+func WithExecutor(ctx context.Context, executor Executor) context.Context {
+	return context.WithValue(ctx, executorKey, executor)
+}
+
+// GetExecutor retrieves the command executor from the context,
+// falling back to the server-wide default executor
+func GetExecutor(ctx context.Context) Executor {
+	if ctx != nil {
+		if executor, ok := ctx.Value(executorKey).(Executor); ok && executor != nil {
+			return executor
+		}
+	}
+	defaultExecutorMu.RLock()
+	defer defaultExecutorMu.RUnlock()
+	return defaultExecutor
+}
+
+// liveExecutor returns the executor of the context without recording, replay or must-gather,
+// for commands such as token reviews that must reach the cluster and must never be stored
+func liveExecutor(ctx context.Context) Executor {
+	switch executor := GetExecutor(ctx).(type) {
+	case *RecordingExecutor:
+		return executor.next
+	case *ReplayExecutor, *MustGatherExecutor:
+		return SubprocessExecutor{}
+	default:
+		return executor
+	}
+}
+
+// ProbeExecutor returns the executor of the context for health probes, which must not
+// change what the server records or replays: recording is skipped, and replayed
+// recordings are served without being consumed. Must-gather archives are read as usual.
+func ProbeExecutor(ctx context.Context) Executor {
+	switch executor := GetExecutor(ctx).(type) {
+	case *RecordingExecutor:
+		return executor.next
+	case *ReplayExecutor:
+		return replayPeeker{replay: executor}
+	default:
+		return executor
+	}
+}
+
+var (
+	runningCommandsMu sync.Mutex
+	runningCommands   = make(map[int64]string)
+	nextCommandID     int64
+)
+
+// trackRunningCommand registers a running command, shown by RunningCommands until
+// the returned function is called
+func trackRunningCommand(command string) func() {
+	runningCommandsMu.Lock()
+	defer runningCommandsMu.Unlock()
+	nextCommandID++
+	id := nextCommandID
+	runningCommands[id] = command
+	return func() {
+		runningCommandsMu.Lock()
+		defer runningCommandsMu.Unlock()
+		delete(runningCommands, id)
+	}
+}
+
+// RunningCommands returns the sanitized command lines of the commands currently
+// running, oldest first
+func RunningCommands() []string {
+	runningCommandsMu.Lock()
+	defer runningCommandsMu.Unlock()
+	ids := make([]int64, 0, len(runningCommands))
+	for id := range runningCommands {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	commands := make([]string, 0, len(ids))
+	for _, id := range ids {
+		commands = append(commands, runningCommands[id])
+	}
+	return commands
+}
+
+// SubprocessExecutor runs commands as local subprocesses
+type SubprocessExecutor struct{}
+
+// Execute runs the command as a subprocess, killing its process tree when the context is done
+func (SubprocessExecutor) Execute(ctx context.Context, command Command) (ExecResult, error) {
+	cmd := exec.CommandContext(ctx, command.Name, command.Args...)
+	if len(command.Env) > 0 {
+		cmd.Env = append(os.Environ(), command.Env...)
+	}
+	if command.Stdin != "" {
+		cmd.Stdin = strings.NewReader(command.Stdin)
+	}
+
+	// Kill the whole process tree when the context is done
+	configureProcessTree(cmd)
+	cmd.WaitDelay = processWaitDelay
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	result := ExecResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+			if result.Stderr == "" {
+				result.Stderr = err.Error()
+			}
+		}
+	}
+
+	return result, nil
+}
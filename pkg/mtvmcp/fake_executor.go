@@ -0,0 +1,83 @@
+package mtvmcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// FakeResponse is a canned response returned by FakeExecutor
+type FakeResponse struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// fakeRule matches an exact argv to a canned response
+type fakeRule struct {
+	name     string
+	args     []string
+	response FakeResponse
+}
+
+// FakeExecutor is an in-memory Executor for hermetic tests.
+// It records every command and returns canned responses matched by exact argv.
+type FakeExecutor struct {
+	mu    sync.Mutex
+	rules []fakeRule
+	calls []Command
+
+	// Default is returned for commands without a matching rule.
+	// If nil, unmatched commands fail with exit code 127.
+	Default *FakeResponse
+}
+
+// NewFakeExecutor creates a FakeExecutor that answers every command with an empty success
+func NewFakeExecutor() *FakeExecutor {
+	return &FakeExecutor{Default: &FakeResponse{}}
+}
+
+// On registers a canned response for the exact command name and argv
+func (f *FakeExecutor) On(name string, args []string, response FakeResponse) *FakeExecutor {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = append(f.rules, fakeRule{name: name, args: slices.Clone(args), response: response})
+	return f
+}
+
+// Calls returns the commands executed so far, in order
+func (f *FakeExecutor) Calls() []Command {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	calls := make([]Command, len(f.calls))
+	for i, call := range f.calls {
+		calls[i] = Command{Name: call.Name, Args: slices.Clone(call.Args), Env: slices.Clone(call.Env), Stdin: call.Stdin}
+	}
+	return calls
+}
+
+// Execute records the command and returns the matching canned response
+func (f *FakeExecutor) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ExecResult{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, Command{Name: cmd.Name, Args: slices.Clone(cmd.Args), Env: slices.Clone(cmd.Env), Stdin: cmd.Stdin})
+
+	for _, rule := range f.rules {
+		if rule.name == cmd.Name && slices.Equal(rule.args, cmd.Args) {
+			return ExecResult(rule.response), nil
+		}
+	}
+
+	if f.Default != nil {
+		return ExecResult(*f.Default), nil
+	}
+	return ExecResult{
+		ExitCode: 127,
+		Stderr:   fmt.Sprintf("fake executor: no response for %s", formatShellCommand(cmd.Name, cmd.Args)),
+	}, nil
+}
@@ -0,0 +1,238 @@
+package mtvmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// tokenEntryName names the user and context of temporary token kubeconfigs
+const tokenEntryName = "kubectl-mtv-mcp-token"
+
+// inClusterCAFile is the service account CA bundle mounted into pods
+const inClusterCAFile = "/var/run/secrets/kubernetes.io/serviceaccount/ca.crt"
+
+// KubeConfig selects the cluster every kubectl and kubectl-mtv command talks to.
+// Empty fields keep the kubectl defaults.
+type KubeConfig struct {
+	// Kubeconfig is the path of the kubeconfig file, instead of KUBECONFIG or ~/.kube/config
+	Kubeconfig string
+	// Context is the kubeconfig context to use, instead of the current context
+	Context string
+	// Server is the address of the Kubernetes API server, overriding the context's cluster server
+	Server string
+}
+
+var (
+	defaultKubeConfigMu sync.RWMutex
+	defaultKubeConfig   KubeConfig
+)
+
+// SetDefaultKubeConfig sets the server-wide cluster selection for all commands
+func SetDefaultKubeConfig(config KubeConfig) {
+	defaultKubeConfigMu.Lock()
+	defer defaultKubeConfigMu.Unlock()
+	defaultKubeConfig = config
+}
+
+// getDefaultKubeConfig returns the server-wide cluster selection
+func getDefaultKubeConfig() KubeConfig {
+	defaultKubeConfigMu.RLock()
+	defer defaultKubeConfigMu.RUnlock()
+	return defaultKubeConfig
+}
+
+// Args returns the kubectl flags that apply the configuration
+func (c KubeConfig) Args() []string {
+	var args []string
+	if c.Kubeconfig != "" {
+		args = append(args, "--kubeconfig", c.Kubeconfig)
+	}
+	if c.Context != "" {
+		args = append(args, "--context", c.Context)
+	}
+	if c.Server != "" {
+		args = append(args, "--server", c.Server)
+	}
+	return args
+}
+
+// kubeconfigContext is a named context entry of a kubeconfig file
+type kubeconfigContext struct {
+	Name    string `json:"name"`
+	Context struct {
+		Cluster   string `json:"cluster"`
+		User      string `json:"user,omitempty"`
+		Namespace string `json:"namespace,omitempty"`
+	} `json:"context"`
+}
+
+// kubeconfigFile holds the kubeconfig fields needed to resolve the current context
+type kubeconfigFile struct {
+	CurrentContext string              `json:"current-context"`
+	Contexts       []kubeconfigContext `json:"contexts"`
+}
+
+// kubeConfigFor returns the cluster selection for the context: the cluster
+// selected with WithCluster, on top of the server-wide default
+func kubeConfigFor(ctx context.Context) KubeConfig {
+	config := getDefaultKubeConfig()
+	cluster, ok := GetCluster(ctx)
+	if !ok {
+		return config
+	}
+	if cluster.Kubeconfig != "" {
+		config.Kubeconfig = cluster.Kubeconfig
+	}
+	if cluster.Context != "" || cluster.Server != "" {
+		config.Context = cluster.Context
+		config.Server = cluster.Server
+	}
+	return config
+}
+
+// kubeconfigPaths returns the kubeconfig files kubectl loads for config, in precedence order
+func kubeconfigPaths(config KubeConfig) []string {
+	if config.Kubeconfig != "" {
+		return []string{config.Kubeconfig}
+	}
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var paths []string
+		for _, path := range filepath.SplitList(env) {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	path := filepath.Join(home, ".kube", "config")
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	return []string{path}
+}
+
+// resolveContext returns the named context of the merged kubeconfig files, or the
+// current context when name is empty.
+// Like kubectl, the first file that sets a value or defines a context wins.
+func resolveContext(paths []string, name string) (kubeconfigContext, bool) {
+	currentContext := name
+	contexts := make(map[string]kubeconfigContext)
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+
+		var config kubeconfigFile
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			continue
+		}
+
+		if currentContext == "" {
+			currentContext = config.CurrentContext
+		}
+		for _, kubeContext := range config.Contexts {
+			if _, ok := contexts[kubeContext.Name]; !ok {
+				contexts[kubeContext.Name] = kubeContext
+			}
+		}
+	}
+
+	kubeContext, ok := contexts[currentContext]
+	return kubeContext, ok && kubeContext.Context.Cluster != ""
+}
+
+// writeTokenKubeconfig writes a temporary kubeconfig that authenticates with token,
+// so the token is never passed on the command line. It returns the environment
+// that points kubectl at the kubeconfig, and a cleanup function that deletes it.
+//
+// The temporary kubeconfig adds a token user and a context for the cluster of the
+// selected kubeconfig context, and is loaded ahead of the existing kubeconfig files.
+// Without a kubeconfig it targets the configured server, or the in-cluster API server.
+func writeTokenKubeconfig(token string, kubeConfig KubeConfig) ([]string, func(), error) {
+	config := map[string]interface{}{
+		"apiVersion":      "v1",
+		"kind":            "Config",
+		"current-context": tokenEntryName,
+		"users": []interface{}{
+			map[string]interface{}{"name": tokenEntryName, "user": map[string]interface{}{"token": token}},
+		},
+	}
+	tokenContext := map[string]interface{}{"user": tokenEntryName}
+
+	paths := kubeconfigPaths(kubeConfig)
+	if current, ok := resolveContext(paths, kubeConfig.Context); ok {
+		tokenContext["cluster"] = current.Context.Cluster
+		if current.Context.Namespace != "" {
+			tokenContext["namespace"] = current.Context.Namespace
+		}
+	} else if server := kubeConfig.Server; server != "" {
+		paths = nil
+		tokenContext["cluster"] = tokenEntryName
+		config["clusters"] = []interface{}{
+			map[string]interface{}{"name": tokenEntryName, "cluster": map[string]interface{}{"server": server}},
+		}
+	} else if host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT"); host != "" && port != "" {
+		paths = nil
+		tokenContext["cluster"] = tokenEntryName
+		config["clusters"] = []interface{}{
+			map[string]interface{}{
+				"name": tokenEntryName,
+				"cluster": map[string]interface{}{
+					"server":                "https://" + net.JoinHostPort(host, port),
+					"certificate-authority": inClusterCAFile,
+				},
+			},
+		}
+	} else {
+		return nil, nil, fmt.Errorf("cannot use bearer token: no kubeconfig context, server or in-cluster configuration found")
+	}
+
+	config["contexts"] = []interface{}{
+		map[string]interface{}{"name": tokenEntryName, "context": tokenContext},
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal token kubeconfig: %w", err)
+	}
+
+	// os.CreateTemp creates the file with 0600 permissions
+	file, err := os.CreateTemp("", "kubectl-mtv-mcp-*.kubeconfig")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create token kubeconfig: %w", err)
+	}
+	cleanup := func() { _ = os.Remove(file.Name()) }
+
+	if err := file.Chmod(0o600); err != nil {
+		_ = file.Close()
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to restrict token kubeconfig permissions: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write token kubeconfig: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to write token kubeconfig: %w", err)
+	}
+
+	kubeconfig := strings.Join(append([]string{file.Name()}, paths...), string(os.PathListSeparator))
+	return []string{"KUBECONFIG=" + kubeconfig}, cleanup, nil
+}
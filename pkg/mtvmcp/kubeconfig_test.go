@@ -0,0 +1,237 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// setTestKubeconfig points KUBECONFIG at a kubeconfig with a single context
+func setTestKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	config := `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://api.dev.example.com:6443
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+    namespace: demo
+users:
+- name: dev-user
+  user:
+    token: kubeconfig-token
+`
+	if err := os.WriteFile(path, []byte(config), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", path)
+	return path
+}
+
+// executorFunc adapts a function to the Executor interface
+type executorFunc func(ctx context.Context, cmd Command) (ExecResult, error)
+
+func (f executorFunc) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	return f(ctx, cmd)
+}
+
+func TestTokenNeverOnCommandLine(t *testing.T) {
+	original := setTestKubeconfig(t)
+	const token = "sha256~super-secret-request-token"
+
+	var kubeconfigPath string
+	executor := executorFunc(func(ctx context.Context, cmd Command) (ExecResult, error) {
+		for _, arg := range cmd.Args {
+			if strings.Contains(arg, token) {
+				t.Errorf("Token found in argv: %v", cmd.Args)
+			}
+		}
+
+		var kubeconfig string
+		for _, env := range cmd.Env {
+			if value, ok := strings.CutPrefix(env, "KUBECONFIG="); ok {
+				kubeconfig = value
+			}
+		}
+		paths := filepath.SplitList(kubeconfig)
+		if len(paths) != 2 || paths[1] != original {
+			t.Fatalf("Expected KUBECONFIG to load the token kubeconfig before %s, got %q", original, kubeconfig)
+		}
+		kubeconfigPath = paths[0]
+
+		info, err := os.Stat(kubeconfigPath)
+		if err != nil {
+			t.Fatalf("Token kubeconfig missing during the call: %v", err)
+		}
+		if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+			t.Errorf("Expected token kubeconfig mode 0600, got %o", info.Mode().Perm())
+		}
+
+		data, err := os.ReadFile(kubeconfigPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, expected := range []string{token, `"cluster":"dev-cluster"`, `"namespace":"demo"`} {
+			if !strings.Contains(string(data), expected) {
+				t.Errorf("Expected token kubeconfig to contain %s, got %s", expected, data)
+			}
+		}
+		return ExecResult{Stdout: "{}"}, nil
+	})
+
+	ctx := WithKubeToken(WithExecutor(context.Background(), executor), token)
+	output, err := RunKubectlMTVCommand(ctx, []string{"get", "plan", "-n", "demo", "-o", "json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(output, token) {
+		t.Errorf("Token found in command response: %s", output)
+	}
+
+	if kubeconfigPath == "" {
+		t.Fatalf("Command was not executed")
+	}
+	if _, err := os.Stat(kubeconfigPath); !os.IsNotExist(err) {
+		t.Errorf("Expected token kubeconfig to be deleted after the call, got %v", err)
+	}
+}
+
+func TestTokenKubeconfigInCluster(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.0.0.1")
+	t.Setenv("KUBERNETES_SERVICE_PORT", "443")
+
+	env, cleanup, err := writeTokenKubeconfig("in-cluster-token", KubeConfig{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer cleanup()
+
+	if len(env) != 1 || strings.Contains(env[0], string(os.PathListSeparator)) {
+		t.Fatalf("Expected KUBECONFIG with only the token kubeconfig, got %v", env)
+	}
+	data, err := os.ReadFile(strings.TrimPrefix(env[0], "KUBECONFIG="))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"server":"https://10.0.0.1:443"`) {
+		t.Errorf("Expected in-cluster server, got %s", data)
+	}
+}
+
+func TestTokenKubeconfigWithoutCluster(t *testing.T) {
+	t.Setenv("KUBECONFIG", filepath.Join(t.TempDir(), "missing"))
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	if _, _, err := writeTokenKubeconfig("token", KubeConfig{}); err == nil {
+		t.Errorf("Expected an error without a kubeconfig context or in-cluster configuration")
+	}
+}
+
+func TestResolveCurrentContextFirstFileWins(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first")
+	second := filepath.Join(dir, "second")
+	if err := os.WriteFile(first, []byte("current-context: prod\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(second, []byte(`current-context: dev
+contexts:
+- name: prod
+  context:
+    cluster: prod-cluster
+- name: dev
+  context:
+    cluster: dev-cluster
+`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	current, ok := resolveContext([]string{first, second}, "")
+	if !ok || current.Context.Cluster != "prod-cluster" {
+		t.Errorf("Expected prod-cluster from the first current-context, got %+v", current)
+	}
+	named, ok := resolveContext([]string{first, second}, "dev")
+	if !ok || named.Context.Cluster != "dev-cluster" {
+		t.Errorf("Expected dev-cluster for the dev context, got %+v", named)
+	}
+	if !slices.Equal(kubeconfigPathsFor(t, first+string(os.PathListSeparator)+second), []string{first, second}) {
+		t.Errorf("Expected KUBECONFIG paths in order")
+	}
+}
+
+// kubeconfigPathsFor returns kubeconfigPaths for a KUBECONFIG value
+func kubeconfigPathsFor(t *testing.T, value string) []string {
+	t.Setenv("KUBECONFIG", value)
+	return kubeconfigPaths(KubeConfig{})
+}
+
+func TestDefaultKubeConfig(t *testing.T) {
+	path := setTestKubeconfig(t)
+	t.Cleanup(func() { SetDefaultKubeConfig(KubeConfig{}) })
+
+	var calls []Command
+	executor := executorFunc(func(ctx context.Context, cmd Command) (ExecResult, error) {
+		calls = append(calls, cmd)
+		if len(cmd.Env) > 0 {
+			data, err := os.ReadFile(strings.TrimPrefix(strings.Split(cmd.Env[0], string(os.PathListSeparator))[0], "KUBECONFIG="))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(data), `"cluster":"other-cluster"`) {
+				t.Errorf("Expected the token kubeconfig to target the selected context, got %s", data)
+			}
+		}
+		return ExecResult{}, nil
+	})
+	ctx := WithExecutor(context.Background(), executor)
+
+	// Append a second context to the test kubeconfig
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = []byte(strings.Replace(string(data), "contexts:\n", "contexts:\n- name: other\n  context:\n    cluster: other-cluster\n", 1))
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	SetDefaultKubeConfig(KubeConfig{Kubeconfig: path, Context: "other", Server: "https://api.other.example.com:6443"})
+
+	if _, err := RunKubectlCommand(ctx, []string{"get", "pods"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := RunKubectlCommand(WithKubeToken(ctx, "token"), []string{"get", "pods"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := [][]string{
+		{"--kubeconfig", path, "--context", "other", "--server", "https://api.other.example.com:6443", "get", "pods"},
+		{"--server", "https://api.other.example.com:6443", "get", "pods"},
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("Expected %d calls, got %d", len(expected), len(calls))
+	}
+	for i, call := range calls {
+		if !slices.Equal(call.Args, expected[i]) {
+			t.Errorf("Call %d: expected args %v, got %v", i, expected[i], call.Args)
+		}
+	}
+	if len(calls[0].Env) != 0 {
+		t.Errorf("Expected no environment without a token, got %v", calls[0].Env)
+	}
+	if len(calls[1].Env) != 1 {
+		t.Errorf("Expected KUBECONFIG with a token, got %v", calls[1].Env)
+	}
+}
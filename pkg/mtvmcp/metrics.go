@@ -0,0 +1,252 @@
+package mtvmcp
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// durationBuckets are the histogram buckets, in seconds, for tool call and subprocess latency
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+// Server metrics, exposed in the Prometheus text format by MetricsHandler
+var (
+	toolCalls = newCounterVec("kubectl_mtv_mcp_tool_calls_total",
+		"Number of tool calls.", "tool")
+	toolErrors = newCounterVec("kubectl_mtv_mcp_tool_errors_total",
+		"Number of tool calls that returned an error, by command exit code and error category.", "tool", "exit_code", "category")
+	toolDuration = newHistogramVec("kubectl_mtv_mcp_tool_duration_seconds",
+		"Tool call latency in seconds.", durationBuckets, "tool")
+	subprocessExecutions = newCounterVec("kubectl_mtv_mcp_subprocess_executions_total",
+		"Number of kubectl and kubectl-mtv executions, by exit code.", "command", "exit_code")
+	subprocessDuration = newHistogramVec("kubectl_mtv_mcp_subprocess_duration_seconds",
+		"kubectl and kubectl-mtv execution latency in seconds.", durationBuckets, "command")
+	subprocessesInFlight = newGauge("kubectl_mtv_mcp_subprocesses_in_flight",
+		"Number of kubectl and kubectl-mtv processes currently running.")
+	activeSessions = newGauge("kubectl_mtv_mcp_active_sessions",
+		"Number of active MCP sessions.")
+)
+
+// metricWriters lists the metrics in exposition order
+var metricWriters = []interface{ write(w *bufio.Writer) }{
+	toolCalls, toolErrors, toolDuration, subprocessExecutions, subprocessDuration, subprocessesInFlight, activeSessions,
+}
+
+// RecordToolCall records a completed tool call and its latency
+func RecordToolCall(tool string, duration time.Duration) {
+	toolCalls.inc(tool)
+	toolDuration.observe(duration.Seconds(), tool)
+}
+
+// RecordToolError records a failed tool call. exitCode is the exit code of the
+// failed command, or "none" if no command failed; category is the error type.
+func RecordToolError(tool, exitCode, category string) {
+	toolErrors.inc(tool, exitCode, category)
+}
+
+// SessionStarted increments the active MCP sessions gauge
+func SessionStarted() {
+	activeSessions.add(1)
+}
+
+// SessionEnded decrements the active MCP sessions gauge
+func SessionEnded() {
+	activeSessions.add(-1)
+}
+
+// recordSubprocess records a finished kubectl or kubectl-mtv execution
+func recordSubprocess(name string, exitCode int, duration time.Duration) {
+	subprocessExecutions.inc(name, strconv.Itoa(exitCode))
+	subprocessDuration.observe(duration.Seconds(), name)
+}
+
+// WriteMetrics writes all server metrics in the Prometheus text exposition format
+func WriteMetrics(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	for _, metric := range metricWriters {
+		metric.write(buf)
+	}
+	return buf.Flush()
+}
+
+// MetricsHandler serves the server metrics in the Prometheus text exposition format
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		_ = WriteMetrics(w)
+	})
+}
+
+// counterVec is a counter partitioned by label values
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+// counterValue is the value of a counter for one set of label values
+type counterValue struct {
+	labelValues []string
+	value       float64
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]*counterValue)}
+}
+
+// inc increments the counter for the label values
+func (c *counterVec) inc(labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.values[key]
+	if !ok {
+		value = &counterValue{labelValues: labelValues}
+		c.values[key] = value
+	}
+	value.value++
+}
+
+func (c *counterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		value := c.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, value.labelValues, "", ""), formatFloat(value.value))
+	}
+}
+
+// histogramVec is a histogram partitioned by label values
+type histogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogramValue
+}
+
+// histogramValue is the state of a histogram for one set of label values
+type histogramValue struct {
+	labelValues []string
+	counts      []uint64 // per bucket, not cumulative
+	sum         float64
+	count       uint64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labels ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labels: labels, buckets: buckets, values: make(map[string]*histogramValue)}
+}
+
+// observe adds an observation for the label values
+func (h *histogramVec) observe(v float64, labelValues ...string) {
+	key := strings.Join(labelValues, "\xff")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	value, ok := h.values[key]
+	if !ok {
+		value = &histogramValue{labelValues: labelValues, counts: make([]uint64, len(h.buckets))}
+		h.values[key] = value
+	}
+	for i, bound := range h.buckets {
+		if v <= bound {
+			value.counts[i]++
+			break
+		}
+	}
+	value.sum += v
+	value.count++
+}
+
+func (h *histogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		value := h.values[key]
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += value.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, value.labelValues, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, value.labelValues, "le", "+Inf"), value.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, value.labelValues, "", ""), formatFloat(value.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, value.labelValues, "", ""), value.count)
+	}
+}
+
+// gauge is a value that can go up and down
+type gauge struct {
+	name  string
+	help  string
+	value atomic.Int64
+}
+
+func newGauge(name, help string) *gauge {
+	return &gauge{name: name, help: help}
+}
+
+// add adds delta to the gauge
+func (g *gauge) add(delta int64) {
+	g.value.Add(delta)
+}
+
+func (g *gauge) write(w *bufio.Writer) {
+	writeHeader(w, g.name, g.help, "gauge")
+	fmt.Fprintf(w, "%s %d\n", g.name, g.value.Load())
+}
+
+// writeHeader writes the HELP and TYPE lines of a metric
+func writeHeader(w *bufio.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// labelEscaper escapes label values as required by the text exposition format
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels renders {name="value",...}, with an optional extra label such as le
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+labelEscaper.Replace(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat renders a sample value
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m in sorted order, for a stable exposition
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,106 @@
+package mtvmcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsExposition(t *testing.T) {
+	counter := newCounterVec("test_total", "Test counter.", "tool", "exit_code")
+	counter.inc("b", "1")
+	counter.inc("a", "0")
+	counter.inc("a", "0")
+	counter.inc(`quo"te`, "\n")
+
+	histogram := newHistogramVec("test_seconds", "Test histogram.", []float64{0.5, 1}, "tool")
+	histogram.observe(0.25, "a")
+	histogram.observe(0.75, "a")
+	histogram.observe(3, "a")
+
+	g := newGauge("test_in_flight", "Test gauge.")
+	g.add(2)
+	g.add(-1)
+
+	var out bytes.Buffer
+	w := bufio.NewWriter(&out)
+	counter.write(w)
+	histogram.write(w)
+	g.write(w)
+	if err := w.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{tool="a",exit_code="0"} 2
+test_total{tool="b",exit_code="1"} 1
+test_total{tool="quo\"te",exit_code="\n"} 1
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{tool="a",le="0.5"} 1
+test_seconds_bucket{tool="a",le="1"} 2
+test_seconds_bucket{tool="a",le="+Inf"} 3
+test_seconds_sum{tool="a"} 4
+test_seconds_count{tool="a"} 3
+# HELP test_in_flight Test gauge.
+# TYPE test_in_flight gauge
+test_in_flight 1
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
+
+func TestRunCommandRecordsSubprocessMetrics(t *testing.T) {
+	setTestKubeconfig(t)
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "plan"}, FakeResponse{Stderr: "boom", ExitCode: 3})
+	ctx := WithExecutor(context.Background(), fake)
+
+	series := `kubectl_mtv_mcp_subprocess_executions_total{command="kubectl-mtv",exit_code="3"}`
+	before := metricValue(t, series)
+	if _, err := RunKubectlMTVCommand(ctx, []string{"get", "plan"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if after := metricValue(t, series); after != before+1 {
+		t.Errorf("Expected %s to increase by 1, got %v -> %v", series, before, after)
+	}
+	if value := metricValue(t, "kubectl_mtv_mcp_subprocesses_in_flight"); value != 0 {
+		t.Errorf("Expected no subprocesses in flight, got %v", value)
+	}
+
+	SessionStarted()
+	defer SessionEnded()
+	if value := metricValue(t, "kubectl_mtv_mcp_active_sessions"); value < 1 {
+		t.Errorf("Expected an active session, got %v", value)
+	}
+
+	RecordToolCall("metrics-test", 2*time.Second)
+	if value := metricValue(t, `kubectl_mtv_mcp_tool_duration_seconds_bucket{tool="metrics-test",le="2.5"}`); value != 1 {
+		t.Errorf("Expected the call in the 2.5s bucket, got %v", value)
+	}
+}
+
+// metricValue returns the current value of a series from WriteMetrics, 0 if absent
+func metricValue(t *testing.T, series string) float64 {
+	t.Helper()
+	var out bytes.Buffer
+	if err := WriteMetrics(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(out.String(), "\n") {
+		if value, ok := strings.CutPrefix(line, series+" "); ok {
+			var v float64
+			if _, err := fmt.Sscan(value, &v); err != nil {
+				t.Fatalf("Invalid sample %q: %v", line, err)
+			}
+			return v
+		}
+	}
+	return 0
+}
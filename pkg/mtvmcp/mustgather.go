@@ -0,0 +1,509 @@
+package mtvmcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// defaultOperatorNamespace is used when the must-gather holds no forklift-controller pod
+const defaultOperatorNamespace = "openshift-mtv"
+
+// SnapshotInfo describes the offline snapshot tool responses are served from
+type SnapshotInfo struct {
+	Source     string    `json:"source"`
+	Path       string    `json:"path"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// mtvResourceKinds maps kubectl-mtv resource types to the Forklift CR kinds they list
+var mtvResourceKinds = map[string][]string{
+	"provider": {"Provider"},
+	"plan":     {"Plan"},
+	"mapping":  {"NetworkMap", "StorageMap"},
+	"host":     {"Host"},
+	"hook":     {"Hook"},
+}
+
+// kubectlResourceKinds maps kubectl resource names to kinds
+var kubectlResourceKinds = map[string]string{
+	"pvc":        "PersistentVolumeClaim",
+	"datavolume": "DataVolume",
+	"pod":        "Pod",
+	"pods":       "Pod",
+}
+
+// valueFlags are the flags whose value is the next argument
+var valueFlags = map[string]bool{
+	"-n": true, "-o": true, "-l": true, "-c": true, "-q": true,
+	"--tail": true, "--inventory-url": true, "--token": true,
+	"--as": true, "--as-group": true, "--kubeconfig": true, "--context": true, "--server": true,
+}
+
+// parsedArgs holds the positional arguments and flags of a command
+type parsedArgs struct {
+	positional []string
+	flags      map[string]string
+}
+
+// parseArgs splits argv into positional arguments and flags
+func parseArgs(args []string) parsedArgs {
+	parsed := parsedArgs{flags: map[string]string{}}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			// Everything after the separator is positional
+			parsed.positional = append(parsed.positional, args[i+1:]...)
+			return parsed
+		case valueFlags[arg] && i+1 < len(args):
+			parsed.flags[arg] = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "-"):
+			parsed.flags[arg] = ""
+		default:
+			parsed.positional = append(parsed.positional, arg)
+		}
+	}
+	return parsed
+}
+
+func (p parsedArgs) has(flag string) bool {
+	_, ok := p.flags[flag]
+	return ok
+}
+
+// podLog is a captured container log file
+type podLog struct {
+	namespace string
+	pod       string
+	container string
+	path      string
+	previous  bool
+}
+
+// MustGatherExecutor answers read commands from an extracted must-gather directory.
+// It understands the commands issued by the ListResources, GetPlanVms,
+// GetMigrationStorage and GetLogs tools; any other command fails.
+type MustGatherExecutor struct {
+	info    SnapshotInfo
+	objects map[string][]map[string]interface{} // kind -> objects
+	logs    []podLog
+}
+
+// NewMustGatherExecutor loads the Forklift CRs, PVCs, DataVolumes, pods and pod logs
+// found anywhere under dir
+func NewMustGatherExecutor(dir string) (*MustGatherExecutor, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open must-gather directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("must-gather path %s is not a directory", dir)
+	}
+
+	m := &MustGatherExecutor{
+		info:    SnapshotInfo{Source: "must-gather", Path: dir},
+		objects: map[string][]map[string]interface{}{},
+	}
+
+	var latest time.Time
+	err = filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		if fileInfo, err := entry.Info(); err == nil && fileInfo.ModTime().After(latest) {
+			latest = fileInfo.ModTime()
+		}
+
+		switch ext := strings.ToLower(filepath.Ext(path)); {
+		case entry.Name() == "timestamp":
+			if capturedAt, ok := readTimestampFile(path); ok {
+				m.info.CapturedAt = capturedAt
+			}
+		case ext == ".yaml" || ext == ".yml" || ext == ".json":
+			if err := m.loadManifest(path); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: skipping %s: %v\n", path, err)
+			}
+		case ext == ".log":
+			if log, ok := parseLogPath(dir, path); ok {
+				m.logs = append(m.logs, log)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read must-gather directory: %w", err)
+	}
+
+	if m.info.CapturedAt.IsZero() {
+		m.info.CapturedAt = latest.UTC()
+	}
+
+	return m, nil
+}
+
+// Snapshot returns the snapshot description
+func (m *MustGatherExecutor) Snapshot() SnapshotInfo {
+	return m.info
+}
+
+// readTimestampFile parses the capture time written by must-gather
+func readTimestampFile(path string) (time.Time, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return time.Time{}, false
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// must-gather writes "2024-01-02 10:11:12.123456789 +0000 UTC m=+0.1"
+		if idx := strings.Index(line, " m=+"); idx > 0 {
+			line = line[:idx]
+		}
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999 -0700 MST"} {
+			if t, err := time.Parse(layout, line); err == nil {
+				return t.UTC(), true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// parseLogPath extracts the namespace, pod and container of a log file.
+// It supports the oc adm inspect layout
+// namespaces/NS/pods/POD/CONTAINER/CONTAINER/logs/current.log and flatter
+// layouts such as namespaces/NS/logs/POD/CONTAINER.log.
+func parseLogPath(root, path string) (podLog, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return podLog{}, false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+
+	nsIndex := -1
+	for i, part := range parts {
+		if part == "namespaces" && i+1 < len(parts) {
+			nsIndex = i
+		}
+	}
+	if nsIndex < 0 || len(parts) < nsIndex+4 {
+		return podLog{}, false
+	}
+
+	log := podLog{namespace: parts[nsIndex+1], path: path}
+	rest := parts[nsIndex+3:]
+	base := strings.TrimSuffix(rest[len(rest)-1], ".log")
+	log.previous = strings.HasPrefix(base, "previous")
+
+	if len(rest) >= 2 {
+		log.pod = rest[0]
+		log.container = rest[1]
+		if len(rest) == 2 {
+			// namespaces/NS/logs/POD/CONTAINER.log
+			log.container = base
+		}
+	} else {
+		// namespaces/NS/logs/POD.log
+		log.pod = base
+	}
+
+	return log, true
+}
+
+// loadManifest indexes every Kubernetes object found in a YAML or JSON file
+func (m *MustGatherExecutor) loadManifest(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	for _, doc := range strings.Split(string(data), "\n---") {
+		if strings.TrimSpace(doc) == "" {
+			continue
+		}
+
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal([]byte(doc), &obj); err != nil {
+			return err
+		}
+		m.addObject(obj)
+	}
+	return nil
+}
+
+// addObject indexes an object by kind, expanding lists
+func (m *MustGatherExecutor) addObject(obj map[string]interface{}) {
+	kind, _ := obj["kind"].(string)
+	if kind == "" {
+		return
+	}
+
+	if items, ok := obj["items"].([]interface{}); ok && strings.HasSuffix(kind, "List") {
+		for _, item := range items {
+			if itemObj, ok := item.(map[string]interface{}); ok {
+				if _, ok := itemObj["kind"]; !ok {
+					itemObj["kind"] = strings.TrimSuffix(kind, "List")
+				}
+				m.addObject(itemObj)
+			}
+		}
+		return
+	}
+
+	m.objects[kind] = append(m.objects[kind], obj)
+}
+
+// find returns objects of kind in namespace (all namespaces if empty) matching the label selector
+func (m *MustGatherExecutor) find(kind, namespace, selector string) []interface{} {
+	labels := map[string]string{}
+	for _, term := range strings.Split(selector, ",") {
+		if key, value, ok := strings.Cut(term, "="); ok {
+			labels[key] = value
+		}
+	}
+
+	items := []interface{}{}
+	for _, obj := range m.objects[kind] {
+		metadata, _ := obj["metadata"].(map[string]interface{})
+		if namespace != "" && metadata["namespace"] != namespace {
+			continue
+		}
+
+		objLabels, _ := metadata["labels"].(map[string]interface{})
+		matches := true
+		for key, value := range labels {
+			if objLabels[key] != value {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			items = append(items, obj)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return objectName(items[i]) < objectName(items[j])
+	})
+	return items
+}
+
+// get returns a single named object
+func (m *MustGatherExecutor) get(kind, namespace, name string) (map[string]interface{}, bool) {
+	for _, item := range m.find(kind, namespace, "") {
+		if objectName(item) == name {
+			return item.(map[string]interface{}), true
+		}
+	}
+	return nil, false
+}
+
+// objectName returns metadata.name of an object
+func objectName(obj interface{}) string {
+	objMap, _ := obj.(map[string]interface{})
+	metadata, _ := objMap["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	return name
+}
+
+// controllerPod finds the forklift-controller pod and its namespace
+func (m *MustGatherExecutor) controllerPod(namespace string) (string, string) {
+	for _, pod := range m.find("Pod", namespace, "app=forklift-controller") {
+		metadata := pod.(map[string]interface{})["metadata"].(map[string]interface{})
+		ns, _ := metadata["namespace"].(string)
+		return ns, objectName(pod)
+	}
+	for _, log := range m.logs {
+		if strings.HasPrefix(log.pod, "forklift-controller") && (namespace == "" || log.namespace == namespace) {
+			return log.namespace, log.pod
+		}
+	}
+	return "", ""
+}
+
+// Execute answers a command from the snapshot
+func (m *MustGatherExecutor) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ExecResult{}, err
+	}
+
+	args := parseArgs(cmd.Args)
+	var (
+		out interface{}
+		err error
+	)
+
+	switch {
+	case cmd.Name == "kubectl-mtv" && len(args.positional) == 1 && args.positional[0] == "version":
+		namespace, _ := m.controllerPod("")
+		if namespace == "" {
+			namespace = defaultOperatorNamespace
+		}
+		out = map[string]interface{}{
+			"clientVersion":     "must-gather",
+			"operatorNamespace": namespace,
+			"operatorStatus":    "snapshot",
+		}
+
+	case cmd.Name == "kubectl-mtv" && len(args.positional) == 3 && args.positional[0] == "get" && args.has("--vms"):
+		out, err = m.planVMs(args.flags["-n"], args.positional[2])
+
+	case cmd.Name == "kubectl-mtv" && len(args.positional) == 2 && args.positional[0] == "get" && mtvResourceKinds[args.positional[1]] != nil:
+		items := []interface{}{}
+		for _, kind := range mtvResourceKinds[args.positional[1]] {
+			items = append(items, m.find(kind, args.flags["-n"], "")...)
+		}
+		out = items
+
+	case cmd.Name == "kubectl" && len(args.positional) >= 2 && args.positional[0] == "get" && kubectlResourceKinds[args.positional[1]] != "":
+		out, err = m.kubectlGet(args)
+		if text, ok := out.(string); ok && err == nil {
+			return ExecResult{Stdout: text}, nil
+		}
+
+	case cmd.Name == "kubectl" && len(args.positional) == 3 && args.positional[0] == "describe" && kubectlResourceKinds[args.positional[1]] != "":
+		return m.describe(kubectlResourceKinds[args.positional[1]], args.flags["-n"], args.positional[2]), nil
+
+	case cmd.Name == "kubectl" && len(args.positional) == 2 && args.positional[0] == "logs":
+		return m.podLogs(args.flags["-n"], args.positional[1], args.flags["-c"], args.flags["--tail"]), nil
+
+	default:
+		return ExecResult{
+			ExitCode: 1,
+			Stderr:   fmt.Sprintf("must-gather: command is not available in snapshot mode: %s", formatShellCommand(cmd.Name, cmd.Args)),
+		}, nil
+	}
+
+	if err != nil {
+		return ExecResult{ExitCode: 1, Stderr: err.Error()}, nil
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return ExecResult{ExitCode: 1, Stderr: err.Error()}, nil
+	}
+	return ExecResult{Stdout: string(data)}, nil
+}
+
+// planVMs returns the VMs of a plan with their migration status
+func (m *MustGatherExecutor) planVMs(namespace, name string) (interface{}, error) {
+	plan, ok := m.get("Plan", namespace, name)
+	if !ok {
+		return nil, fmt.Errorf("Error from server (NotFound): plans.forklift.konveyor.io %q not found in must-gather", name)
+	}
+
+	if status, ok := plan["status"].(map[string]interface{}); ok {
+		if migration, ok := status["migration"].(map[string]interface{}); ok {
+			if vms, ok := migration["vms"].([]interface{}); ok {
+				return vms, nil
+			}
+		}
+	}
+	if spec, ok := plan["spec"].(map[string]interface{}); ok {
+		if vms, ok := spec["vms"].([]interface{}); ok {
+			return vms, nil
+		}
+	}
+	return []interface{}{}, nil
+}
+
+// kubectlGet answers kubectl get for PVCs, DataVolumes and pods
+func (m *MustGatherExecutor) kubectlGet(args parsedArgs) (interface{}, error) {
+	kind := kubectlResourceKinds[args.positional[1]]
+	namespace := args.flags["-n"]
+
+	if len(args.positional) == 3 {
+		obj, ok := m.get(kind, namespace, args.positional[2])
+		if !ok && kind == "Pod" {
+			// Pods are often captured only as logs
+			for _, log := range m.logs {
+				if log.pod == args.positional[2] && (namespace == "" || log.namespace == namespace) {
+					return map[string]interface{}{
+						"apiVersion": "v1",
+						"kind":       "Pod",
+						"metadata":   map[string]interface{}{"name": log.pod, "namespace": log.namespace},
+					}, nil
+				}
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("Error from server (NotFound): %s %q not found in must-gather", args.positional[1], args.positional[2])
+		}
+		return obj, nil
+	}
+
+	// The controller lookup asks for a pod name with a jsonpath output
+	if kind == "Pod" && strings.HasPrefix(args.flags["-o"], "jsonpath=") {
+		_, pod := m.controllerPod(namespace)
+		return pod, nil
+	}
+
+	return map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      m.find(kind, namespace, args.flags["-l"]),
+	}, nil
+}
+
+// describe renders an object as YAML in place of kubectl describe output
+func (m *MustGatherExecutor) describe(kind, namespace, name string) ExecResult {
+	obj, ok := m.get(kind, namespace, name)
+	if !ok {
+		return ExecResult{ExitCode: 1, Stderr: fmt.Sprintf("Error from server (NotFound): %s %q not found in must-gather", kind, name)}
+	}
+
+	data, err := yaml.Marshal(obj)
+	if err != nil {
+		return ExecResult{ExitCode: 1, Stderr: err.Error()}
+	}
+	return ExecResult{Stdout: string(data)}
+}
+
+// podLogs returns the captured log of a pod container, limited to the last tail lines
+func (m *MustGatherExecutor) podLogs(namespace, pod, container, tail string) ExecResult {
+	var match *podLog
+	for i, log := range m.logs {
+		if log.pod != pod || (namespace != "" && log.namespace != namespace) || log.previous {
+			continue
+		}
+		if container != "" && log.container != container {
+			continue
+		}
+		// Without a container, prefer the main container like the controller pod's default
+		if match == nil || (container == "" && log.container == "main") {
+			match = &m.logs[i]
+		}
+	}
+	if match == nil {
+		return ExecResult{ExitCode: 1, Stderr: fmt.Sprintf("must-gather: no logs captured for pod %s container %q", pod, container)}
+	}
+
+	data, err := os.ReadFile(match.path)
+	if err != nil {
+		return ExecResult{ExitCode: 1, Stderr: err.Error()}
+	}
+	logs := string(data)
+
+	if lines, err := strconv.Atoi(tail); err == nil && lines > 0 {
+		all := strings.SplitAfter(strings.TrimRight(logs, "\n"), "\n")
+		if len(all) > lines {
+			all = all[len(all)-lines:]
+		}
+		logs = strings.Join(all, "") + "\n"
+	}
+
+	return ExecResult{Stdout: logs}
+}
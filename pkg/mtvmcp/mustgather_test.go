@@ -0,0 +1,179 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeMustGatherFile writes a file below the must-gather root
+func writeMustGatherFile(t *testing.T, root, path, content string) {
+	t.Helper()
+	full := filepath.Join(root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestMustGather creates a small must-gather directory
+func newTestMustGather(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+
+	writeMustGatherFile(t, root, "timestamp", "2026-10-01 08:30:00.123456789 +0000 UTC m=+0.012\n")
+	writeMustGatherFile(t, root, "quay-io-forklift-must-gather/namespaces/demo/crs/plan/my-plan.yaml", `apiVersion: forklift.konveyor.io/v1beta1
+kind: Plan
+metadata:
+  name: my-plan
+  namespace: demo
+spec:
+  vms:
+  - id: vm-47
+    name: web
+status:
+  migration:
+    vms:
+    - id: vm-47
+      name: web
+      phase: CopyDisks
+`)
+	writeMustGatherFile(t, root, "quay-io-forklift-must-gather/namespaces/demo/crs/networkmap/net.yaml", `apiVersion: forklift.konveyor.io/v1beta1
+kind: NetworkMap
+metadata:
+  name: net
+  namespace: demo
+`)
+	writeMustGatherFile(t, root, "quay-io-forklift-must-gather/namespaces/demo/core/persistentvolumeclaims.yaml", `apiVersion: v1
+kind: PersistentVolumeClaimList
+items:
+- metadata:
+    name: web-disk-0
+    namespace: demo
+    labels:
+      plan: plan-uid
+      vmID: vm-47
+- metadata:
+    name: other
+    namespace: demo
+`)
+	writeMustGatherFile(t, root, "quay-io-forklift-must-gather/namespaces/openshift-mtv/pods/forklift-controller-abc/main/main/logs/current.log",
+		"line 1\nline 2\nline 3\n")
+	writeMustGatherFile(t, root, "quay-io-forklift-must-gather/namespaces/openshift-mtv/pods/forklift-controller-abc/inventory/inventory/logs/current.log",
+		"inventory line\n")
+
+	return root
+}
+
+func TestMustGatherExecutor(t *testing.T) {
+	executor, err := NewMustGatherExecutor(newTestMustGather(t))
+	if err != nil {
+		t.Fatalf("Failed to load must-gather: %v", err)
+	}
+
+	expected := time.Date(2026, 10, 1, 8, 30, 0, 123456789, time.UTC)
+	if captured := executor.Snapshot().CapturedAt; !captured.Equal(expected) {
+		t.Errorf("Expected capture time %s, got %s", expected, captured)
+	}
+
+	tests := []struct {
+		name     string
+		command  Command
+		exitCode int
+		contains []string
+		excludes []string
+	}{
+		{
+			name:     "list plans",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"get", "plan", "-n", "demo", "-o", "json"}},
+			contains: []string{`"name": "my-plan"`},
+		},
+		{
+			name:     "list mappings",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"get", "mapping", "-A", "-o", "json"}},
+			contains: []string{`"kind": "NetworkMap"`},
+		},
+		{
+			name:     "plan VMs with status",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"get", "plan", "my-plan", "--vms", "-n", "demo", "-o", "json"}},
+			contains: []string{`"phase": "CopyDisks"`},
+		},
+		{
+			name:     "plan VMs after separator",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"get", "plan", "--vms", "-n", "demo", "-o", "json", "--", "my-plan"}},
+			contains: []string{`"phase": "CopyDisks"`},
+		},
+		{
+			name: "plan VMs with impersonation and kubeconfig flags",
+			command: Command{Name: "kubectl-mtv", Args: []string{"get", "plan", "--as", "alice", "--as-group", "admins",
+				"--kubeconfig", "/tmp/kubeconfig", "--context", "prod", "--server", "https://api:6443", "my-plan", "--vms", "-n", "demo", "-o", "json"}},
+			contains: []string{`"phase": "CopyDisks"`},
+		},
+		{
+			name:     "missing plan",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"get", "plan", "missing", "--vms", "-o", "json"}},
+			exitCode: 1,
+		},
+		{
+			name:     "operator namespace from controller logs",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"version", "-o", "json"}},
+			contains: []string{`"operatorNamespace": "openshift-mtv"`},
+		},
+		{
+			name:     "PVCs by label",
+			command:  Command{Name: "kubectl", Args: []string{"get", "pvc", "-n", "demo", "-l", "plan=plan-uid,vmID=vm-47", "-o", "json"}},
+			contains: []string{"web-disk-0"},
+			excludes: []string{`"other"`},
+		},
+		{
+			name:     "controller pod name",
+			command:  Command{Name: "kubectl", Args: []string{"get", "pods", "-n", "openshift-mtv", "-l", "app=forklift-controller", "-o", "jsonpath={.items[0].metadata.name}"}},
+			contains: []string{"forklift-controller-abc"},
+		},
+		{
+			name:     "tail of container logs",
+			command:  Command{Name: "kubectl", Args: []string{"logs", "-n", "openshift-mtv", "forklift-controller-abc", "-c", "main", "--tail", "2"}},
+			contains: []string{"line 2\nline 3\n"},
+			excludes: []string{"line 1"},
+		},
+		{
+			name:     "default container logs",
+			command:  Command{Name: "kubectl", Args: []string{"logs", "-n", "openshift-mtv", "forklift-controller-abc"}},
+			contains: []string{"line 1"},
+		},
+		{
+			name:     "write commands are not available",
+			command:  Command{Name: "kubectl-mtv", Args: []string{"delete", "plan", "my-plan"}},
+			exitCode: 1,
+			contains: []string{"not available in snapshot mode"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := executor.Execute(context.Background(), tt.command)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if result.ExitCode != tt.exitCode {
+				t.Fatalf("Expected exit code %d, got %d (stderr: %s)", tt.exitCode, result.ExitCode, result.Stderr)
+			}
+			output := result.Stdout + result.Stderr
+			for _, s := range tt.contains {
+				if !strings.Contains(output, s) {
+					t.Errorf("Expected output to contain %q, got:\n%s", s, output)
+				}
+			}
+			for _, s := range tt.excludes {
+				if strings.Contains(output, s) {
+					t.Errorf("Expected output not to contain %q, got:\n%s", s, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package mtvmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CommandOutput is the structured result of a kubectl or kubectl-mtv command.
+// Tools embed it in their typed outputs; when the command stdout is JSON it is
+// decoded into the tool's typed fields and Stdout is left empty.
+type CommandOutput struct {
+	Command     string        `json:"command" jsonschema:"The executed command line, with secrets redacted"`
+	ReturnValue int           `json:"return_value" jsonschema:"Exit code of the command, 0 on success"`
+	Stdout      string        `json:"stdout,omitempty" jsonschema:"Raw command output, set when it is not decoded into structured fields"`
+	Stderr      string        `json:"stderr,omitempty" jsonschema:"Command error output"`
+	Snapshot    *SnapshotInfo `json:"snapshot,omitempty" jsonschema:"Set when the result is served from an offline snapshot instead of a live cluster"`
+	Cached      bool          `json:"cached,omitempty" jsonschema:"Set when the result is served from the result cache; it may be up to the cache TTL old, pass refresh=true for a fresh result"`
+}
+
+// ParseCommandOutput parses the JSON response returned by RunKubectlMTVCommand and RunKubectlCommand.
+// A command that exited with a non-zero code is returned as a classified *CommandError.
+func ParseCommandOutput(responseJSON string) (CommandOutput, error) {
+	var response CommandResponse
+	if err := json.Unmarshal([]byte(responseJSON), &response); err != nil {
+		return CommandOutput{}, fmt.Errorf("failed to unmarshal JSON response: %w", err)
+	}
+
+	if response.ReturnValue != 0 {
+		return CommandOutput{}, NewCommandError(response.Command, response.ReturnValue, response.Stderr)
+	}
+
+	return CommandOutput{
+		Command:     response.Command,
+		ReturnValue: response.ReturnValue,
+		Stdout:      response.Stdout,
+		Stderr:      response.Stderr,
+		Cached:      response.Cached,
+	}, nil
+}
+
+// DecodeStdout decodes the JSON stdout of a successful command into v.
+// On success the raw stdout is cleared so it is not returned twice.
+func (o *CommandOutput) DecodeStdout(v any) bool {
+	stdout := strings.TrimSpace(o.Stdout)
+	if o.ReturnValue != 0 || stdout == "" {
+		return false
+	}
+	if err := json.Unmarshal([]byte(stdout), v); err != nil {
+		return false
+	}
+	o.Stdout = ""
+	return true
+}
+
+// DecodeStdoutList decodes a JSON list from the command stdout. The list may be
+// a JSON array or an object holding the array under one of keys (e.g. "items").
+// On success the raw stdout is cleared.
+func DecodeStdoutList[T any](o *CommandOutput, keys ...string) ([]T, bool) {
+	stdout := strings.TrimSpace(o.Stdout)
+	if o.ReturnValue != 0 || stdout == "" {
+		return nil, false
+	}
+
+	var items []T
+	if err := json.Unmarshal([]byte(stdout), &items); err == nil {
+		o.Stdout = ""
+		return items, true
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &object); err != nil {
+		return nil, false
+	}
+	for _, key := range keys {
+		raw, ok := object[key]
+		if !ok {
+			continue
+		}
+		if err := json.Unmarshal(raw, &items); err == nil {
+			o.Stdout = ""
+			return items, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,160 @@
+package mtvmcp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxResponseBytes is the default size bound of the items in one tool response
+const DefaultMaxResponseBytes = 128 << 10
+
+// cursorVersion prefixes cursors, so their format can change without misreading old cursors
+const cursorVersion = "v1"
+
+var (
+	maxResponseBytesMu sync.RWMutex
+	maxResponseBytes   = DefaultMaxResponseBytes
+)
+
+// SetMaxResponseBytes sets the server-wide size bound of the items in one tool response,
+// 0 disables pagination
+func SetMaxResponseBytes(limit int) {
+	maxResponseBytesMu.Lock()
+	defer maxResponseBytesMu.Unlock()
+	maxResponseBytes = limit
+}
+
+// getMaxResponseBytes returns the server-wide size bound of a tool response
+func getMaxResponseBytes() int {
+	maxResponseBytesMu.RLock()
+	defer maxResponseBytesMu.RUnlock()
+	return maxResponseBytes
+}
+
+// PageInfo describes the page of a result returned by a paginated tool
+type PageInfo struct {
+	Total      int    `json:"total" jsonschema:"Total number of items (or log lines) in the full result"`
+	Truncated  bool   `json:"truncated" jsonschema:"Set when the response holds only part of the items because of the response size limit"`
+	NextCursor string `json:"next_cursor,omitempty" jsonschema:"Pass as cursor, with the same other arguments, to get the next page"`
+}
+
+// Paginate returns the page of items starting at cursor, as many items as fit the
+// server-wide response size limit and at least one. size returns the size of an item.
+// query identifies the result, so that a cursor cannot be used with another query.
+func Paginate[T any](items []T, cursor string, size func(T) int, query ...string) ([]T, PageInfo, error) {
+	fingerprint := queryFingerprint(query)
+	offset := 0
+	if cursor != "" {
+		var err error
+		if offset, err = decodeCursor(cursor, fingerprint); err != nil {
+			return nil, PageInfo{}, err
+		}
+		// The result may have shrunk since the previous page
+		offset = min(offset, len(items))
+	}
+
+	end := len(items)
+	if limit := getMaxResponseBytes(); limit > 0 {
+		used := 0
+		for end = offset; end < len(items); end++ {
+			n := size(items[end])
+			if end > offset && used+n > limit {
+				break
+			}
+			used += n
+		}
+	}
+
+	page := PageInfo{Total: len(items), Truncated: offset > 0 || end < len(items)}
+	if end < len(items) {
+		page.NextCursor = encodeCursor(end, fingerprint)
+	}
+	return items[offset:end], page, nil
+}
+
+// PaginateText returns the page of text starting at cursor. split cuts the text
+// into the items that are counted and kept whole, such as lines.
+func PaginateText(text, cursor string, split func(string) []string, query ...string) (string, PageInfo, error) {
+	chunks, page, err := Paginate(split(text), cursor, func(chunk string) int { return len(chunk) }, query...)
+	if err != nil {
+		return "", PageInfo{}, err
+	}
+	return strings.Join(chunks, ""), page, nil
+}
+
+// JSONSize returns the size of the JSON encoding of an item
+func JSONSize[T any](item T) int {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// SplitLines splits text into lines, keeping the line endings
+func SplitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// SplitYAMLList splits a YAML list into its elements, each starting at a "- " line.
+// Lines before the first element are kept with it.
+func SplitYAMLList(text string) []string {
+	var items []string
+	var current strings.Builder
+	inElement := false
+	for _, line := range SplitLines(text) {
+		if strings.HasPrefix(line, "- ") {
+			if inElement {
+				items = append(items, current.String())
+				current.Reset()
+			}
+			inElement = true
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		items = append(items, current.String())
+	}
+	return items
+}
+
+// queryFingerprint returns a short digest identifying a paginated query
+func queryFingerprint(query []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(query, "\x00")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// encodeCursor returns the opaque cursor of the page starting at offset
+func encodeCursor(offset int, fingerprint string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%d:%s", cursorVersion, offset, fingerprint)))
+}
+
+// decodeCursor returns the offset of a cursor issued for the query with fingerprint
+func decodeCursor(cursor, fingerprint string) (int, error) {
+	invalid := invalidParamsError("invalid_cursor", map[string]string{"cursor": cursor},
+		"Invalid cursor, pass the next_cursor of the previous response with the same other arguments")
+
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, invalid
+	}
+	parts := strings.Split(string(data), ":")
+	if len(parts) != 3 || parts[0] != cursorVersion || parts[2] != fingerprint {
+		return 0, invalid
+	}
+	offset, err := strconv.Atoi(parts[1])
+	if err != nil || offset < 0 {
+		return 0, invalid
+	}
+	return offset, nil
+}
@@ -0,0 +1,174 @@
+package mtvmcp
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+)
+
+// setTestMaxResponseBytes sets the response size limit for the duration of the test
+func setTestMaxResponseBytes(t *testing.T, limit int) {
+	t.Helper()
+	SetMaxResponseBytes(limit)
+	t.Cleanup(func() { SetMaxResponseBytes(DefaultMaxResponseBytes) })
+}
+
+func TestPaginate(t *testing.T) {
+	setTestMaxResponseBytes(t, 10)
+	size := func(item string) int { return len(item) }
+	items := []string{"aaaa", "bbbb", "cccc", "dddddddddddd", "e"}
+
+	// Walk the pages by following next_cursor
+	var pages [][]string
+	cursor := ""
+	for {
+		page, info, err := Paginate(items, cursor, size, "get", "vm")
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if info.Total != len(items) || !info.Truncated {
+			t.Errorf("Expected a truncated result of %d items, got %+v", len(items), info)
+		}
+		pages = append(pages, page)
+		if info.NextCursor == "" {
+			break
+		}
+		cursor = info.NextCursor
+	}
+
+	// An item larger than the limit still makes a page of its own
+	expected := [][]string{{"aaaa", "bbbb"}, {"cccc"}, {"dddddddddddd"}, {"e"}}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("Expected pages %q, got %q", expected, pages)
+	}
+}
+
+func TestPaginateWithinLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+	}{
+		{name: "fits the limit", limit: 100},
+		{name: "pagination disabled", limit: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestMaxResponseBytes(t, tt.limit)
+			items := []string{"aaaa", "bbbb", "cccc"}
+			page, info, err := Paginate(items, "", func(item string) int { return 40 }, "get", "vm")
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.limit == 0 && len(page) != len(items) {
+				t.Errorf("Expected every item, got %q", page)
+			}
+			if info.Total != len(items) || info.Truncated != (len(page) < len(items)) {
+				t.Errorf("Unexpected page info %+v for %d items", info, len(page))
+			}
+		})
+	}
+}
+
+func TestPaginateCursor(t *testing.T) {
+	setTestMaxResponseBytes(t, 1)
+	size := func(item string) int { return len(item) }
+	items := []string{"a", "b", "c"}
+
+	_, info, err := Paginate(items, "", size, "get", "vm")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		query   []string
+		wantErr bool
+	}{
+		{name: "same query", cursor: info.NextCursor, query: []string{"get", "vm"}},
+		{name: "other query", cursor: info.NextCursor, query: []string{"get", "host"}, wantErr: true},
+		{name: "not base64", cursor: "not a cursor!", query: []string{"get", "vm"}, wantErr: true},
+		{name: "unknown version", cursor: base64.RawURLEncoding.EncodeToString([]byte("v0:1:" + queryFingerprint([]string{"get", "vm"}))), query: []string{"get", "vm"}, wantErr: true},
+		{name: "negative offset", cursor: encodeCursor(-1, queryFingerprint([]string{"get", "vm"})), query: []string{"get", "vm"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, _, err := Paginate(items, tt.cursor, size, tt.query...)
+			if tt.wantErr {
+				checkInvalidParams(t, err, "invalid_cursor", []string{"cursor"})
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(page, []string{"b"}) {
+				t.Errorf("Expected the second page, got %q", page)
+			}
+		})
+	}
+
+	// A cursor past the end of a shrunk result returns an empty last page
+	page, info, err := Paginate(items[:1], encodeCursor(2, queryFingerprint([]string{"get", "vm"})), size, "get", "vm")
+	if err != nil || len(page) != 0 || info.NextCursor != "" {
+		t.Errorf("Expected an empty last page, got %q, %+v, %v", page, info, err)
+	}
+}
+
+func TestPaginateText(t *testing.T) {
+	setTestMaxResponseBytes(t, 12)
+
+	text, info, err := PaginateText("line one\nline two\nline three\n", "", SplitLines, "logs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "line one\n" || info.Total != 3 || info.NextCursor == "" {
+		t.Errorf("Unexpected first page %q, %+v", text, info)
+	}
+
+	text, info, err = PaginateText("line one\nline two\nline three\n", info.NextCursor, SplitLines, "logs")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if text != "line two\n" || !info.Truncated {
+		t.Errorf("Unexpected second page %q, %+v", text, info)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name     string
+		split    func(string) []string
+		text     string
+		expected []string
+	}{
+		{name: "lines", split: SplitLines, text: "a\nb\n", expected: []string{"a\n", "b\n"}},
+		{name: "lines without final newline", split: SplitLines, text: "a\nb", expected: []string{"a\n", "b"}},
+		{name: "empty", split: SplitLines, text: "", expected: []string{}},
+		{
+			name:     "yaml list",
+			split:    SplitYAMLList,
+			text:     "- name: web\n  id: vm-1\n- name: db\n  id: vm-2\n",
+			expected: []string{"- name: web\n  id: vm-1\n", "- name: db\n  id: vm-2\n"},
+		},
+		{
+			name:     "yaml list with header",
+			split:    SplitYAMLList,
+			text:     "# vms\n- name: web\n- name: db\n",
+			expected: []string{"# vms\n- name: web\n", "- name: db\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.split(tt.text)
+			if len(got) == 0 && len(tt.expected) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package mtvmcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitKeyKey is the context key for the identity commands are rate limited by
+const rateLimitKeyKey contextKey = "rate_limit_key"
+
+// serverCommandKey is the context key marking commands the server runs for itself
+const serverCommandKey contextKey = "server_command"
+
+// DefaultMaxConcurrentSubprocesses is the default server-wide cap on running commands
+const DefaultMaxConcurrentSubprocesses = 32
+
+// DefaultRateLimitBurst is the default number of commands an identity can start at once
+const DefaultRateLimitBurst = 20
+
+// defaultSlotWait bounds how long a command waits for a free subprocess slot
+const defaultSlotWait = 5 * time.Second
+
+// rateLimitBucketsSize bounds the number of per-identity token buckets
+const rateLimitBucketsSize = 4096
+
+// Rate limit error types
+const (
+	// RateLimitTypeRate is returned when an identity starts commands faster than the rate limit
+	RateLimitTypeRate = "rate_limit"
+	// RateLimitTypeConcurrency is returned when the server runs the maximum number of commands
+	RateLimitTypeConcurrency = "concurrency_limit"
+)
+
+// Limits configures the subprocess limits
+type Limits struct {
+	// MaxConcurrent caps the commands running at once across all clients, 0 disables the cap
+	MaxConcurrent int
+	// Rate is the number of commands per second each identity may start, 0 disables rate limiting
+	Rate float64
+	// Burst is the number of commands an identity may start at once, defaults to DefaultRateLimitBurst
+	Burst int
+}
+
+// RateLimitError is returned instead of running a command when a limit is hit.
+// Its message is a JSON document, like CommandError.
+type RateLimitError struct {
+	Type              string `json:"type"`
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds"`
+}
+
+// Error returns the error as a JSON document
+func (e *RateLimitError) Error() string {
+	type body RateLimitError
+	jsonData, _ := json.MarshalIndent(struct {
+		Error string `json:"error"`
+		*body
+	}{Error: "rate_limited", body: (*body)(e)}, "", "  ")
+	return string(jsonData)
+}
+
+// newRateLimitError builds a RateLimitError asking to retry after at least one second
+func newRateLimitError(limitType string, retryAfter time.Duration, reason string) *RateLimitError {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &RateLimitError{
+		Type:              limitType,
+		Message:           fmt.Sprintf("rate limited, retry after %d seconds: %s", seconds, reason),
+		RetryAfterSeconds: seconds,
+	}
+}
+
+// WithRateLimitKey sets the identity whose rate limit applies to commands run with the context
+func WithRateLimitKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, rateLimitKeyKey, key)
+}
+
+// getRateLimitKey returns the rate limit identity of the context, "" for the shared identity
+func getRateLimitKey(ctx context.Context) string {
+	key, _ := ctx.Value(rateLimitKeyKey).(string)
+	return key
+}
+
+// WithServerCommand marks commands run with the context as run by the server for itself,
+// such as token reviews and health probes. They do not count against the rate limits,
+// the subprocess cap or the subprocess metrics, so a saturated server can still
+// authenticate callers and report its health.
+func WithServerCommand(ctx context.Context) context.Context {
+	return context.WithValue(ctx, serverCommandKey, true)
+}
+
+// isServerCommand reports whether the context runs commands for the server itself
+func isServerCommand(ctx context.Context) bool {
+	serverCommand, _ := ctx.Value(serverCommandKey).(bool)
+	return serverCommand
+}
+
+// tokenBucket holds the rate limit state of one identity
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// subprocessLimiter enforces the subprocess limits
+type subprocessLimiter struct {
+	mu      sync.Mutex
+	limits  Limits
+	slots   chan struct{}
+	buckets map[string]*tokenBucket
+	// slotWait bounds how long a command waits for a free subprocess slot
+	slotWait time.Duration
+	now      func() time.Time
+}
+
+// newSubprocessLimiter creates a limiter enforcing limits
+func newSubprocessLimiter(limits Limits) *subprocessLimiter {
+	l := &subprocessLimiter{slotWait: defaultSlotWait, now: time.Now}
+	l.setLimits(limits)
+	return l
+}
+
+var limiter = newSubprocessLimiter(Limits{MaxConcurrent: DefaultMaxConcurrentSubprocesses})
+
+// SetLimits sets the server-wide subprocess limits
+func SetLimits(limits Limits) {
+	limiter.setLimits(limits)
+}
+
+// setLimits replaces the limits and resets the rate limit state
+func (l *subprocessLimiter) setLimits(limits Limits) {
+	if limits.Burst <= 0 {
+		limits.Burst = DefaultRateLimitBurst
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = limits
+	l.slots = nil
+	if limits.MaxConcurrent > 0 {
+		l.slots = make(chan struct{}, limits.MaxConcurrent)
+	}
+	l.buckets = make(map[string]*tokenBucket)
+}
+
+// acquire checks the rate limit of the identity and waits briefly for a subprocess slot.
+// The returned function releases the slot.
+func (l *subprocessLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	l.mu.Lock()
+	if err := l.take(key); err != nil {
+		l.mu.Unlock()
+		return nil, err
+	}
+	slots := l.slots
+	maxConcurrent := l.limits.MaxConcurrent
+	slotWait := l.slotWait
+	l.mu.Unlock()
+
+	if slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-slots }
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+
+	timer := time.NewTimer(slotWait)
+	defer timer.Stop()
+	select {
+	case slots <- struct{}{}:
+		return release, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("cancelled while waiting for a subprocess slot: %w", ctx.Err())
+	case <-timer.C:
+		return nil, newRateLimitError(RateLimitTypeConcurrency, slotWait,
+			fmt.Sprintf("the server is already running %d kubectl commands", maxConcurrent))
+	}
+}
+
+// take removes a token from the bucket of the identity; the caller holds l.mu
+func (l *subprocessLimiter) take(key string) error {
+	rate := l.limits.Rate
+	if rate <= 0 {
+		return nil
+	}
+	burst := float64(l.limits.Burst)
+	now := l.now()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		if len(l.buckets) >= rateLimitBucketsSize {
+			l.pruneBuckets(now)
+		}
+		bucket = &tokenBucket{tokens: burst, updated: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		retryAfter := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+		return newRateLimitError(RateLimitTypeRate, retryAfter,
+			fmt.Sprintf("more than %g kubectl commands per second", rate))
+	}
+	bucket.tokens--
+	return nil
+}
+
+// pruneBuckets drops the buckets that have refilled, which behave like new buckets.
+// If too many buckets remain, the fullest are evicted, which loses the least rate limit
+// state: a flood of new identities evicts its own buckets, never a throttled one.
+// The caller holds l.mu.
+func (l *subprocessLimiter) pruneBuckets(now time.Time) {
+	type candidate struct {
+		key    string
+		tokens float64
+	}
+	candidates := make([]candidate, 0, len(l.buckets))
+	for key, bucket := range l.buckets {
+		tokens := bucket.tokens + now.Sub(bucket.updated).Seconds()*l.limits.Rate
+		if tokens >= float64(l.limits.Burst) {
+			delete(l.buckets, key)
+			continue
+		}
+		candidates = append(candidates, candidate{key: key, tokens: tokens})
+	}
+	if len(l.buckets) < rateLimitBucketsSize {
+		return
+	}
+
+	// Evict an eighth of the buckets at once, so a flood does not scan them on every call
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].tokens > candidates[j].tokens })
+	for _, c := range candidates[:len(candidates)-rateLimitBucketsSize*7/8] {
+		delete(l.buckets, c.key)
+	}
+}
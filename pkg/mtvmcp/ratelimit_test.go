@@ -0,0 +1,147 @@
+package mtvmcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTokenBucket(t *testing.T) {
+	l := newSubprocessLimiter(Limits{Rate: 1, Burst: 2})
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	steps := []struct {
+		name           string
+		advance        time.Duration
+		key            string
+		wantRetryAfter int // 0 means allowed
+	}{
+		{"burst 1", 0, "alice", 0},
+		{"burst 2", 0, "alice", 0},
+		{"bucket empty", 0, "alice", 1},
+		{"other identity has its own bucket", 0, "bob", 0},
+		{"partially refilled", 500 * time.Millisecond, "alice", 1},
+		{"refilled", 500 * time.Millisecond, "alice", 0},
+		{"empty again", 0, "alice", 1},
+	}
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		release, err := l.acquire(context.Background(), step.key)
+		if step.wantRetryAfter == 0 {
+			if err != nil {
+				t.Fatalf("%s: unexpected error %v", step.name, err)
+			}
+			release()
+			continue
+		}
+		var rateErr *RateLimitError
+		if !errors.As(err, &rateErr) || rateErr.Type != RateLimitTypeRate || rateErr.RetryAfterSeconds != step.wantRetryAfter {
+			t.Fatalf("%s: expected a rate limit error with retry after %ds, got %v", step.name, step.wantRetryAfter, err)
+		}
+		if !strings.Contains(err.Error(), "rate limited, retry after 1 seconds") {
+			t.Errorf("%s: unexpected message %s", step.name, err)
+		}
+	}
+}
+
+func TestRateLimitKeyFlood(t *testing.T) {
+	l := newSubprocessLimiter(Limits{Rate: 0.001, Burst: 2})
+	now := time.Date(2026, 10, 1, 8, 0, 0, 0, time.UTC)
+	l.now = func() time.Time { return now }
+
+	// Throttle a client
+	for i := 0; i < 2; i++ {
+		release, err := l.acquire(context.Background(), "throttled")
+		if err != nil {
+			t.Fatal(err)
+		}
+		release()
+	}
+
+	// A flood of new identities, none of them refilled, fills the buckets several times
+	for i := 0; i < 3*rateLimitBucketsSize; i++ {
+		release, err := l.acquire(context.Background(), fmt.Sprintf("session-%d", i))
+		if err != nil {
+			t.Fatalf("Unexpected error for a new identity: %v", err)
+		}
+		release()
+	}
+	if len(l.buckets) > rateLimitBucketsSize {
+		t.Errorf("Expected at most %d buckets, got %d", rateLimitBucketsSize, len(l.buckets))
+	}
+
+	var rateErr *RateLimitError
+	if _, err := l.acquire(context.Background(), "throttled"); !errors.As(err, &rateErr) {
+		t.Errorf("Expected the throttled client to stay rate limited, got %v", err)
+	}
+}
+
+func TestRateLimitConcurrency(t *testing.T) {
+	l := newSubprocessLimiter(Limits{MaxConcurrent: 1})
+	l.slotWait = 10 * time.Millisecond
+
+	release, err := l.acquire(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = l.acquire(context.Background(), "")
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.Type != RateLimitTypeConcurrency {
+		t.Fatalf("Expected a concurrency limit error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.acquire(ctx, ""); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled wait, got %v", err)
+	}
+
+	release()
+	release, err = l.acquire(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Expected a free slot after release, got %v", err)
+	}
+	release()
+
+	unlimited := newSubprocessLimiter(Limits{})
+	for i := 0; i < 100; i++ {
+		if _, err := unlimited.acquire(context.Background(), ""); err != nil {
+			t.Fatalf("Expected no limit, got %v", err)
+		}
+	}
+}
+
+func TestRunCommandRateLimited(t *testing.T) {
+	setTestKubeconfig(t)
+	SetLimits(Limits{Rate: 0.001, Burst: 1})
+	t.Cleanup(func() { SetLimits(Limits{MaxConcurrent: DefaultMaxConcurrentSubprocesses}) })
+
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "plan"}, FakeResponse{Stdout: "[]"})
+	ctx := WithRateLimitKey(WithExecutor(context.Background(), fake), "alice")
+
+	if _, err := RunKubectlMTVCommand(ctx, []string{"get", "plan"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, err := RunKubectlMTVCommand(ctx, []string{"get", "plan"})
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) || !strings.Contains(err.Error(), `"error": "rate_limited"`) {
+		t.Fatalf("Expected a rate limited error, got %v", err)
+	}
+	if len(fake.Calls()) != 1 {
+		t.Errorf("Expected the rate limited command not to run, got %d calls", len(fake.Calls()))
+	}
+
+	// Other identities and dry runs are not affected
+	if _, err := RunKubectlMTVCommand(WithRateLimitKey(ctx, "bob"), []string{"get", "plan"}); err != nil {
+		t.Errorf("Unexpected error for another identity: %v", err)
+	}
+	if _, err := RunKubectlMTVCommand(WithDryRun(ctx, true), []string{"get", "plan"}); err != nil {
+		t.Errorf("Unexpected error in dry run: %v", err)
+	}
+}
@@ -0,0 +1,212 @@
+package mtvmcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fixture is a recorded command transcript entry.
+// The argv and output are redacted the same way as in tool responses,
+// so fixtures never hold passwords, tokens or secret data.
+type Fixture struct {
+	Command    string    `json:"command"`
+	Name       string    `json:"name"`
+	Args       []string  `json:"args"`
+	Stdout     string    `json:"stdout"`
+	Stderr     string    `json:"stderr"`
+	ExitCode   int       `json:"exit_code"`
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// fixtureKey returns the lookup key of a command, computed from its redacted argv
+func fixtureKey(name string, args []string) string {
+	return formatShellCommand(name, args)
+}
+
+// RecordingExecutor wraps an Executor and writes every executed command to a fixture file
+type RecordingExecutor struct {
+	next Executor
+	dir  string
+
+	mu  sync.Mutex
+	seq int
+}
+
+// NewRecordingExecutor creates a RecordingExecutor that writes fixtures to dir
+func NewRecordingExecutor(next Executor, dir string) (*RecordingExecutor, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create record directory %s: %w", dir, err)
+	}
+
+	// Continue numbering after fixtures already in the directory
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecordingExecutor{next: next, dir: dir, seq: len(files)}, nil
+}
+
+// Execute runs the command with the wrapped executor and records its result
+func (r *RecordingExecutor) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	result, err := r.next.Execute(ctx, cmd)
+	if err != nil {
+		// Cancelled or timed out commands have no reproducible result
+		return result, err
+	}
+
+	fixture := Fixture{
+		Command:    fixtureKey(cmd.Name, cmd.Args),
+		Name:       cmd.Name,
+		Args:       sanitizeArgs(cmd.Args),
+		Stdout:     redactOutput(result.Stdout),
+		Stderr:     redactOutput(result.Stderr),
+		ExitCode:   result.ExitCode,
+		RecordedAt: time.Now().UTC(),
+	}
+
+	if writeErr := r.write(fixture); writeErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to record command %s: %v\n", fixture.Command, writeErr)
+	}
+
+	return result, nil
+}
+
+// write stores a fixture in a new, sequentially numbered file
+func (r *RecordingExecutor) write(fixture Fixture) error {
+	data, err := json.MarshalIndent(fixture, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal fixture: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(fixture.Command))
+	r.seq++
+	name := fmt.Sprintf("%06d-%s.json", r.seq, hex.EncodeToString(sum[:])[:12])
+
+	return os.WriteFile(filepath.Join(r.dir, name), data, 0o600)
+}
+
+// ReplayExecutor serves commands from recorded fixtures without running anything.
+// Commands recorded several times are replayed in recording order; once exhausted,
+// the last recording is repeated.
+type ReplayExecutor struct {
+	mu       sync.Mutex
+	fixtures map[string][]Fixture
+	served   map[string]int
+}
+
+// NewReplayExecutor loads all fixtures from dir
+func NewReplayExecutor(dir string) (*ReplayExecutor, error) {
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no fixtures found in replay directory %s", dir)
+	}
+
+	r := &ReplayExecutor{
+		fixtures: make(map[string][]Fixture),
+		served:   make(map[string]int),
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read fixture %s: %w", file, err)
+		}
+
+		var fixture Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture %s: %w", file, err)
+		}
+
+		// Key by the redacted argv so fixtures can be edited by hand
+		key := fixture.Command
+		if fixture.Name != "" {
+			key = fixtureKey(fixture.Name, fixture.Args)
+		}
+		r.fixtures[key] = append(r.fixtures[key], fixture)
+	}
+
+	return r, nil
+}
+
+// Execute returns the recorded result for the command
+func (r *ReplayExecutor) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	return r.serve(ctx, cmd, true)
+}
+
+// serve returns the recorded result for the command; consume moves on to the
+// next recording of the command, so later calls see the recording order
+func (r *ReplayExecutor) serve(ctx context.Context, cmd Command, consume bool) (ExecResult, error) {
+	if err := ctx.Err(); err != nil {
+		return ExecResult{}, err
+	}
+
+	key := fixtureKey(cmd.Name, cmd.Args)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recorded := r.fixtures[key]
+	if len(recorded) == 0 {
+		return ExecResult{
+			ExitCode: 127,
+			Stderr:   fmt.Sprintf("replay: no recorded response for %s", key),
+		}, nil
+	}
+
+	index := min(r.served[key], len(recorded)-1)
+	if consume {
+		r.served[key]++
+	}
+
+	fixture := recorded[index]
+	return ExecResult{
+		Stdout:   fixture.Stdout,
+		Stderr:   fixture.Stderr,
+		ExitCode: fixture.ExitCode,
+	}, nil
+}
+
+// replayPeeker serves commands from a ReplayExecutor without consuming its recordings
+type replayPeeker struct {
+	replay *ReplayExecutor
+}
+
+// Execute returns the recorded result for the command
+func (p replayPeeker) Execute(ctx context.Context, cmd Command) (ExecResult, error) {
+	return p.replay.serve(ctx, cmd, false)
+}
+
+// fixtureFiles lists fixture files in dir in recording order
+func fixtureFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture directory %s: %w", dir, err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
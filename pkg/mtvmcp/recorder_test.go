@@ -0,0 +1,78 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+	setTestKubeconfig(t)
+
+	fake := NewFakeExecutor()
+	fake.On("kubectl-mtv", []string{"get", "plan", "-A", "-o", "json"}, FakeResponse{Stdout: `{"items":[]}`})
+	fake.On("kubectl", []string{"get", "pod", "-n", "demo", "missing", "-o", "json"}, FakeResponse{Stderr: "NotFound", ExitCode: 1})
+
+	recorder, err := NewRecordingExecutor(fake, dir)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+	recordCtx := WithKubeToken(WithExecutor(context.Background(), recorder), "secret-token")
+
+	if _, err := RunKubectlMTVCommand(recordCtx, []string{"get", "plan", "-A", "-o", "json"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := RunKubectlCommand(recordCtx, []string{"get", "pod", "-n", "demo", "missing", "-o", "json"}); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	files, err := fixtureFiles(dir)
+	if err != nil {
+		t.Fatalf("Failed to list fixtures: %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 fixtures, got %d", len(files))
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		if strings.Contains(string(data), "secret-token") {
+			t.Errorf("Fixture %s contains the token", file)
+		}
+	}
+
+	replay, err := NewReplayExecutor(dir)
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+	// Replay matches the redacted argv, so a different token still hits the fixture
+	replayCtx := WithKubeToken(WithExecutor(context.Background(), replay), "other-token")
+
+	out, err := RunKubectlMTVCommand(replayCtx, []string{"get", "plan", "-A", "-o", "json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if stdout := ExtractStdoutFromResponse(out); stdout != `{"items":[]}` {
+		t.Errorf("Unexpected replayed stdout %q", stdout)
+	}
+
+	out, err = RunKubectlCommand(replayCtx, []string{"get", "pod", "-n", "demo", "missing", "-o", "json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out, `"return_value": 1`) || !strings.Contains(out, "NotFound") {
+		t.Errorf("Expected replayed failure, got %s", out)
+	}
+
+	out, err = RunKubectlCommand(replayCtx, []string{"get", "pods"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "no recorded response") {
+		t.Errorf("Expected unrecorded command to fail, got %s", out)
+	}
+}
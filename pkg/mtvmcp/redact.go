@@ -0,0 +1,306 @@
+package mtvmcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+// RedactedValue replaces redacted values in echoed commands and command output
+const RedactedValue = "****"
+
+// RedactionRules configures what is redacted from echoed commands and command output
+type RedactionRules struct {
+	// Flags are the flags whose values are redacted, in argv and in output text
+	Flags []string `json:"flags,omitempty"`
+	// JSONPaths are dot-separated paths of values redacted from JSON output, matched
+	// relative to every object of the document. "*" matches any key or array element,
+	// "\." is a literal dot, and a "Kind:" prefix limits the path to objects of that
+	// kind, as in "Secret:data.*".
+	JSONPaths []string `json:"jsonPaths,omitempty"`
+	// Patterns are regular expressions redacted from output text. If a pattern has
+	// capture groups, the first group is kept and the rest of the match is redacted.
+	Patterns []string `json:"patterns,omitempty"`
+}
+
+// DefaultRedactionRules returns the rules applied unless the server is configured otherwise
+func DefaultRedactionRules() RedactionRules {
+	return RedactionRules{
+		Flags: []string{"--password", "--token", "--cacert"},
+		JSONPaths: []string{
+			"Secret:data.*",
+			"Secret:stringData.*",
+			`Secret:metadata.annotations.kubectl\.kubernetes\.io/last-applied-configuration`,
+			"TokenReview:spec.token",
+		},
+		Patterns: []string{
+			`(?i)(authorization:\s*(?:(?:bearer|basic|negotiate)\s+)?)[^\s"',]+`,
+			`(?i)(\bbearer\s+)[A-Za-z0-9\-._~+/]+=*`,
+			`-----BEGIN [A-Z0-9 ]+-----[\s\S]*?-----END [A-Z0-9 ]+-----`,
+		},
+	}
+}
+
+// Merge returns the rules with the flags, JSON paths and patterns of other added
+func (r RedactionRules) Merge(other RedactionRules) RedactionRules {
+	return RedactionRules{
+		Flags:     append(append([]string{}, r.Flags...), other.Flags...),
+		JSONPaths: append(append([]string{}, r.JSONPaths...), other.JSONPaths...),
+		Patterns:  append(append([]string{}, r.Patterns...), other.Patterns...),
+	}
+}
+
+// LoadRedactionRules reads redaction rules from a YAML or JSON file
+func LoadRedactionRules(path string) (RedactionRules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return RedactionRules{}, fmt.Errorf("failed to read redaction rules: %w", err)
+	}
+
+	var rules RedactionRules
+	if err := yaml.UnmarshalStrict(data, &rules); err != nil {
+		return RedactionRules{}, fmt.Errorf("failed to parse redaction rules: %w", err)
+	}
+	if _, err := newRedactor(rules); err != nil {
+		return RedactionRules{}, err
+	}
+	return rules, nil
+}
+
+// jsonPathRule is a parsed JSON path redaction rule
+type jsonPathRule struct {
+	kind string
+	path []string
+}
+
+// redactor applies compiled redaction rules
+type redactor struct {
+	flags    map[string]bool
+	paths    []jsonPathRule
+	patterns []*regexp.Regexp
+}
+
+// newRedactor compiles redaction rules; patterns matching the values of the flags are added
+func newRedactor(rules RedactionRules) (*redactor, error) {
+	r := &redactor{flags: make(map[string]bool)}
+
+	for _, flag := range rules.Flags {
+		flag = strings.TrimSpace(flag)
+		if flag == "" {
+			continue
+		}
+		if !strings.HasPrefix(flag, "-") {
+			flag = "--" + flag
+		}
+		if r.flags[flag] {
+			continue
+		}
+		r.flags[flag] = true
+		// Flag values in text, such as a command line in a log, unquoted or quoted
+		r.patterns = append(r.patterns, regexp.MustCompile(`(`+regexp.QuoteMeta(flag)+`(?:=|\s+))(?:"[^"]*"|'[^']*'|[^\s"']+)`))
+	}
+
+	for _, jsonPath := range rules.JSONPaths {
+		rule, err := parseJSONPathRule(jsonPath)
+		if err != nil {
+			return nil, err
+		}
+		r.paths = append(r.paths, rule)
+	}
+
+	for _, pattern := range rules.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern '%s': %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	return r, nil
+}
+
+// parseJSONPathRule parses a "[Kind:]a.b.c" JSON path rule, where "\." is a literal dot
+func parseJSONPathRule(jsonPath string) (jsonPathRule, error) {
+	var rule jsonPathRule
+	path := strings.TrimSpace(jsonPath)
+	if kind, rest, ok := strings.Cut(path, ":"); ok && !strings.ContainsAny(kind, `.\`) {
+		rule.kind, path = kind, rest
+	}
+
+	var segment strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch {
+		case path[i] == '\\' && i+1 < len(path) && path[i+1] == '.':
+			segment.WriteByte('.')
+			i++
+		case path[i] == '.':
+			rule.path = append(rule.path, segment.String())
+			segment.Reset()
+		default:
+			segment.WriteByte(path[i])
+		}
+	}
+	rule.path = append(rule.path, segment.String())
+
+	for _, segment := range rule.path {
+		if segment == "" {
+			return jsonPathRule{}, fmt.Errorf("invalid redaction JSON path '%s': empty path segment", jsonPath)
+		}
+	}
+	return rule, nil
+}
+
+var (
+	redactionMu     sync.RWMutex
+	defaultRedactor = mustNewRedactor(DefaultRedactionRules())
+)
+
+// mustNewRedactor compiles rules known to be valid
+func mustNewRedactor(rules RedactionRules) *redactor {
+	r, err := newRedactor(rules)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+// SetRedactionRules replaces the server-wide redaction rules
+func SetRedactionRules(rules RedactionRules) error {
+	r, err := newRedactor(rules)
+	if err != nil {
+		return err
+	}
+	redactionMu.Lock()
+	defer redactionMu.Unlock()
+	defaultRedactor = r
+	return nil
+}
+
+// getRedactor returns the server-wide redactor
+func getRedactor() *redactor {
+	redactionMu.RLock()
+	defer redactionMu.RUnlock()
+	return defaultRedactor
+}
+
+// redactOutput redacts secrets from command output with the server-wide rules
+func redactOutput(output string) string {
+	return getRedactor().redact(output)
+}
+
+// redact redacts the JSON paths of a JSON document, then the patterns of the text
+func (r *redactor) redact(output string) string {
+	if output == "" {
+		return output
+	}
+
+	if r.mayMatchJSON(output) {
+		trimmed := bytes.TrimSpace([]byte(output))
+		if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+			decoder := json.NewDecoder(bytes.NewReader(trimmed))
+			decoder.UseNumber()
+			var doc any
+			if decoder.Decode(&doc) == nil && !decoder.More() && r.redactJSON(doc) {
+				var buf bytes.Buffer
+				encoder := json.NewEncoder(&buf)
+				encoder.SetEscapeHTML(false)
+				encoder.SetIndent("", "  ")
+				if encoder.Encode(doc) == nil {
+					output = strings.TrimSuffix(buf.String(), "\n")
+				}
+			}
+		}
+	}
+
+	for _, re := range r.patterns {
+		replacement := RedactedValue
+		if re.NumSubexp() > 0 {
+			replacement = "${1}" + RedactedValue
+		}
+		output = re.ReplaceAllString(output, replacement)
+	}
+	return output
+}
+
+// mayMatchJSON reports whether a JSON path rule can apply to the output,
+// to avoid decoding large outputs that hold no object of a redacted kind
+func (r *redactor) mayMatchJSON(output string) bool {
+	for _, rule := range r.paths {
+		if rule.kind == "" || strings.Contains(output, `"`+rule.kind+`"`) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactJSON applies the JSON path rules to every object of the document,
+// returning true if a value was redacted
+func (r *redactor) redactJSON(node any) bool {
+	changed := false
+	switch n := node.(type) {
+	case map[string]any:
+		kind, _ := n["kind"].(string)
+		for _, rule := range r.paths {
+			if (rule.kind == "" || rule.kind == kind) && redactJSONPath(n, rule.path) {
+				changed = true
+			}
+		}
+		for _, value := range n {
+			if r.redactJSON(value) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, value := range n {
+			if r.redactJSON(value) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// redactJSONPath redacts the non-empty values at path below node, returning true if one was redacted
+func redactJSONPath(node any, path []string) bool {
+	redactValue := func(value any) (any, bool) {
+		if len(path) > 1 {
+			return value, redactJSONPath(value, path[1:])
+		}
+		if value == nil || value == "" || value == RedactedValue {
+			return value, false
+		}
+		return RedactedValue, true
+	}
+
+	changed := false
+	switch n := node.(type) {
+	case map[string]any:
+		for key, value := range n {
+			if path[0] != "*" && path[0] != key {
+				continue
+			}
+			if redacted, ok := redactValue(value); ok {
+				n[key] = redacted
+				changed = true
+			}
+		}
+	case []any:
+		for i, value := range n {
+			if path[0] != "*" && path[0] != strconv.Itoa(i) {
+				continue
+			}
+			if redacted, ok := redactValue(value); ok {
+				n[i] = redacted
+				changed = true
+			}
+		}
+	}
+	return changed
+}
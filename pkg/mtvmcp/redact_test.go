@@ -0,0 +1,156 @@
+package mtvmcp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRedactOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		rules    RedactionRules
+		output   string
+		contains []string
+		excludes []string
+	}{
+		{
+			name: "secret data",
+			output: `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "vsphere-creds", "annotations": {
+				"kubectl.kubernetes.io/last-applied-configuration": "{\"data\":{\"password\":\"c2VjcmV0\"}}"}},
+				"data": {"password": "c2VjcmV0", "user": "YWRtaW4=", "empty": ""}, "stringData": {"token": "plain"}}`,
+			contains: []string{`"name": "vsphere-creds"`, `"password": "****"`, `"user": "****"`, `"token": "****"`, `"empty": ""`},
+			excludes: []string{"c2VjcmV0", "YWRtaW4=", "plain"},
+		},
+		{
+			name: "secrets in a list",
+			output: `{"kind": "List", "items": [{"kind": "Secret", "data": {"password": "c2VjcmV0"}},
+				{"kind": "ConfigMap", "data": {"url": "https://vcenter"}}]}`,
+			contains: []string{`"url": "https://vcenter"`, `"password": "****"`},
+			excludes: []string{"c2VjcmV0"},
+		},
+		{
+			name:     "output without secrets is unchanged",
+			output:   `{"kind": "Plan", "data": {"count": 10000000000000000001}}`,
+			contains: []string{`{"kind": "Plan", "data": {"count": 10000000000000000001}}`},
+		},
+		{
+			name:     "authorization headers and bearer tokens in logs",
+			output:   "GET /api Authorization: Bearer abc.def-ghi\n{\"msg\": \"sending bearer eyJhbGciOi.payload.sig=\"}\nauthorization: xyz123",
+			contains: []string{"Authorization: Bearer ****", "bearer ****", "authorization: ****"},
+			excludes: []string{"abc.def-ghi", "eyJhbGciOi", "xyz123"},
+		},
+		{
+			name:     "PEM blocks",
+			output:   "cert: -----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIU\n-----END CERTIFICATE----- done",
+			contains: []string{"cert: **** done"},
+			excludes: []string{"MIIBszCCAVmgAwIBAgIU"},
+		},
+		{
+			name:     "sensitive flag values in text",
+			output:   `running: kubectl-mtv create provider --password=s3cret --token "a b" --username admin`,
+			contains: []string{"--password=****", "--token ****", "--username admin"},
+			excludes: []string{"s3cret", `"a b"`},
+		},
+		{
+			name:     "configured flags and patterns",
+			rules:    DefaultRedactionRules().Merge(RedactionRules{Flags: []string{"--username", "luks-secret"}, Patterns: []string{`api-key=\w+`}}),
+			output:   `--username admin --luks-secret luks-keys api-key=k123`,
+			contains: []string{"--username ****", "--luks-secret ****", "****"},
+			excludes: []string{"admin", "luks-keys", "k123"},
+		},
+		{
+			name:     "configured JSON paths",
+			rules:    RedactionRules{JSONPaths: []string{"spec.settings.*", "Provider:status.url"}},
+			output:   `[{"kind": "Provider", "spec": {"settings": {"sdkEndpoint": "vcenter"}}, "status": {"url": "https://x"}}]`,
+			contains: []string{`"sdkEndpoint": "****"`, `"url": "****"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := tt.rules
+			if rules.Flags == nil && rules.JSONPaths == nil && rules.Patterns == nil {
+				rules = DefaultRedactionRules()
+			}
+			r, err := newRedactor(rules)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			output := r.redact(tt.output)
+			for _, want := range tt.contains {
+				if !strings.Contains(output, want) {
+					t.Errorf("Expected output to contain %q, got:\n%s", want, output)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(output, unwanted) {
+					t.Errorf("Expected output not to contain %q, got:\n%s", unwanted, output)
+				}
+			}
+		})
+	}
+}
+
+func TestSanitizeArgsConfiguredFlags(t *testing.T) {
+	if err := SetRedactionRules(DefaultRedactionRules().Merge(RedactionRules{Flags: []string{"--username", "--luks-secret"}})); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetRedactionRules(DefaultRedactionRules()) })
+
+	command := formatShellCommand("kubectl-mtv", []string{
+		"create", "provider", "--username", "admin", "--password=secret", "--luks-secret", "keys", "--url", "https://vcenter",
+	})
+	expected := `kubectl-mtv create provider --username \*\*\*\* --password=\*\*\*\* --luks-secret \*\*\*\* --url https://vcenter`
+	if command != expected {
+		t.Errorf("Expected %s, got %s", expected, command)
+	}
+}
+
+func TestLoadRedactionRules(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	rules, err := LoadRedactionRules(write("rules.yaml", "flags: [--username]\njsonPaths: ['Provider:spec.settings.*']\npatterns: ['x-api-key:\\s*\\S+']\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(rules.Flags) != 1 || len(rules.JSONPaths) != 1 || len(rules.Patterns) != 1 {
+		t.Errorf("Unexpected rules %+v", rules)
+	}
+
+	for name, content := range map[string]string{
+		"bad-pattern.yaml": "patterns: ['(']\n",
+		"bad-path.yaml":    "jsonPaths: ['data..x']\n",
+		"unknown.yaml":     "flag: [--username]\n",
+	} {
+		if _, err := LoadRedactionRules(write(name, content)); err == nil {
+			t.Errorf("Expected %s to be rejected", name)
+		}
+	}
+}
+
+func TestRunCommandRedactsOutput(t *testing.T) {
+	fake := NewFakeExecutor()
+	fake.On("kubectl", []string{"get", "secret", "creds", "-o", "json"}, FakeResponse{
+		Stdout: `{"kind": "Secret", "data": {"password": "c2VjcmV0"}}`,
+		Stderr: "Authorization: Bearer sha256~token",
+	})
+
+	response, err := RunKubectlCommand(WithExecutor(context.Background(), fake), []string{"get", "secret", "creds", "-o", "json"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if strings.Contains(response, "c2VjcmV0") || strings.Contains(response, "sha256~token") {
+		t.Errorf("Expected secrets to be redacted, got %s", response)
+	}
+}
@@ -0,0 +1,118 @@
+package mtvmcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DNS-1123 and label value rules, as enforced by the Kubernetes API server
+var (
+	dns1123LabelRegexp     = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+	dns1123SubdomainRegexp = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+	labelValueRegexp       = regexp.MustCompile(`^(([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9])?$`)
+)
+
+// Maximum lengths of DNS-1123 names and label values
+const (
+	dns1123LabelMaxLength     = 63
+	dns1123SubdomainMaxLength = 253
+	labelValueMaxLength       = 63
+)
+
+// InvalidParamsError represents a structured error for parameters with invalid values
+type InvalidParamsError struct {
+	Error   string   `json:"error"`
+	Type    string   `json:"type"`
+	Message string   `json:"message"`
+	Invalid []string `json:"invalid_params"`
+}
+
+// IsDNS1123Label reports whether value is a valid DNS-1123 label, such as a namespace name
+func IsDNS1123Label(value string) bool {
+	return len(value) <= dns1123LabelMaxLength && dns1123LabelRegexp.MatchString(value)
+}
+
+// IsDNS1123Subdomain reports whether value is a valid DNS-1123 subdomain, such as a resource name
+func IsDNS1123Subdomain(value string) bool {
+	return len(value) <= dns1123SubdomainMaxLength && dns1123SubdomainRegexp.MatchString(value)
+}
+
+// ValidateNames validates that the namespace is a DNS-1123 label and that the resource
+// names are DNS-1123 subdomains. Empty values are skipped, required parameters are
+// checked by ValidateRequiredParams.
+func ValidateNames(namespace string, names map[string]string) error {
+	invalid := map[string]string{}
+	if namespace != "" && !IsDNS1123Label(namespace) {
+		invalid["namespace"] = namespace
+	}
+	for name, value := range names {
+		if value != "" && !IsDNS1123Subdomain(value) {
+			invalid[name] = value
+		}
+	}
+	return invalidParamsError("invalid_name", invalid,
+		"Invalid Kubernetes name(s), names must consist of lowercase alphanumeric characters, '-' or '.', and start and end with an alphanumeric character")
+}
+
+// ValidatePositionalArgs validates that free-form values passed as positional arguments
+// cannot be read as flags. Use ValidateNames for Kubernetes resource names.
+func ValidatePositionalArgs(params map[string]string) error {
+	invalid := map[string]string{}
+	for name, value := range params {
+		if strings.HasPrefix(value, "-") {
+			invalid[name] = value
+		}
+	}
+	return invalidParamsError("invalid_argument", invalid, "Invalid argument(s), values must not start with '-'")
+}
+
+// ValidateLabelValues validates that values used in label selectors are valid label values,
+// so that they cannot add requirements to the selector
+func ValidateLabelValues(params map[string]string) error {
+	invalid := map[string]string{}
+	for name, value := range params {
+		if len(value) > labelValueMaxLength || !labelValueRegexp.MatchString(value) {
+			invalid[name] = value
+		}
+	}
+	return invalidParamsError("invalid_label_value", invalid,
+		"Invalid label value(s), values must consist of alphanumeric characters, '-', '_' or '.', and start and end with an alphanumeric character")
+}
+
+// AddPositionalArgs appends the positional values to args after a "--" separator, so that
+// they are never parsed as flags. It must be called after all flags have been added.
+func AddPositionalArgs(args *[]string, values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	*args = append(*args, "--")
+	*args = append(*args, values...)
+}
+
+// invalidParamsError returns an InvalidParamsError for the invalid parameter values, or nil if there are none
+func invalidParamsError(errorType string, invalid map[string]string, message string) error {
+	if len(invalid) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(invalid))
+	for name := range invalid {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	details := make([]string, 0, len(names))
+	for _, name := range names {
+		details = append(details, fmt.Sprintf("%s '%s'", name, invalid[name]))
+	}
+	validationErr := InvalidParamsError{
+		Error:   "validation_error",
+		Type:    errorType,
+		Message: fmt.Sprintf("%s: %s", message, strings.Join(details, ", ")),
+		Invalid: names,
+	}
+	jsonData, _ := json.MarshalIndent(validationErr, "", "  ")
+	return fmt.Errorf("%s", string(jsonData))
+}
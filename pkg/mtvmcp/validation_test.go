@@ -0,0 +1,106 @@
+package mtvmcp
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestValidateNames(t *testing.T) {
+	tests := []struct {
+		name        string
+		namespace   string
+		names       map[string]string
+		wantInvalid []string
+	}{
+		{
+			name:      "valid names",
+			namespace: "demo",
+			names:     map[string]string{"plan_name": "my-plan.v2", "provider_name": "vsphere"},
+		},
+		{
+			name:  "empty values are skipped",
+			names: map[string]string{"plan_name": ""},
+		},
+		{
+			name:        "flag-like names",
+			names:       map[string]string{"plan_name": "--all", "provider_name": "-A"},
+			wantInvalid: []string{"plan_name", "provider_name"},
+		},
+		{
+			name:        "uppercase and spaces",
+			names:       map[string]string{"plan_name": "My Plan"},
+			wantInvalid: []string{"plan_name"},
+		},
+		{
+			name:        "namespace must be a label",
+			namespace:   "demo.example",
+			names:       map[string]string{"plan_name": "demo.example"},
+			wantInvalid: []string{"namespace"},
+		},
+		{
+			name:        "namespace too long",
+			namespace:   "a123456789012345678901234567890123456789012345678901234567890123",
+			wantInvalid: []string{"namespace"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateNames(tt.namespace, tt.names)
+			checkInvalidParams(t, err, "invalid_name", tt.wantInvalid)
+		})
+	}
+}
+
+func TestValidatePositionalArgs(t *testing.T) {
+	err := ValidatePositionalArgs(map[string]string{"vm_name": "Web VM (prod)", "host_name": "esxi-1.example.com"})
+	checkInvalidParams(t, err, "invalid_argument", nil)
+
+	err = ValidatePositionalArgs(map[string]string{"vm_name": "-web", "host_name": "--help"})
+	checkInvalidParams(t, err, "invalid_argument", []string{"host_name", "vm_name"})
+}
+
+func TestValidateLabelValues(t *testing.T) {
+	err := ValidateLabelValues(map[string]string{"plan_id": "0f7c4d52-8b0e-4c1a-9f3e-1234567890ab", "vm_id": "vm-47", "migration_id": ""})
+	checkInvalidParams(t, err, "invalid_label_value", nil)
+
+	err = ValidateLabelValues(map[string]string{"plan_id": "a,vmID!=b", "vm_id": "vm 47", "migration_id": "-m"})
+	checkInvalidParams(t, err, "invalid_label_value", []string{"migration_id", "plan_id", "vm_id"})
+}
+
+func TestAddPositionalArgs(t *testing.T) {
+	args := []string{"delete", "plan", "-n", "demo"}
+	AddPositionalArgs(&args)
+	AddPositionalArgs(&args, "--all", "p")
+
+	expected := []string{"delete", "plan", "-n", "demo", "--", "--all", "p"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected %v, got %v", expected, args)
+	}
+}
+
+// checkInvalidParams verifies that err is an InvalidParamsError of the given type listing wantInvalid,
+// or nil if wantInvalid is empty
+func checkInvalidParams(t *testing.T, err error, errorType string, wantInvalid []string) {
+	t.Helper()
+	if len(wantInvalid) == 0 {
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return
+	}
+	if err == nil {
+		t.Fatalf("Expected an error for %v", wantInvalid)
+	}
+	var validationErr InvalidParamsError
+	if jsonErr := json.Unmarshal([]byte(err.Error()), &validationErr); jsonErr != nil {
+		t.Fatalf("Expected a JSON error, got %v", err)
+	}
+	if validationErr.Error != "validation_error" || validationErr.Type != errorType {
+		t.Errorf("Expected a %s validation error, got %+v", errorType, validationErr)
+	}
+	if !reflect.DeepEqual(validationErr.Invalid, wantInvalid) {
+		t.Errorf("Expected invalid params %v, got %v", wantInvalid, validationErr.Invalid)
+	}
+}